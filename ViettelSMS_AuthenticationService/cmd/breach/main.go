@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/breach"
+)
+
+var (
+	flags = flag.NewFlagSet("breach", flag.ExitOnError)
+
+	kind      = flags.String("kind", "sha1", "hash kind of the input lists: sha1 or ntlm (build)")
+	out       = flags.String("out", "", "corpus file to write, defaults to BREACHED_PASSWORD_FILE (build)")
+	minCount  = flags.Int64("min-count", 0, "skip hashes seen fewer times than this in HASH:COUNT lines (build)")
+	chunkSize = flags.Int("chunk", 1<<24, "number of hashes sorted in memory at a time (build)")
+	tempDir   = flags.String("tmp", "", "directory for intermediate sorted chunks, defaults to the output directory (build)")
+)
+
+func main() {
+	flags.Usage = usage
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		flags.Usage()
+		return
+	}
+
+	cfg := config.LoadConfig()
+
+	switch args[0] {
+	case "build":
+		if err := build(cfg, args[1:]); err != nil {
+			log.Fatalf("breach build: %v", err)
+		}
+	case "check":
+		if err := check(cfg); err != nil {
+			log.Fatalf("breach check: %v", err)
+		}
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+}
+
+func build(cfg *config.Config, files []string) error {
+	hashKind, err := breach.ParseKind(*kind)
+	if err != nil {
+		return err
+	}
+
+	target := *out
+	if target == "" {
+		target = cfg.BreachedPassword.File
+	}
+	if target == "" {
+		return errors.New("-out or BREACHED_PASSWORD_FILE is required")
+	}
+
+	inputs := []io.Reader{}
+	if len(files) == 0 {
+		inputs = append(inputs, os.Stdin)
+	}
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		inputs = append(inputs, file)
+	}
+
+	stats, err := breach.Build(target, inputs, breach.BuildOptions{
+		Kind:      hashKind,
+		MinCount:  *minCount,
+		ChunkSize: *chunkSize,
+		TempDir:   *tempDir,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Read %d hashes, skipped %d lines\n", stats.Read, stats.Skipped)
+	fmt.Printf("Wrote %d unique %s hashes to %s\n", stats.Written, hashKind, target)
+	return nil
+}
+
+// check reads passwords from stdin, one per line, and reports which of them
+// are in the configured corpus.
+func check(cfg *config.Config) error {
+	checker, err := breach.NewBreachedPasswordChecker(cfg)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		breached, err := checker.IsBreached(scanner.Text())
+		if err != nil {
+			return err
+		}
+		status := "ok"
+		if breached {
+			status = "breached"
+		}
+		fmt.Printf("%s\t%s\n", status, scanner.Text())
+	}
+	return scanner.Err()
+}
+
+func usage() {
+	fmt.Println(usagePrefix)
+	flags.PrintDefaults()
+	fmt.Println(usageCommands)
+}
+
+var (
+	usagePrefix = `Usage: breach [OPTIONS] COMMAND [FILE...]
+Examples:
+    breach -out data/breached.bin build pwned-passwords-sha1-ordered-by-hash-v8.txt
+    breach -kind ntlm -min-count 10 build pwned-passwords-ntlm.txt
+    echo 'P@ssw0rd' | breach check
+`
+
+	usageCommands = `
+Commands:
+    build                Build a sorted corpus from hex hash lists (stdin when no FILE is given)
+    check                Look up passwords read from stdin in BREACHED_PASSWORD_FILE`
+)
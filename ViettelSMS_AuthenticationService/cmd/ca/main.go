@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/postgres"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/repository"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	caOrganization    = "ViettelSMS"
+	agentOrganization = "ViettelSMS Agents"
+)
+
+var (
+	flags = flag.NewFlagSet("ca", flag.ExitOnError)
+
+	commonName = flags.String("cn", "ViettelSMS Agent CA", "common name of the CA certificate (init)")
+	caDays     = flags.Int("days", 3650, "validity of the CA certificate in days (init)")
+	name       = flags.String("name", "", "machine identity name, used as the certificate common name (issue)")
+	scopes     = flags.String("scopes", "", "comma separated scopes granted to the machine identity (issue)")
+	outDir     = flags.String("out", "certs", "directory the agent certificate and key are written to (issue)")
+	register   = flags.Bool("register", false, "register the issued certificate as a machine identity (issue)")
+	pin        = flags.Bool("pin", true, "pin the identity to the certificate fingerprint instead of its subject (issue)")
+)
+
+func main() {
+	flags.Usage = usage
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		flags.Usage()
+		return
+	}
+
+	cfg := config.LoadConfig()
+
+	switch args[0] {
+	case "init":
+		if err := initCA(cfg); err != nil {
+			log.Fatalf("ca init: %v", err)
+		}
+	case "issue":
+		if err := issue(cfg); err != nil {
+			log.Fatalf("ca issue: %v", err)
+		}
+	default:
+		flags.Usage()
+		os.Exit(1)
+	}
+}
+
+func initCA(cfg *config.Config) error {
+	if _, err := os.Stat(cfg.CA.KeyFile); err == nil {
+		return fmt.Errorf("CA key %s already exists", cfg.CA.KeyFile)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: *commonName, Organization: []string{caOrganization}},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.AddDate(0, 0, *caDays),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	if err := writeKeyPair(cfg.CA.CertFile, cfg.CA.KeyFile, der, key); err != nil {
+		return err
+	}
+
+	fmt.Printf("CA certificate written to %s\n", cfg.CA.CertFile)
+	fmt.Printf("CA key written to %s\n", cfg.CA.KeyFile)
+	return nil
+}
+
+func issue(cfg *config.Config) error {
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+
+	caCert, caKey, err := loadCA(cfg)
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := newSerial()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: *name, Organization: []string{agentOrganization}},
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(cfg.CA.AgentCertTTL),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+
+	certFile := filepath.Join(*outDir, *name+".crt")
+	keyFile := filepath.Join(*outDir, *name+".key")
+	if err := writeKeyPair(certFile, keyFile, der, key); err != nil {
+		return err
+	}
+
+	fingerprint := utils.CertThumbprint(cert)
+	fmt.Printf("Agent certificate written to %s\n", certFile)
+	fmt.Printf("Agent key written to %s\n", keyFile)
+	fmt.Printf("Subject: %s\n", cert.Subject.String())
+	fmt.Printf("Fingerprint (x5t#S256): %s\n", fingerprint)
+
+	if !*register {
+		return nil
+	}
+
+	identity := &entity.MachineIdentity{
+		Name:    *name,
+		Subject: cert.Subject.String(),
+		Scopes:  splitScopes(*scopes),
+	}
+	if *pin {
+		identity.Fingerprint = fingerprint
+	}
+
+	db, err := postgres.NewPostgresDB(cfg, zap.NewNop())
+	if err != nil {
+		return err
+	}
+
+	if err := repository.NewRepository(db).CreateMachineIdentity(context.Background(), identity); err != nil {
+		return fmt.Errorf("failed to register machine identity: %w", err)
+	}
+
+	fmt.Printf("Machine identity %q registered with scopes %v\n", identity.Name, []string(identity.Scopes))
+	return nil
+}
+
+func loadCA(cfg *config.Config) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(cfg.CA.CertFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM data in %s", cfg.CA.CertFile)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPEM, err := os.ReadFile(cfg.CA.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA key: %w", err)
+	}
+	block, _ = pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("no PEM data in %s", cfg.CA.KeyFile)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, key, nil
+}
+
+func writeKeyPair(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	if err := os.MkdirAll(filepath.Dir(certFile), 0755); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certFile, certPEM, 0644); err != nil {
+		return err
+	}
+
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return os.WriteFile(keyFile, keyPEM, 0600)
+}
+
+func newSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func splitScopes(raw string) []string {
+	result := []string{}
+	for _, scope := range strings.Split(raw, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
+func usage() {
+	fmt.Println(usagePrefix)
+	flags.PrintDefaults()
+	fmt.Println(usageCommands)
+}
+
+var (
+	usagePrefix = `Usage: ca [OPTIONS] COMMAND
+Examples:
+    ca init
+    ca -name web-01 -scopes metrics:write -register issue
+`
+
+	usageCommands = `
+Commands:
+    init                 Create the internal agent CA key and certificate
+    issue                Issue a client certificate for a monitored server`
+)
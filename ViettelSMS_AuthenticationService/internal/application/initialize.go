@@ -1,18 +1,33 @@
 package application
 
 import (
+	"fmt"
+
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/consumer"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/controller"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/middleware"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/presenter"
-	password "github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/bcrypt"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/abac"
+	argon2Password "github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/argon2"
+	bcryptPassword "github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/bcrypt"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/breach"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/captcha"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/geoip"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/hasher"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/ipaccess"
 	consumerGroup "github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/kafka/consumer"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/kafka/producer"
 	log "github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/logger"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/mailer"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/pepper"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/policy"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/postgres"
 	rdb "github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/redis"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/repository"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/scope"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/usecases/auth"
 )
 
@@ -29,27 +44,81 @@ func InitApp() (*Application, error) {
 		return nil, err
 	}
 
-	rdb, err := rdb.NewRedisDB(config)
+	cache, err := rdb.NewRedisDB(config)
+	if err != nil {
+		return nil, err
+	}
+
+	broker, err := producer.NewBroker(config, logger)
 	if err != nil {
 		return nil, err
 	}
 
 	repo := repository.NewRepository(db)
+	sessions := rdb.NewSessionStore(cache)
+	mailSrv := mailer.NewSMTPMailer(config)
+
+	passwordSrv, err := newPasswordService(config)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordPolicy := policy.NewPasswordPolicy(config)
+
+	breachedPasswords, err := breach.NewBreachedPasswordChecker(config)
+	if err != nil {
+		return nil, err
+	}
+
+	captchaVerifier, err := captcha.NewCaptchaVerifier(config)
+	if err != nil {
+		return nil, err
+	}
+
+	geoIP, err := geoip.NewGeoIPLocator(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ipAccess := ipaccess.NewIPAccessList(repo, config.IPAccess.Refresh, logger)
+
+	scopeMatcher, err := scope.LoadMatcher(config.Scopes.File)
+	if err != nil {
+		return nil, err
+	}
+
+	policyEngine, err := abac.LoadEngine(config.Authorization.PolicyFile, scopeMatcher)
+	if err != nil {
+		return nil, err
+	}
 
-	passwordSrv := password.NewBcryptService()
 	usecase := auth.NewUseCase(
 		repo,
 		passwordSrv,
-		rdb,
-		config.JWT.Secret,
+		passwordPolicy,
+		breachedPasswords,
+		captchaVerifier,
+		geoIP,
+		ipAccess,
+		scopeMatcher,
+		policyEngine,
+		cache,
+		sessions,
+		broker,
+		mailSrv,
+		config,
 		logger,
 	)
 
 	presenter := presenter.NewPresenter()
-	middleware := middleware.NewJWTMiddleware(presenter, []byte(config.JWT.Secret))
+	rateLimit, err := middleware.NewRateLimitMiddleware(presenter, rdb.NewRateLimiter(cache), config.RateLimit.Rules, logger)
+	if err != nil {
+		return nil, err
+	}
+	middleware := middleware.NewJWTMiddleware(presenter, sessions, ipAccess, scopeMatcher, []byte(config.JWT.Secret))
 	controller := controller.NewController(logger, usecase, presenter)
 
-	httpServer := http.NewHttpServer(config, controller, middleware, logger)
+	httpServer := http.NewHttpServer(config, controller, middleware, rateLimit, logger)
 
 	userConsumer, err := consumerGroup.NewConsumer(
 		config,
@@ -63,12 +132,62 @@ func InitApp() (*Application, error) {
 		return nil, err
 	}
 
+	serverConsumer, err := consumerGroup.NewConsumer(
+		config,
+		logger,
+		config.Consumer.ServerAuth,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	serverHandler := consumer.NewServerHandler(logger, usecase)
+
 	rootConsumer := consumer.NewRoot(
 		logger,
 		userConsumer,
 		userHandler,
+		serverConsumer,
+		serverHandler,
 	)
 
 	app := NewApplication(httpServer, rootConsumer, logger)
 	return app, nil
 }
+
+// newPasswordService hashes with the configured algorithm and keeps the other
+// one around for verifying hashes that have not been migrated yet. When pepper
+// keys are configured every hash is peppered on top.
+func newPasswordService(config *config.Config) (srv.PasswordService, error) {
+	bcryptSrv := bcryptPassword.NewBcryptService(config.Password.BcryptCost, config.Password.BcryptMaxCost)
+	argon2Srv := argon2Password.NewArgon2Service(argon2Password.Params{
+		Memory:      config.Password.Argon2Memory,
+		Time:        config.Password.Argon2Time,
+		Parallelism: config.Password.Argon2Parallelism,
+		SaltLength:  config.Password.Argon2SaltLength,
+		KeyLength:   config.Password.Argon2KeyLength,
+	}, argon2Password.Limits{
+		MaxMemory:      config.Password.Argon2MaxMemory,
+		MaxTime:        config.Password.Argon2MaxTime,
+		MaxParallelism: config.Password.Argon2MaxParallelism,
+	})
+
+	var passwordSrv srv.PasswordService
+	switch config.Password.Algorithm {
+	case "argon2id":
+		passwordSrv = hasher.NewMultiHasher(argon2Srv, bcryptSrv)
+	case "bcrypt":
+		passwordSrv = hasher.NewMultiHasher(bcryptSrv, argon2Srv)
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm: %s", config.Password.Algorithm)
+	}
+
+	keys, current, err := pepper.LoadKeys(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return passwordSrv, nil
+	}
+	return pepper.NewPepperedService(passwordSrv, keys, current)
+}
@@ -1,6 +1,9 @@
 package config
 
 import (
+	"strings"
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -8,6 +11,10 @@ type (
 	Server struct {
 		Host string
 		Port int
+		// TrustedProxies are the addresses or CIDRs of reverse proxies
+		// (Traefik) allowed to set X-Forwarded-For. Without any, the client
+		// IP is the address of the TCP peer.
+		TrustedProxies []string
 	}
 
 	Postgres struct {
@@ -46,18 +53,157 @@ type (
 	}
 
 	Consumer struct {
-		UserAuth string
+		UserAuth   string
+		ServerAuth string
+	}
+
+	TLS struct {
+		Enabled      bool
+		CertFile     string
+		KeyFile      string
+		ClientCAFile string
+		ClientAuth   string
+	}
+
+	CA struct {
+		CertFile     string
+		KeyFile      string
+		AgentCertTTL time.Duration
+	}
+
+	Device struct {
+		VerificationURI string
+		CodeTTL         time.Duration
+		PollInterval    time.Duration
+		// ClientIDs lists the clients allowed to start a device grant.
+		ClientIDs []string
+	}
+
+	SMTP struct {
+		Host     string
+		Port     int
+		Username string
+		Password string
+		From     string
+	}
+
+	PasswordReset struct {
+		URL      string
+		TokenTTL time.Duration
+	}
+
+	Password struct {
+		Algorithm         string
+		BcryptCost        int
+		Argon2Memory      uint32
+		Argon2Time        uint32
+		Argon2Parallelism uint8
+		Argon2SaltLength  uint32
+		Argon2KeyLength   uint32
+		// Limits for hashes arriving in user events, which are stored and
+		// verified as they are.
+		BcryptMaxCost        int
+		Argon2MaxMemory      uint32
+		Argon2MaxTime        uint32
+		Argon2MaxParallelism uint8
+		PepperFile           string
+		PepperKeys           string
+		PepperVersion        int
+	}
+
+	PasswordPolicy struct {
+		MinLength      int
+		MaxLength      int
+		MinCharClasses int
+		MaxRepeat      int
+		BannedWords    []string
+		MinScore       int
+		HistorySize    int
+		MinAge         time.Duration
+		MaxAge         time.Duration
+	}
+
+	BreachedPassword struct {
+		File         string
+		CheckOnLogin bool
+	}
+
+	Lockout struct {
+		MaxAttempts  int
+		Window       time.Duration
+		BaseDuration time.Duration
+		MaxDuration  time.Duration
+		Decay        time.Duration
+	}
+
+	RateLimit struct {
+		Rules string
+	}
+
+	Challenge struct {
+		Mode             string
+		AfterFailures    int
+		Window           time.Duration
+		PowDifficulty    int
+		PowTTL           time.Duration
+		CaptchaProvider  string
+		CaptchaVerifyURL string
+		CaptchaSecret    string
+		CaptchaSiteKey   string
+		CaptchaStubToken string
+	}
+
+	Scopes struct {
+		File string
+	}
+
+	Authorization struct {
+		PolicyFile string
+		CacheTTL   time.Duration
+	}
+
+	IPAccess struct {
+		Refresh time.Duration
+	}
+
+	Risk struct {
+		GeoIPFile         string
+		NewDeviceScore    int
+		NewCountryScore   int
+		TravelScore       int
+		MaxTravelSpeedKmh float64
+		StepUpScore       int
+		BlockScore        int
+		OTPTTL            time.Duration
+		OTPMaxAttempts    int
+		NotifyNewDevice   bool
 	}
 )
 
 type Config struct {
-	Server   Server
-	Postgres Postgres
-	Logger   Logger
-	Redis    Redis
-	Kafka    Kafka
-	Consumer Consumer
-	JWT      JWT
+	Server         Server
+	Postgres       Postgres
+	Logger         Logger
+	Redis          Redis
+	Kafka          Kafka
+	Consumer       Consumer
+	JWT            JWT
+	TLS            TLS
+	CA             CA
+	Device         Device
+	SMTP           SMTP
+	PasswordReset  PasswordReset
+	Password       Password
+	PasswordPolicy PasswordPolicy
+
+	BreachedPassword BreachedPassword
+	Lockout          Lockout
+	RateLimit        RateLimit
+	Challenge        Challenge
+	Risk             Risk
+	IPAccess         IPAccess
+	Scopes           Scopes
+	Authorization    Authorization
 }
 
 func LoadConfig() *Config {
@@ -71,6 +217,9 @@ func LoadConfig() *Config {
 	serverEnv := Server{
 		Host: viper.GetString("SERVER_HOST"),
 		Port: viper.GetInt("SERVER_PORT"),
+		TrustedProxies: strings.FieldsFunc(viper.GetString("TRUSTED_PROXIES"), func(r rune) bool {
+			return r == ',' || r == ' '
+		}),
 	}
 
 	// postgres env
@@ -135,17 +284,253 @@ func LoadConfig() *Config {
 		Secret: viper.GetString("JWT_SECRET"),
 	}
 
+	viper.SetDefault("SERVER_AUTH_CONSUMER_GROUP", "server-auth-consumer-group")
 	consumerEnv := Consumer{
-		UserAuth: viper.GetString("USER_AUTH_CONSUMER_GROUP"),
+		UserAuth:   viper.GetString("USER_AUTH_CONSUMER_GROUP"),
+		ServerAuth: viper.GetString("SERVER_AUTH_CONSUMER_GROUP"),
+	}
+
+	// tls env
+	viper.SetDefault("TLS_ENABLED", false)
+	viper.SetDefault("TLS_CERT_FILE", "./certs/server.crt")
+	viper.SetDefault("TLS_KEY_FILE", "./certs/server.key")
+	viper.SetDefault("TLS_CLIENT_CA_FILE", "./certs/agent-ca.crt")
+	viper.SetDefault("TLS_CLIENT_AUTH", "request")
+
+	tlsEnv := TLS{
+		Enabled:      viper.GetBool("TLS_ENABLED"),
+		CertFile:     viper.GetString("TLS_CERT_FILE"),
+		KeyFile:      viper.GetString("TLS_KEY_FILE"),
+		ClientCAFile: viper.GetString("TLS_CLIENT_CA_FILE"),
+		ClientAuth:   viper.GetString("TLS_CLIENT_AUTH"),
+	}
+
+	// internal agent CA env
+	viper.SetDefault("CA_CERT_FILE", "./certs/agent-ca.crt")
+	viper.SetDefault("CA_KEY_FILE", "./certs/agent-ca.key")
+	viper.SetDefault("CA_AGENT_CERT_TTL", "8760h")
+
+	caEnv := CA{
+		CertFile:     viper.GetString("CA_CERT_FILE"),
+		KeyFile:      viper.GetString("CA_KEY_FILE"),
+		AgentCertTTL: viper.GetDuration("CA_AGENT_CERT_TTL"),
+	}
+
+	// device authorization grant env
+	viper.SetDefault("DEVICE_VERIFICATION_URI", "http://localhost/device")
+	viper.SetDefault("DEVICE_CODE_TTL", "10m")
+	viper.SetDefault("DEVICE_POLL_INTERVAL", "5s")
+	viper.SetDefault("DEVICE_CLIENT_IDS", "vcs-cli")
+
+	deviceEnv := Device{
+		VerificationURI: viper.GetString("DEVICE_VERIFICATION_URI"),
+		CodeTTL:         viper.GetDuration("DEVICE_CODE_TTL"),
+		PollInterval:    viper.GetDuration("DEVICE_POLL_INTERVAL"),
+		ClientIDs: strings.FieldsFunc(viper.GetString("DEVICE_CLIENT_IDS"), func(r rune) bool {
+			return r == ',' || r == ' '
+		}),
+	}
+
+	// smtp env
+	viper.SetDefault("SMTP_HOST", "localhost")
+	viper.SetDefault("SMTP_PORT", 587)
+	viper.SetDefault("SMTP_USERNAME", "")
+	viper.SetDefault("SMTP_PASSWORD", "")
+	viper.SetDefault("SMTP_FROM", "no-reply@vcs-sms.local")
+
+	smtpEnv := SMTP{
+		Host:     viper.GetString("SMTP_HOST"),
+		Port:     viper.GetInt("SMTP_PORT"),
+		Username: viper.GetString("SMTP_USERNAME"),
+		Password: viper.GetString("SMTP_PASSWORD"),
+		From:     viper.GetString("SMTP_FROM"),
+	}
+
+	// password reset env
+	viper.SetDefault("PASSWORD_RESET_URL", "http://localhost/reset-password")
+	viper.SetDefault("PASSWORD_RESET_TOKEN_TTL", "30m")
+
+	passwordResetEnv := PasswordReset{
+		URL:      viper.GetString("PASSWORD_RESET_URL"),
+		TokenTTL: viper.GetDuration("PASSWORD_RESET_TOKEN_TTL"),
+	}
+
+	// password hashing env
+	viper.SetDefault("PASSWORD_ALGORITHM", "argon2id")
+	viper.SetDefault("PASSWORD_BCRYPT_COST", 10)
+	viper.SetDefault("PASSWORD_ARGON2_MEMORY", 64*1024)
+	viper.SetDefault("PASSWORD_ARGON2_TIME", 3)
+	viper.SetDefault("PASSWORD_ARGON2_PARALLELISM", 2)
+	viper.SetDefault("PASSWORD_ARGON2_SALT_LENGTH", 16)
+	viper.SetDefault("PASSWORD_ARGON2_KEY_LENGTH", 32)
+	viper.SetDefault("PASSWORD_BCRYPT_MAX_COST", 14)
+	viper.SetDefault("PASSWORD_ARGON2_MAX_MEMORY", 256*1024)
+	viper.SetDefault("PASSWORD_ARGON2_MAX_TIME", 10)
+	viper.SetDefault("PASSWORD_ARGON2_MAX_PARALLELISM", 8)
+
+	passwordEnv := Password{
+		Algorithm:            viper.GetString("PASSWORD_ALGORITHM"),
+		BcryptCost:           viper.GetInt("PASSWORD_BCRYPT_COST"),
+		Argon2Memory:         viper.GetUint32("PASSWORD_ARGON2_MEMORY"),
+		Argon2Time:           viper.GetUint32("PASSWORD_ARGON2_TIME"),
+		Argon2Parallelism:    uint8(viper.GetUint("PASSWORD_ARGON2_PARALLELISM")),
+		Argon2SaltLength:     viper.GetUint32("PASSWORD_ARGON2_SALT_LENGTH"),
+		Argon2KeyLength:      viper.GetUint32("PASSWORD_ARGON2_KEY_LENGTH"),
+		BcryptMaxCost:        viper.GetInt("PASSWORD_BCRYPT_MAX_COST"),
+		Argon2MaxMemory:      viper.GetUint32("PASSWORD_ARGON2_MAX_MEMORY"),
+		Argon2MaxTime:        viper.GetUint32("PASSWORD_ARGON2_MAX_TIME"),
+		Argon2MaxParallelism: uint8(viper.GetUint("PASSWORD_ARGON2_MAX_PARALLELISM")),
+		PepperFile:           viper.GetString("PASSWORD_PEPPER_FILE"),
+		PepperKeys:           viper.GetString("PASSWORD_PEPPER_KEYS"),
+		PepperVersion:        viper.GetInt("PASSWORD_PEPPER_VERSION"),
+	}
+
+	// password policy env
+	viper.SetDefault("PASSWORD_POLICY_MIN_LENGTH", 8)
+	viper.SetDefault("PASSWORD_POLICY_MAX_LENGTH", 128)
+	viper.SetDefault("PASSWORD_POLICY_MIN_CHAR_CLASSES", 3)
+	viper.SetDefault("PASSWORD_POLICY_MAX_REPEAT", 3)
+	viper.SetDefault("PASSWORD_POLICY_BANNED_WORDS", []string{"viettel", "vcs", "sms", "password", "admin"})
+	viper.SetDefault("PASSWORD_POLICY_MIN_SCORE", 2)
+	viper.SetDefault("PASSWORD_POLICY_HISTORY_SIZE", 5)
+	viper.SetDefault("PASSWORD_POLICY_MIN_AGE", "24h")
+	viper.SetDefault("PASSWORD_POLICY_MAX_AGE", "2160h")
+
+	passwordPolicyEnv := PasswordPolicy{
+		MinLength:      viper.GetInt("PASSWORD_POLICY_MIN_LENGTH"),
+		MaxLength:      viper.GetInt("PASSWORD_POLICY_MAX_LENGTH"),
+		MinCharClasses: viper.GetInt("PASSWORD_POLICY_MIN_CHAR_CLASSES"),
+		MaxRepeat:      viper.GetInt("PASSWORD_POLICY_MAX_REPEAT"),
+		BannedWords:    viper.GetStringSlice("PASSWORD_POLICY_BANNED_WORDS"),
+		MinScore:       viper.GetInt("PASSWORD_POLICY_MIN_SCORE"),
+		HistorySize:    viper.GetInt("PASSWORD_POLICY_HISTORY_SIZE"),
+		MinAge:         viper.GetDuration("PASSWORD_POLICY_MIN_AGE"),
+		MaxAge:         viper.GetDuration("PASSWORD_POLICY_MAX_AGE"),
+	}
+
+	// breached password env
+	viper.SetDefault("BREACHED_PASSWORD_CHECK_ON_LOGIN", false)
+
+	breachedPasswordEnv := BreachedPassword{
+		File:         viper.GetString("BREACHED_PASSWORD_FILE"),
+		CheckOnLogin: viper.GetBool("BREACHED_PASSWORD_CHECK_ON_LOGIN"),
+	}
+
+	// lockout env
+	viper.SetDefault("LOCKOUT_MAX_ATTEMPTS", 5)
+	viper.SetDefault("LOCKOUT_WINDOW", "15m")
+	viper.SetDefault("LOCKOUT_BASE_DURATION", "1m")
+	viper.SetDefault("LOCKOUT_MAX_DURATION", "24h")
+	viper.SetDefault("LOCKOUT_DECAY", "24h")
+
+	lockoutEnv := Lockout{
+		MaxAttempts:  viper.GetInt("LOCKOUT_MAX_ATTEMPTS"),
+		Window:       viper.GetDuration("LOCKOUT_WINDOW"),
+		BaseDuration: viper.GetDuration("LOCKOUT_BASE_DURATION"),
+		MaxDuration:  viper.GetDuration("LOCKOUT_MAX_DURATION"),
+		Decay:        viper.GetDuration("LOCKOUT_DECAY"),
+	}
+
+	// rate limit env
+	viper.SetDefault("RATE_LIMIT_RULES", "login:ip=20/1m,login:username=10/1m,token:client_id=60/1m,"+
+		"login_verify:ip=10/1m,device_code:ip=10/1m,challenge:ip=30/1m,password_forgot:ip=5/15m,password_reset:ip=10/15m,password_change:ip=10/15m")
+
+	rateLimitEnv := RateLimit{
+		Rules: viper.GetString("RATE_LIMIT_RULES"),
+	}
+
+	// challenge env
+	viper.SetDefault("CHALLENGE_MODE", "pow")
+	viper.SetDefault("CHALLENGE_AFTER_FAILURES", 3)
+	viper.SetDefault("CHALLENGE_WINDOW", "15m")
+	viper.SetDefault("CHALLENGE_POW_DIFFICULTY", 20)
+	viper.SetDefault("CHALLENGE_POW_TTL", "2m")
+	viper.SetDefault("CHALLENGE_CAPTCHA_PROVIDER", "stub")
+	viper.SetDefault("CHALLENGE_CAPTCHA_STUB_TOKEN", "captcha-stub-pass")
+
+	challengeEnv := Challenge{
+		Mode:             viper.GetString("CHALLENGE_MODE"),
+		AfterFailures:    viper.GetInt("CHALLENGE_AFTER_FAILURES"),
+		Window:           viper.GetDuration("CHALLENGE_WINDOW"),
+		PowDifficulty:    viper.GetInt("CHALLENGE_POW_DIFFICULTY"),
+		PowTTL:           viper.GetDuration("CHALLENGE_POW_TTL"),
+		CaptchaProvider:  viper.GetString("CHALLENGE_CAPTCHA_PROVIDER"),
+		CaptchaVerifyURL: viper.GetString("CHALLENGE_CAPTCHA_VERIFY_URL"),
+		CaptchaSecret:    viper.GetString("CHALLENGE_CAPTCHA_SECRET"),
+		CaptchaSiteKey:   viper.GetString("CHALLENGE_CAPTCHA_SITE_KEY"),
+		CaptchaStubToken: viper.GetString("CHALLENGE_CAPTCHA_STUB_TOKEN"),
+	}
+
+	// login risk env
+	viper.SetDefault("RISK_NEW_DEVICE_SCORE", 30)
+	viper.SetDefault("RISK_NEW_COUNTRY_SCORE", 30)
+	viper.SetDefault("RISK_TRAVEL_SCORE", 50)
+	viper.SetDefault("RISK_MAX_TRAVEL_SPEED_KMH", 1000)
+	viper.SetDefault("RISK_STEP_UP_SCORE", 50)
+	viper.SetDefault("RISK_BLOCK_SCORE", 80)
+	viper.SetDefault("RISK_OTP_TTL", "5m")
+	viper.SetDefault("RISK_OTP_MAX_ATTEMPTS", 5)
+	viper.SetDefault("RISK_NOTIFY_NEW_DEVICE", true)
+
+	riskEnv := Risk{
+		GeoIPFile:         viper.GetString("RISK_GEOIP_FILE"),
+		NewDeviceScore:    viper.GetInt("RISK_NEW_DEVICE_SCORE"),
+		NewCountryScore:   viper.GetInt("RISK_NEW_COUNTRY_SCORE"),
+		TravelScore:       viper.GetInt("RISK_TRAVEL_SCORE"),
+		MaxTravelSpeedKmh: viper.GetFloat64("RISK_MAX_TRAVEL_SPEED_KMH"),
+		StepUpScore:       viper.GetInt("RISK_STEP_UP_SCORE"),
+		BlockScore:        viper.GetInt("RISK_BLOCK_SCORE"),
+		OTPTTL:            viper.GetDuration("RISK_OTP_TTL"),
+		OTPMaxAttempts:    viper.GetInt("RISK_OTP_MAX_ATTEMPTS"),
+		NotifyNewDevice:   viper.GetBool("RISK_NOTIFY_NEW_DEVICE"),
+	}
+
+	// ip access env
+	viper.SetDefault("IP_RULES_REFRESH", "30s")
+
+	ipAccessEnv := IPAccess{
+		Refresh: viper.GetDuration("IP_RULES_REFRESH"),
+	}
+
+	// scope grammar env
+	viper.SetDefault("SCOPES_FILE", "./config/scopes.yaml")
+
+	scopesEnv := Scopes{
+		File: viper.GetString("SCOPES_FILE"),
+	}
+
+	// authorization policy env
+	viper.SetDefault("AUTHZ_POLICY_FILE", "./config/policies.yaml")
+	viper.SetDefault("AUTHZ_CACHE_TTL", "10s")
+
+	authorizationEnv := Authorization{
+		PolicyFile: viper.GetString("AUTHZ_POLICY_FILE"),
+		CacheTTL:   viper.GetDuration("AUTHZ_CACHE_TTL"),
 	}
 
 	return &Config{
-		Server:   serverEnv,
-		Postgres: postgresEnv,
-		Logger:   loggerEnv,
-		Redis:    redisEnv,
-		Kafka:    kafkaEnv,
-		Consumer: consumerEnv,
-		JWT:      jwtEnv,
+		Server:         serverEnv,
+		Postgres:       postgresEnv,
+		Logger:         loggerEnv,
+		Redis:          redisEnv,
+		Kafka:          kafkaEnv,
+		Consumer:       consumerEnv,
+		JWT:            jwtEnv,
+		TLS:            tlsEnv,
+		CA:             caEnv,
+		Device:         deviceEnv,
+		SMTP:           smtpEnv,
+		PasswordReset:  passwordResetEnv,
+		Password:       passwordEnv,
+		PasswordPolicy: passwordPolicyEnv,
+
+		BreachedPassword: breachedPasswordEnv,
+		Lockout:          lockoutEnv,
+		RateLimit:        rateLimitEnv,
+		Challenge:        challengeEnv,
+		Risk:             riskEnv,
+		IPAccess:         ipAccessEnv,
+		Scopes:           scopesEnv,
+		Authorization:    authorizationEnv,
 	}
 }
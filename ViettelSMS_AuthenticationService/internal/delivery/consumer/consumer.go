@@ -8,7 +8,8 @@ import (
 )
 
 const (
-	USER_TOPIC = "user-events"
+	USER_TOPIC   = "user-events"
+	SERVER_TOPIC = "server-events"
 )
 
 type (
@@ -21,6 +22,9 @@ type (
 
 		userConsumer consumer.Consumer
 		userHandler  UserHandler
+
+		serverConsumer consumer.Consumer
+		serverHandler  ServerHandler
 	}
 )
 
@@ -28,11 +32,15 @@ func NewRoot(
 	logger *zap.Logger,
 	userConsumer consumer.Consumer,
 	userHandler UserHandler,
+	serverConsumer consumer.Consumer,
+	serverHandler ServerHandler,
 ) Root {
 	return &root{
-		logger:       logger,
-		userConsumer: userConsumer,
-		userHandler:  userHandler,
+		logger:         logger,
+		userConsumer:   userConsumer,
+		userHandler:    userHandler,
+		serverConsumer: serverConsumer,
+		serverHandler:  serverHandler,
 	}
 }
 
@@ -46,6 +54,13 @@ func (r *root) Start(ctx context.Context) error {
 		},
 	)
 
+	r.serverConsumer.RegisterHandler(
+		SERVER_TOPIC,
+		func(ctx context.Context, queueName string, payload []byte) error {
+			return r.serverHandler.Handle(ctx, queueName, payload)
+		},
+	)
+
 	r.logger.Info("Kafka consumer started, waiting for messages...")
 
 	go func() {
@@ -53,5 +68,10 @@ func (r *root) Start(ctx context.Context) error {
 			r.logger.Error("Failed to start Kafka consumer", zap.Error(err))
 		}
 	}()
+	go func() {
+		if err := r.serverConsumer.Start(ctx); err != nil {
+			r.logger.Error("Failed to start Kafka server consumer", zap.Error(err))
+		}
+	}()
 	return nil
 }
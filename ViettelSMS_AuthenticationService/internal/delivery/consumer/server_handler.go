@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/usecases/auth"
+	"go.uber.org/zap"
+)
+
+type ServerHandler interface {
+	Handle(ctx context.Context, topic string, payload []byte) error
+}
+
+type serverHandler struct {
+	logger  *zap.Logger
+	usecase auth.UseCase
+}
+
+func NewServerHandler(
+	logger *zap.Logger,
+	usecase auth.UseCase,
+) ServerHandler {
+	return &serverHandler{
+		logger:  logger,
+		usecase: usecase,
+	}
+}
+
+// Handle keeps resource permissions in step with the servers ServerService
+// registers. Server events share the envelope of user events.
+func (s *serverHandler) Handle(ctx context.Context, topic string, payload []byte) error {
+	s.logger.Info("Handling server event message", zap.String("topic", topic), zap.Int("size", len(payload)))
+
+	var msg dto.UserEvent
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		s.logger.Error("failed to unmarshal server event", zap.Error(err))
+		return err
+	}
+
+	switch msg.Event {
+	case "server.registered":
+		return s.usecase.RegisterAuthServer(ctx, msg.Payload)
+	case "server.deleted":
+		return s.usecase.DeleteAuthServer(ctx, msg.Payload)
+	default:
+		// ServerService publishes status and import events here too.
+		s.logger.Debug("ignoring server event", zap.String("event", msg.Event))
+		return nil
+	}
+}
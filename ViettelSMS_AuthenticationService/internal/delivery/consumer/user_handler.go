@@ -29,7 +29,8 @@ func NewUserHandler(
 }
 
 func (u *userHandler) Handle(ctx context.Context, topic string, payload []byte) error {
-	u.logger.Info("Handling user event message", zap.String("topic", topic), zap.ByteString("payload", payload))
+	// The payload may carry a password, so only its size is logged.
+	u.logger.Info("Handling user event message", zap.String("topic", topic), zap.Int("size", len(payload)))
 
 	var msg dto.UserEvent
 	if err := json.Unmarshal(payload, &msg); err != nil {
@@ -37,6 +38,8 @@ func (u *userHandler) Handle(ctx context.Context, topic string, payload []byte)
 		return err
 	}
 
+	u.logger.Info("Decoded user event", zap.String("topic", topic), zap.String("event", msg.Event))
+
 	switch msg.Event {
 	case "user.created":
 		return u.usecase.CreateAuthUser(ctx, msg.Payload)
@@ -50,6 +53,14 @@ func (u *userHandler) Handle(ctx context.Context, topic string, payload []byte)
 		return u.usecase.AddAuthScope(ctx, msg.Payload)
 	case "user.deleted_scope":
 		return u.usecase.DeleteAuthScope(ctx, msg.Payload)
+	case "user.assigned_role":
+		return u.usecase.AssignAuthRole(ctx, msg.Payload)
+	case "user.unassigned_role":
+		return u.usecase.UnassignAuthRole(ctx, msg.Payload)
+	case "role.created", "role.updated":
+		return u.usecase.SaveAuthRole(ctx, msg.Payload)
+	case "role.deleted":
+		return u.usecase.DeleteAuthRole(ctx, msg.Payload)
 	default:
 		u.logger.Warn("unknown user event", zap.String("event", msg.Event))
 		return nil
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// UnlockUser godoc
+// @Summary Unlock account
+// @Description Lift a lockout caused by repeated failed logins and reset the failure counters
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param user_name path string true "User name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/users/{user_name}/unlock [post]
+func (c *Controller) UnlockUser(ctx *gin.Context) {
+	userName := ctx.Param("user_name")
+	adminID := ctx.GetUint("userID")
+
+	if err := c.usecase.UnlockUser(ctx.Request.Context(), userName, adminID); err != nil {
+		c.logger.Warn("Failed to unlock user", zap.String("user_name", userName), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.LoginSuccess(ctx, "Account unlocked successfully", nil)
+	c.logger.Info("Account unlocked", zap.String("user_name", userName), zap.Uint("by", adminID))
+}
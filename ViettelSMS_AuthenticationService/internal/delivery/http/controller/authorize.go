@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/response"
+	"go.uber.org/zap"
+)
+
+// Authorize godoc
+// @Summary Authorization decision
+// @Description Evaluate the attribute-based policies for a subject performing an action on a resource. Returns allow or deny with the rule that decided; the request itself succeeds either way.
+// @Tags authorization
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.AuthorizeRequest true "Authorization request"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/authorize [post]
+func (c *Controller) Authorize(ctx *gin.Context) {
+	var req dto.AuthorizeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind authorize request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	decision, err := c.usecase.Authorize(ctx.Request.Context(), req)
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Authorization decided", decision)
+}
+
+// Introspect godoc
+// @Summary Token introspection
+// @Description RFC 7662 token introspection for services holding auth:authorize. With resource_type the response also lists the token holder's resource permissions on that type.
+// @Tags authorization
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Security BearerAuth
+// @Param token formData string true "Access token"
+// @Param resource_type formData string false "Resource type"
+// @Success 200 {object} dto.IntrospectResponse
+// @Failure 400 {object} response.OAuthErrorResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 500 {object} response.OAuthErrorResponse
+// @Router /auth/introspect [post]
+func (c *Controller) Introspect(ctx *gin.Context) {
+	var req dto.IntrospectRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		c.logger.Warn("Failed to bind introspect request", zap.Error(err))
+		c.presenter.OAuthError(ctx, http.StatusBadRequest, response.OAuthInvalidRequest, err)
+		return
+	}
+
+	result, err := c.usecase.Introspect(ctx.Request.Context(), req)
+	if err != nil {
+		c.presenter.OAuthDomainError(ctx, err)
+		return
+	}
+
+	c.presenter.OAuthSuccess(ctx, result)
+}
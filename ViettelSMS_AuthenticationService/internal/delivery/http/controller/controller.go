@@ -1,8 +1,6 @@
 package controller
 
 import (
-	"errors"
-
 	"github.com/gin-gonic/gin"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/presenter"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
@@ -31,13 +29,16 @@ func NewController(
 
 // Login godoc
 // @Summary Login
-// @Description User login
+// @Description User login. When the password has expired or must be changed, only a restricted access token for /auth/password/change is returned. A login from an unusual device or location may instead return mfa_required with an mfa_token for /auth/login/verify, or be refused.
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param login body dto.LoginRequest true "Login request"
 // @Success 200 {object} response.APIResponse
 // @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 428 {object} response.APIResponse
 // @Failure 500 {object} response.APIResponse
 // @Router /auth/login [post]
 func (c *Controller) Login(ctx *gin.Context) {
@@ -50,15 +51,22 @@ func (c *Controller) Login(ctx *gin.Context) {
 		return
 	}
 
-	token, err := c.usecase.Login(ctx.Request.Context(), req.UserName, req.Password)
+	token, err := c.usecase.Login(ctx.Request.Context(), req, clientInfo(ctx))
 	if err != nil {
-		if errors.Is(err, domain.ErrUserNotFound) {
-			c.logger.Warn("User not found", zap.String("username", req.UserName))
-			c.presenter.InvalidRequest(ctx, "Invalid request", err)
-		} else {
-			c.logger.Error("Failed to login", zap.Error(err))
-			c.presenter.InternalError(ctx, "Internal server error", err)
-		}
+		c.logger.Warn("Failed to login", zap.String("username", req.UserName), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	if token.MFARequired {
+		c.presenter.LoginSuccess(ctx, "Verification code sent", token)
+		c.logger.Info("User must verify login", zap.String("username", req.UserName))
+		return
+	}
+
+	if token.PasswordChangeRequired {
+		c.presenter.LoginSuccess(ctx, "Password change required", token)
+		c.logger.Info("User must change password", zap.String("username", req.UserName))
 		return
 	}
 
@@ -66,6 +74,42 @@ func (c *Controller) Login(ctx *gin.Context) {
 	c.logger.Info("User logged in successfully", zap.String("username", req.UserName))
 }
 
+// VerifyLogin godoc
+// @Summary Verify login
+// @Description Finish a login that required verification, using the code emailed to the user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param verify body dto.LoginVerifyRequest true "Login verification request"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/login/verify [post]
+func (c *Controller) VerifyLogin(ctx *gin.Context) {
+	var req dto.LoginVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind login verify request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	token, err := c.usecase.VerifyLogin(ctx.Request.Context(), req, clientInfo(ctx))
+	if err != nil {
+		c.logger.Warn("Failed to verify login", zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	if token.PasswordChangeRequired {
+		c.presenter.LoginSuccess(ctx, "Password change required", token)
+		return
+	}
+
+	c.presenter.LoginSuccess(ctx, "Login successful", token)
+	c.logger.Info("User verified login successfully")
+}
+
 // RefreshToken godoc
 // @Summary Refresh token
 // @Description Refresh user token
@@ -84,10 +128,69 @@ func (c *Controller) RefreshToken(ctx *gin.Context) {
 	token, err := c.usecase.RefreshToken(ctx.Request.Context(), userID)
 	if err != nil {
 		c.logger.Error("Failed to refresh token", zap.Error(err))
-		c.presenter.InternalError(ctx, "Internal server error", err)
+		c.presenter.Error(ctx, err)
 		return
 	}
 
 	c.presenter.LoginSuccess(ctx, "Token refreshed successfully", *token)
 	c.logger.Info("Token refreshed successfully")
 }
+
+// MachineToken godoc
+// @Summary Issue machine token
+// @Description Issue a certificate-bound access token to a monitored server authenticated with a client certificate
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/machine/token [post]
+func (c *Controller) MachineToken(ctx *gin.Context) {
+	c.logger.Info("Issuing machine token")
+
+	tlsState := ctx.Request.TLS
+	if tlsState == nil || len(tlsState.VerifiedChains) == 0 || len(tlsState.PeerCertificates) == 0 {
+		c.logger.Warn("Machine token requested without a verified client certificate")
+		c.presenter.Unauthorized(ctx, "Client certificate required", domain.ErrClientCertRequired)
+		return
+	}
+
+	token, err := c.usecase.IssueMachineToken(ctx.Request.Context(), tlsState.PeerCertificates[0])
+	if err != nil {
+		c.logger.Warn("Failed to issue machine token", zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.LoginSuccess(ctx, "Machine token issued successfully", token)
+	c.logger.Info("Machine token issued successfully", zap.String("machine", token.Machine))
+}
+
+// Challenge godoc
+// @Summary Login challenge
+// @Description Issue the proof-of-work challenge or CAPTCHA details required by /auth/login after repeated failures
+// @Tags auth
+// @Produce json
+// @Success 200 {object} response.APIResponse
+// @Failure 429 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/challenge [post]
+func (c *Controller) Challenge(ctx *gin.Context) {
+	challenge, err := c.usecase.IssueChallenge(ctx.Request.Context())
+	if err != nil {
+		c.logger.Error("Failed to issue challenge", zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.LoginSuccess(ctx, "Challenge issued", challenge)
+}
+
+func clientInfo(ctx *gin.Context) dto.ClientInfo {
+	return dto.ClientInfo{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		APIKey:    ctx.GetHeader("X-API-Key"),
+	}
+}
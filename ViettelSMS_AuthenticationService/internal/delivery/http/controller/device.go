@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/response"
+	"go.uber.org/zap"
+)
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// DeviceCode godoc
+// @Summary Device authorization request
+// @Description Start an RFC 8628 device authorization grant for a CLI or headless agent
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param request body dto.DeviceCodeRequest true "Device code request"
+// @Success 200 {object} dto.DeviceCodeResponse
+// @Failure 400 {object} response.OAuthErrorResponse
+// @Failure 401 {object} response.OAuthErrorResponse
+// @Failure 500 {object} response.OAuthErrorResponse
+// @Router /auth/device/code [post]
+func (c *Controller) DeviceCode(ctx *gin.Context) {
+	c.logger.Info("Device code requested")
+
+	var req dto.DeviceCodeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		c.logger.Warn("Failed to bind device code request", zap.Error(err))
+		c.presenter.OAuthError(ctx, http.StatusBadRequest, response.OAuthInvalidRequest, err)
+		return
+	}
+
+	resp, err := c.usecase.RequestDeviceCode(ctx.Request.Context(), req.ClientID)
+	if err != nil {
+		c.logger.Error("Failed to create device code", zap.Error(err))
+		c.presenter.OAuthDomainError(ctx, err)
+		return
+	}
+
+	c.presenter.OAuthSuccess(ctx, resp)
+}
+
+// ApproveDevice godoc
+// @Summary Approve device
+// @Description Approve or deny a pending device authorization from a logged-in browser session
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.DeviceApproveRequest true "Device approval"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/device/approve [post]
+func (c *Controller) ApproveDevice(ctx *gin.Context) {
+	var req dto.DeviceApproveRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind device approve request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	userID := ctx.GetUint("userID")
+
+	if err := c.usecase.ApproveDeviceCode(ctx.Request.Context(), userID, req.UserCode, req.Approve); err != nil {
+		c.logger.Warn("Failed to approve device", zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.LoginSuccess(ctx, "Device authorization updated", nil)
+	c.logger.Info("Device authorization updated", zap.Uint("userID", userID), zap.Bool("approve", req.Approve))
+}
+
+// Token godoc
+// @Summary Token endpoint
+// @Description OAuth token endpoint; supports the device code grant
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Produce json
+// @Param request body dto.TokenRequest true "Token request"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} response.OAuthErrorResponse
+// @Failure 401 {object} response.OAuthErrorResponse
+// @Failure 403 {object} response.OAuthErrorResponse
+// @Failure 500 {object} response.OAuthErrorResponse
+// @Router /auth/token [post]
+func (c *Controller) Token(ctx *gin.Context) {
+	var req dto.TokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		c.logger.Warn("Failed to bind token request", zap.Error(err))
+		c.presenter.OAuthError(ctx, http.StatusBadRequest, response.OAuthInvalidRequest, err)
+		return
+	}
+
+	switch req.GrantType {
+	case deviceCodeGrantType:
+		c.exchangeDeviceCode(ctx, &req)
+	default:
+		c.presenter.OAuthError(ctx, http.StatusBadRequest, response.OAuthUnsupportedGrantType, domain.ErrUnsupportedGrantType)
+	}
+}
+
+func (c *Controller) exchangeDeviceCode(ctx *gin.Context, req *dto.TokenRequest) {
+	if req.DeviceCode == "" {
+		c.presenter.OAuthError(ctx, http.StatusBadRequest, response.OAuthInvalidRequest, errors.New("device_code is required"))
+		return
+	}
+
+	token, err := c.usecase.ExchangeDeviceCode(ctx.Request.Context(), req.DeviceCode, req.ClientID, clientInfo(ctx))
+	if err != nil {
+		if errors.Is(err, domain.ErrInternalServer) {
+			c.logger.Error("Failed to exchange device code", zap.Error(err))
+		}
+		c.presenter.OAuthDomainError(ctx, err)
+		return
+	}
+
+	c.presenter.OAuthSuccess(ctx, token)
+	c.logger.Info("Device code exchanged successfully")
+}
@@ -0,0 +1,226 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"go.uber.org/zap"
+)
+
+// ListGroups godoc
+// @Summary List groups
+// @Description List groups with the scopes and roles each one grants and the groups it is nested in
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/groups [get]
+func (c *Controller) ListGroups(ctx *gin.Context) {
+	groups, err := c.usecase.ListGroups(ctx.Request.Context())
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Groups retrieved successfully", groups)
+}
+
+// CreateGroup godoc
+// @Summary Create group
+// @Description Create a group. Members inherit its scopes and roles, and those of its parent groups.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group body dto.GroupRequest true "Group"
+// @Success 201 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 409 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/groups [post]
+func (c *Controller) CreateGroup(ctx *gin.Context) {
+	var req dto.GroupRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind group request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	group, err := c.usecase.CreateGroup(ctx.Request.Context(), req, ctx.GetUint("userID"))
+	if err != nil {
+		c.logger.Warn("Failed to create group", zap.String("group", req.Name), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Created(ctx, "Group created successfully", group)
+}
+
+// UpdateGroup godoc
+// @Summary Update group
+// @Description Replace the description, scopes, roles and parents of a group. Nesting a group in itself or in one of its own subgroups is refused.
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Group name"
+// @Param group body dto.GroupUpdateRequest true "Group"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 409 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/groups/{name} [put]
+func (c *Controller) UpdateGroup(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	var req dto.GroupUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind group request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	group, err := c.usecase.UpdateGroup(ctx.Request.Context(), name, req, ctx.GetUint("userID"))
+	if err != nil {
+		c.logger.Warn("Failed to update group", zap.String("group", name), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Group updated successfully", group)
+}
+
+// DeleteGroup godoc
+// @Summary Delete group
+// @Description Delete a group. Its members and subgroups lose what it granted.
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Group name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/groups/{name} [delete]
+func (c *Controller) DeleteGroup(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	if err := c.usecase.DeleteGroup(ctx.Request.Context(), name, ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to delete group", zap.String("group", name), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Deleted(ctx, "Group deleted successfully")
+}
+
+// GetGroupMembers godoc
+// @Summary List group members
+// @Description List the users belonging directly to a group
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Group name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/groups/{name}/members [get]
+func (c *Controller) GetGroupMembers(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	members, err := c.usecase.GetGroupMembers(ctx.Request.Context(), name)
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Group members retrieved successfully", members)
+}
+
+// AddGroupMember godoc
+// @Summary Add group member
+// @Description Add a user to a group. The user's next token carries what the group grants.
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Group name"
+// @Param user_name path string true "User name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/groups/{name}/members/{user_name} [put]
+func (c *Controller) AddGroupMember(ctx *gin.Context) {
+	name, userName := ctx.Param("name"), ctx.Param("user_name")
+
+	if err := c.usecase.AddGroupMember(ctx.Request.Context(), name, userName, ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to add group member", zap.String("group", name), zap.String("user_name", userName), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Group member added successfully", nil)
+}
+
+// RemoveGroupMember godoc
+// @Summary Remove group member
+// @Description Remove a user from a group
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Group name"
+// @Param user_name path string true "User name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/groups/{name}/members/{user_name} [delete]
+func (c *Controller) RemoveGroupMember(ctx *gin.Context) {
+	name, userName := ctx.Param("name"), ctx.Param("user_name")
+
+	if err := c.usecase.RemoveGroupMember(ctx.Request.Context(), name, userName, ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to remove group member", zap.String("group", name), zap.String("user_name", userName), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Deleted(ctx, "Group member removed successfully")
+}
+
+// GetEffectiveScopes godoc
+// @Summary Effective scopes
+// @Description List the scopes a user's next token carries, each with the grants it comes from: the user's own scopes, its roles and its groups, including nested groups
+// @Tags groups
+// @Produce json
+// @Security BearerAuth
+// @Param user_name path string true "User name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/users/{user_name}/effective-scopes [get]
+func (c *Controller) GetEffectiveScopes(ctx *gin.Context) {
+	userName := ctx.Param("user_name")
+
+	scopes, err := c.usecase.GetEffectiveScopes(ctx.Request.Context(), userName)
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Effective scopes retrieved successfully", scopes)
+}
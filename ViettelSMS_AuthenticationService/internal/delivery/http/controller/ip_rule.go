@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"go.uber.org/zap"
+)
+
+// ListIPRules godoc
+// @Summary List IP rules
+// @Description List the CIDR allow and deny rules attached to users, scopes, machine identities and API keys. API key rules show the SHA-256 of the key.
+// @Tags ip-rules
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/ip-rules [get]
+func (c *Controller) ListIPRules(ctx *gin.Context) {
+	rules, err := c.usecase.ListIPRules(ctx.Request.Context())
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "IP rules retrieved successfully", rules)
+}
+
+// CreateIPRule godoc
+// @Summary Create IP rule
+// @Description Allow or deny a network for a user (by user name), a scope, a machine identity or an API key (the X-API-Key header; only its SHA-256 is stored). A subject with allow rules can only be used from those networks; deny rules always win.
+// @Tags ip-rules
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param rule body dto.IPRuleRequest true "IP rule"
+// @Success 201 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 409 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/ip-rules [post]
+func (c *Controller) CreateIPRule(ctx *gin.Context) {
+	var req dto.IPRuleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind ip rule request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	rule, err := c.usecase.CreateIPRule(ctx.Request.Context(), req, ctx.GetUint("userID"))
+	if err != nil {
+		c.logger.Warn("Failed to create ip rule", zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Created(ctx, "IP rule created successfully", rule)
+}
+
+// DeleteIPRule godoc
+// @Summary Delete IP rule
+// @Description Delete an IP rule
+// @Tags ip-rules
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Rule ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/ip-rules/{id} [delete]
+func (c *Controller) DeleteIPRule(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.presenter.InvalidRequest(ctx, "Invalid rule id", err)
+		return
+	}
+
+	if err := c.usecase.DeleteIPRule(ctx.Request.Context(), uint(id), ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to delete ip rule", zap.Uint64("id", id), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Deleted(ctx, "IP rule deleted successfully")
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"go.uber.org/zap"
+)
+
+// ForgotPassword godoc
+// @Summary Forgot password
+// @Description Email a single-use password reset link. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordRequest true "Forgot password request"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Router /auth/password/forgot [post]
+func (c *Controller) ForgotPassword(ctx *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind forgot password request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	if req.UserName == "" && req.Email == "" {
+		c.presenter.InvalidRequest(ctx, "Invalid request", errors.New("user_name or email is required"))
+		return
+	}
+
+	if err := c.usecase.ForgotPassword(ctx.Request.Context(), req); err != nil {
+		c.logger.Error("Failed to process forgot password request", zap.Error(err))
+	}
+
+	c.presenter.LoginSuccess(ctx, "If the account exists, a password reset link has been sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using a token from the password reset email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordRequest true "Reset password request"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/password/reset [post]
+func (c *Controller) ResetPassword(ctx *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind reset password request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	if err := c.usecase.ResetPassword(ctx.Request.Context(), req.Token, req.NewPassword); err != nil {
+		c.logger.Warn("Failed to reset password", zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.LoginSuccess(ctx, "Password reset successfully", nil)
+	c.logger.Info("Password reset successfully")
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Change the password of the logged-in user. Other sessions are signed out. Accepts the restricted token issued at login for an expired password.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ChangePasswordRequest true "Change password request"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/password/change [post]
+func (c *Controller) ChangePassword(ctx *gin.Context) {
+	var req dto.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind change password request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	userID := ctx.GetUint("userID")
+	sessionID := ctx.GetString("sessionID")
+
+	if err := c.usecase.ChangePassword(ctx.Request.Context(), userID, sessionID, req.CurrentPassword, req.NewPassword); err != nil {
+		c.logger.Warn("Failed to change password", zap.Uint("userID", userID), zap.Error(err))
+		if errors.Is(err, domain.ErrUserNotFound) {
+			// The token outlived its user.
+			err = domain.ErrInvalidToken
+		}
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.LoginSuccess(ctx, "Password changed successfully", nil)
+	c.logger.Info("Password changed successfully", zap.Uint("userID", userID))
+}
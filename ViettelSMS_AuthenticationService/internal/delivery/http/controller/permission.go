@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"go.uber.org/zap"
+)
+
+// ListResourcePermissions godoc
+// @Summary List resource permissions
+// @Description List the permissions granting actions on individual resources
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Param resource_type query string false "Only permissions on this resource type"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/permissions [get]
+func (c *Controller) ListResourcePermissions(ctx *gin.Context) {
+	permissions, err := c.usecase.ListResourcePermissions(ctx.Request.Context(), ctx.Query("resource_type"))
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Resource permissions retrieved successfully", permissions)
+}
+
+// CreateResourcePermission godoc
+// @Summary Create resource permission
+// @Description Grant an action ("*" for all) on one resource, or on resource IDs matching a "*" pattern, to a user (by user name), a role or a machine identity
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param permission body dto.ResourcePermissionRequest true "Resource permission"
+// @Success 201 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 409 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/permissions [post]
+func (c *Controller) CreateResourcePermission(ctx *gin.Context) {
+	var req dto.ResourcePermissionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind resource permission request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	permission, err := c.usecase.CreateResourcePermission(ctx.Request.Context(), req, ctx.GetUint("userID"))
+	if err != nil {
+		c.logger.Warn("Failed to create resource permission", zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Created(ctx, "Resource permission created successfully", permission)
+}
+
+// DeleteResourcePermission godoc
+// @Summary Delete resource permission
+// @Description Delete a resource permission
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Permission ID"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/permissions/{id} [delete]
+func (c *Controller) DeleteResourcePermission(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		c.presenter.InvalidRequest(ctx, "Invalid permission id", err)
+		return
+	}
+
+	if err := c.usecase.DeleteResourcePermission(ctx.Request.Context(), uint(id), ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to delete resource permission", zap.Uint64("id", id), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Deleted(ctx, "Resource permission deleted successfully")
+}
+
+// CheckPermission godoc
+// @Summary Check resource permission
+// @Description Check whether a user or machine identity may perform an action on one resource, through a scope covering every resource of the type or through a resource permission
+// @Tags permissions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.PermissionCheckRequest true "Permission check"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/permissions/check [post]
+func (c *Controller) CheckPermission(ctx *gin.Context) {
+	var req dto.PermissionCheckRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind permission check request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	result, err := c.usecase.CheckPermission(ctx.Request.Context(), req)
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Permission checked", result)
+}
+
+// PermittedResources godoc
+// @Summary List permitted resources
+// @Description List the resource IDs and ID patterns a user or machine identity may perform an action on, or all=true when a scope covers every resource of the type
+// @Tags permissions
+// @Produce json
+// @Security BearerAuth
+// @Param subject_type query string true "user or machine"
+// @Param subject query string true "User name or machine identity name"
+// @Param resource_type query string true "Resource type"
+// @Param action query string true "Action"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/permissions/resources [get]
+func (c *Controller) PermittedResources(ctx *gin.Context) {
+	var req dto.PermittedResourcesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		c.logger.Warn("Failed to bind permitted resources request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	result, err := c.usecase.PermittedResources(ctx.Request.Context(), req)
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Permitted resources retrieved successfully", result)
+}
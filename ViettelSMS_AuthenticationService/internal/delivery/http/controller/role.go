@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"go.uber.org/zap"
+)
+
+// ListRoles godoc
+// @Summary List roles
+// @Description List roles and the scopes each one grants
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/roles [get]
+func (c *Controller) ListRoles(ctx *gin.Context) {
+	roles, err := c.usecase.ListRoles(ctx.Request.Context())
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Roles retrieved successfully", roles)
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Description Create a role granting a set of scopes
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param role body dto.RoleRequest true "Role"
+// @Success 201 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 409 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/roles [post]
+func (c *Controller) CreateRole(ctx *gin.Context) {
+	var req dto.RoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind role request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	role, err := c.usecase.CreateRole(ctx.Request.Context(), req, ctx.GetUint("userID"))
+	if err != nil {
+		c.logger.Warn("Failed to create role", zap.String("role", req.Name), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Created(ctx, "Role created successfully", role)
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Replace the description and scopes of a role. Built-in roles cannot be changed.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Param role body dto.RoleUpdateRequest true "Role"
+// @Success 200 {object} response.APIResponse
+// @Failure 400 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 409 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/roles/{name} [put]
+func (c *Controller) UpdateRole(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	var req dto.RoleUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		c.logger.Warn("Failed to bind role request", zap.Error(err))
+		c.presenter.InvalidRequest(ctx, "Invalid request", err)
+		return
+	}
+
+	role, err := c.usecase.UpdateRole(ctx.Request.Context(), name, req, ctx.GetUint("userID"))
+	if err != nil {
+		c.logger.Warn("Failed to update role", zap.String("role", name), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Role updated successfully", role)
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Delete a role and take it away from every user holding it. Built-in roles cannot be deleted.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 409 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/roles/{name} [delete]
+func (c *Controller) DeleteRole(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	if err := c.usecase.DeleteRole(ctx.Request.Context(), name, ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to delete role", zap.String("role", name), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Deleted(ctx, "Role deleted successfully")
+}
+
+// GetUserRoles godoc
+// @Summary List user roles
+// @Description List the roles assigned to a user
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param user_name path string true "User name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/users/{user_name}/roles [get]
+func (c *Controller) GetUserRoles(ctx *gin.Context) {
+	userName := ctx.Param("user_name")
+
+	roles, err := c.usecase.GetUserRoles(ctx.Request.Context(), userName)
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "User roles retrieved successfully", roles)
+}
+
+// AssignRole godoc
+// @Summary Assign role
+// @Description Assign a role to a user. The user's next token carries the role's scopes.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param user_name path string true "User name"
+// @Param role path string true "Role name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/users/{user_name}/roles/{role} [put]
+func (c *Controller) AssignRole(ctx *gin.Context) {
+	userName, role := ctx.Param("user_name"), ctx.Param("role")
+
+	if err := c.usecase.AssignRole(ctx.Request.Context(), userName, role, ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to assign role", zap.String("user_name", userName), zap.String("role", role), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Role assigned successfully", nil)
+}
+
+// UnassignRole godoc
+// @Summary Unassign role
+// @Description Take a role away from a user
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param user_name path string true "User name"
+// @Param role path string true "Role name"
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 403 {object} response.APIResponse
+// @Failure 404 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/users/{user_name}/roles/{role} [delete]
+func (c *Controller) UnassignRole(ctx *gin.Context) {
+	userName, role := ctx.Param("user_name"), ctx.Param("role")
+
+	if err := c.usecase.UnassignRole(ctx.Request.Context(), userName, role, ctx.GetUint("userID")); err != nil {
+		c.logger.Warn("Failed to unassign role", zap.String("user_name", userName), zap.String("role", role), zap.Error(err))
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Deleted(ctx, "Role unassigned successfully")
+}
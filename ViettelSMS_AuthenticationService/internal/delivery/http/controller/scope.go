@@ -0,0 +1,23 @@
+package controller
+
+import "github.com/gin-gonic/gin"
+
+// ListScopes godoc
+// @Summary List scopes
+// @Description List the scope catalogue: every scope that can be granted, with its description, owning service and deprecation flag
+// @Tags scopes
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.APIResponse
+// @Failure 401 {object} response.APIResponse
+// @Failure 500 {object} response.APIResponse
+// @Router /auth/scopes [get]
+func (c *Controller) ListScopes(ctx *gin.Context) {
+	scopes, err := c.usecase.ListScopes(ctx.Request.Context())
+	if err != nil {
+		c.presenter.Error(ctx, err)
+		return
+	}
+
+	c.presenter.Success(ctx, "Scopes retrieved successfully", scopes)
+}
@@ -2,36 +2,77 @@ package middleware
 
 import (
 	"fmt"
-	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/presenter"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/utils"
 )
 
 type JWTMiddleware interface {
 	RequireAuth() gin.HandlerFunc
+	RequireAuthAllowRestricted() gin.HandlerFunc
+	RequireAuthAllowMachine() gin.HandlerFunc
 	RequireScope(requireScope string) gin.HandlerFunc
+	RequireAllScopes(requireScopes ...string) gin.HandlerFunc
+	RequireAnyScope(requireScopes ...string) gin.HandlerFunc
+	// RequireScopeExpr takes an expression such as
+	// "server:update && (server:import || user:scope)" using &&, ||, ! and
+	// parentheses. It panics on a malformed expression, at route setup.
+	RequireScopeExpr(expr string) gin.HandlerFunc
 }
 
 type jwtMiddleware struct {
 	presenter presenter.Presenter
+	sessions  srv.SessionStore
+	ipAccess  srv.IPAccessList
+	scopes    srv.ScopeMatcher
 	jwtSecret []byte
 }
 
 func NewJWTMiddleware(
 	presenter presenter.Presenter,
+	sessions srv.SessionStore,
+	ipAccess srv.IPAccessList,
+	scopes srv.ScopeMatcher,
 	jwtSecret []byte,
 ) JWTMiddleware {
 	return &jwtMiddleware{
 		presenter: presenter,
+		sessions:  sessions,
+		ipAccess:  ipAccess,
+		scopes:    scopes,
 		jwtSecret: jwtSecret,
 	}
 }
 
+// RequireAuth accepts user tokens only. Handlers behind it may take
+// "userID" as the acting user.
 func (s *jwtMiddleware) RequireAuth() gin.HandlerFunc {
+	return s.authenticate(false, false)
+}
+
+// RequireAuthAllowRestricted also accepts the restricted token handed out
+// when a password has expired or must be changed. Only the change-password
+// endpoint should use it.
+func (s *jwtMiddleware) RequireAuthAllowRestricted() gin.HandlerFunc {
+	return s.authenticate(true, false)
+}
+
+// RequireAuthAllowMachine also accepts machine identity tokens, for the
+// endpoints other services call. A machine token sets "machine" and no
+// "userID", since its sub is a machine identity ID.
+func (s *jwtMiddleware) RequireAuthAllowMachine() gin.HandlerFunc {
+	return s.authenticate(false, true)
+}
+
+func (s *jwtMiddleware) authenticate(allowRestricted, allowMachine bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := s.extractTokenFromHeader(c)
 		if token == "" {
@@ -53,7 +94,52 @@ func (s *jwtMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("userID", claims.Sub)
+		if claims.Machine != "" && !allowMachine {
+			s.presenter.Forbidden(c, "Machine tokens are not accepted here", domain.ErrAccessDenied)
+			c.Abort()
+			return
+		}
+
+		if claims.Restricted && !allowRestricted {
+			s.presenter.Forbidden(c, "Password change required", domain.ErrPasswordChangeRequired)
+			c.Abort()
+			return
+		}
+
+		if err := s.verifyCertificateBinding(c, claims); err != nil {
+			s.presenter.Unauthorized(c, "Invalid token", err)
+			c.Abort()
+			return
+		}
+
+		if claims.Sid != "" {
+			active, err := s.sessions.Exists(c.Request.Context(), claims.Sid)
+			if err != nil {
+				s.presenter.InternalError(c, "Internal server error", err)
+				c.Abort()
+				return
+			}
+			if !active {
+				s.presenter.Unauthorized(c, "Session revoked", domain.ErrSessionRevoked)
+				c.Abort()
+				return
+			}
+			c.Set("sessionID", claims.Sid)
+		}
+
+		subjects := tokenIPSubjects(claims)
+		if apiKey := c.GetHeader(apiKeyHeader); apiKey != "" {
+			subjects = append(subjects, dto.IPSubject{Type: entity.IPSubjectAPIKey, Value: entity.APIKeyFingerprint(apiKey)})
+		}
+		if !s.checkIP(c, subjects...) {
+			return
+		}
+
+		if claims.Machine != "" {
+			c.Set("machine", claims.Machine)
+		} else {
+			c.Set("userID", claims.Sub)
+		}
 		c.Set("scopes", claims.Scopes)
 
 		c.Next()
@@ -61,22 +147,102 @@ func (s *jwtMiddleware) RequireAuth() gin.HandlerFunc {
 }
 
 func (s *jwtMiddleware) RequireScope(requireScope string) gin.HandlerFunc {
+	return s.RequireAllScopes(requireScope)
+}
+
+func (s *jwtMiddleware) RequireAllScopes(requireScopes ...string) gin.HandlerFunc {
+	return s.RequireScopeExpr(strings.Join(requireScopes, " && "))
+}
+
+func (s *jwtMiddleware) RequireAnyScope(requireScopes ...string) gin.HandlerFunc {
+	return s.RequireScopeExpr(strings.Join(requireScopes, " || "))
+}
+
+func (s *jwtMiddleware) RequireScopeExpr(expr string) gin.HandlerFunc {
+	required, err := compileScopeExpr(expr, s.scopes)
+	if err != nil {
+		panic(err)
+	}
+
 	return func(c *gin.Context) {
-		scopes, exists := c.Get("scopes")
-		if !exists {
-			s.presenter.Forbidden(c, "Insufficient scope", fmt.Errorf("insufficient scope"))
+		// Tokens carry expanded scopes, but a token minted before the scope
+		// grammar changed may hold a wildcard the matcher still understands.
+		granted := c.GetStringSlice("scopes")
+
+		ok, failed := required.eval(granted, s.scopes)
+		if !ok {
+			s.presenter.ForbiddenDetails(c, "Insufficient scope", dto.ScopeDenial{
+				Required: required.String(),
+				Failed:   failed.String(),
+			})
 			c.Abort()
 			return
 		}
 
-		if slices.Contains(scopes.([]string), requireScope) {
-			c.Next()
+		var subjects []dto.IPSubject
+		for _, scope := range required.scopes() {
+			if s.scopes.Grants(granted, scope) {
+				subjects = append(subjects, dto.IPSubject{Type: entity.IPSubjectScope, Value: scope})
+			}
+		}
+		if len(subjects) > 0 && !s.checkIP(c, subjects...) {
 			return
 		}
 
-		s.presenter.Forbidden(c, "Insufficient scope", fmt.Errorf("insufficient scope"))
+		c.Next()
+	}
+}
+
+// checkIP aborts the request when the client IP is not allowed for one of
+// subjects. c.ClientIP only honours X-Forwarded-For from TRUSTED_PROXIES.
+func (s *jwtMiddleware) checkIP(c *gin.Context, subjects ...dto.IPSubject) bool {
+	allowed, err := s.ipAccess.Allowed(c.Request.Context(), c.ClientIP(), subjects...)
+	if err != nil {
+		s.presenter.InternalError(c, "Internal server error", err)
 		c.Abort()
+		return false
 	}
+	if !allowed {
+		s.presenter.Error(c, domain.ErrIPNotAllowed)
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// tokenIPSubjects lists the principal of a token, user or machine identity,
+// and every scope it carries.
+func tokenIPSubjects(claims *dto.Claims) []dto.IPSubject {
+	subjects := make([]dto.IPSubject, 0, len(claims.Scopes)+1)
+	if claims.Machine != "" {
+		subjects = append(subjects, dto.IPSubject{Type: entity.IPSubjectMachine, Value: claims.Machine})
+	} else {
+		subjects = append(subjects, dto.IPSubject{Type: entity.IPSubjectUser, Value: strconv.FormatUint(uint64(claims.Sub), 10)})
+	}
+	for _, scope := range claims.Scopes {
+		subjects = append(subjects, dto.IPSubject{Type: entity.IPSubjectScope, Value: scope})
+	}
+	return subjects
+}
+
+// verifyCertificateBinding enforces RFC 8705: a token carrying a
+// "x5t#S256" confirmation is only accepted over a TLS connection presenting
+// the same client certificate it was issued to.
+func (s *jwtMiddleware) verifyCertificateBinding(c *gin.Context, claims *dto.Claims) error {
+	if claims.Cnf == nil || claims.Cnf.X5tS256 == "" {
+		return nil
+	}
+
+	tlsState := c.Request.TLS
+	if tlsState == nil || len(tlsState.PeerCertificates) == 0 {
+		return fmt.Errorf("certificate-bound token presented without a client certificate")
+	}
+
+	if utils.CertThumbprint(tlsState.PeerCertificates[0]) != claims.Cnf.X5tS256 {
+		return fmt.Errorf("client certificate does not match token binding")
+	}
+
+	return nil
 }
 
 func (s *jwtMiddleware) extractTokenFromHeader(c *gin.Context) string {
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/presenter"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+)
+
+var testSecret = []byte("test-secret")
+
+func signTestToken(t *testing.T, claims dto.Claims) string {
+	t.Helper()
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func TestAuthenticateMachineTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &jwtMiddleware{
+		presenter: presenter.NewPresenter(),
+		ipAccess:  allowAllIPs{},
+		scopes:    newTestMatcher(t),
+		jwtSecret: testSecret,
+	}
+
+	user := signTestToken(t, dto.Claims{Sub: 5, Scopes: []string{"user:update"}})
+	machine := signTestToken(t, dto.Claims{Sub: 5, Machine: "scanner", Scopes: []string{"user:update"}})
+
+	tests := []struct {
+		name    string
+		auth    gin.HandlerFunc
+		token   string
+		status  int
+		userID  bool
+		machine string
+	}{
+		{"user on user route", s.RequireAuth(), user, http.StatusOK, true, ""},
+		{"machine on user route", s.RequireAuth(), machine, http.StatusForbidden, false, ""},
+		{"machine on restricted route", s.RequireAuthAllowRestricted(), machine, http.StatusForbidden, false, ""},
+		{"user on machine route", s.RequireAuthAllowMachine(), user, http.StatusOK, true, ""},
+		{"machine on machine route", s.RequireAuthAllowMachine(), machine, http.StatusOK, false, "scanner"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				userID      uint
+				hasUserID   bool
+				machineName string
+			)
+			router := gin.New()
+			router.GET("/", tt.auth, func(c *gin.Context) {
+				var v any
+				v, hasUserID = c.Get("userID")
+				userID, _ = v.(uint)
+				machineName = c.GetString("machine")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if hasUserID != tt.userID || (tt.userID && userID != 5) {
+				t.Errorf("userID = %v (set %v), want set %v", userID, hasUserID, tt.userID)
+			}
+			if machineName != tt.machine {
+				t.Errorf("machine = %q, want %q", machineName, tt.machine)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/presenter"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+const (
+	KeyIP       = "ip"
+	KeyUsername = "username"
+	KeyAPIKey   = "api_key"
+	KeyClientID = "client_id"
+
+	apiKeyHeader = "X-API-Key"
+
+	// maxPeekBody bounds how much of a request body is buffered to find the
+	// username or client_id.
+	maxPeekBody = 64 << 10
+
+	// maxKeyValue bounds a caller-supplied value kept in a Redis key; longer
+	// values are replaced by their SHA-256.
+	maxKeyValue = 64
+)
+
+// rateLimitFailOpen counts requests let through because the limiter could
+// not be reached, published on /debug/vars.
+var rateLimitFailOpen = expvar.NewInt("ratelimit_fail_open")
+
+type RateLimitMiddleware interface {
+	// Limit applies every rule configured for route. Routes without rules
+	// are not limited.
+	Limit(route string) gin.HandlerFunc
+}
+
+type rateLimitRule struct {
+	key   string
+	limit dto.RateLimit
+}
+
+type rateLimitMiddleware struct {
+	presenter presenter.Presenter
+	limiter   srv.RateLimiter
+	rules     map[string][]rateLimitRule
+	logger    *zap.Logger
+}
+
+// NewRateLimitMiddleware parses RATE_LIMIT_RULES, a comma separated list of
+// ROUTE:KEY=RATE/PERIOD[:BURST] entries, e.g. "login:ip=20/1m,login:username=5/1m:10".
+// KEY is one of ip, username, api_key or client_id.
+func NewRateLimitMiddleware(
+	presenter presenter.Presenter,
+	limiter srv.RateLimiter,
+	rules string,
+	logger *zap.Logger,
+) (RateLimitMiddleware, error) {
+	parsed, err := parseRateLimitRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rateLimitMiddleware{
+		presenter: presenter,
+		limiter:   limiter,
+		rules:     parsed,
+		logger:    logger,
+	}, nil
+}
+
+func (m *rateLimitMiddleware) Limit(route string) gin.HandlerFunc {
+	rules := m.rules[route]
+	return func(c *gin.Context) {
+		if len(rules) == 0 {
+			c.Next()
+			return
+		}
+
+		var tightest *dto.RateLimitResult
+		for _, rule := range rules {
+			value := m.keyValue(c, rule.key)
+			if value == "" {
+				continue
+			}
+
+			result, err := m.limiter.Allow(c.Request.Context(), route+":"+rule.key+":"+value, rule.limit)
+			if err != nil {
+				// Fail open: a Redis outage must not lock everyone out of login.
+				rateLimitFailOpen.Add(1)
+				m.logger.Error("Rate limiter unavailable", zap.String("route", route), zap.Error(err))
+				continue
+			}
+
+			if tighter(result, tightest) {
+				tightest = result
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+
+		if tightest == nil {
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, tightest)
+
+		if !tightest.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(tightest.RetryAfter)))
+			m.logger.Warn("Rate limit exceeded", zap.String("route", route), zap.String("ip", c.ClientIP()))
+			m.presenter.TooManyRequests(c, "Too many requests", domain.ErrRateLimited)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tighter reports whether result should be reported instead of tightest: a
+// denial wins, then the fewest remaining requests.
+func tighter(result, tightest *dto.RateLimitResult) bool {
+	if tightest == nil || !result.Allowed {
+		return true
+	}
+	return tightest.Allowed && result.Remaining < tightest.Remaining
+}
+
+// keyValue returns what a rule counts requests by. API keys are fingerprinted
+// so they never reach Redis in plain text.
+func (m *rateLimitMiddleware) keyValue(c *gin.Context, key string) string {
+	switch key {
+	case KeyIP:
+		return c.ClientIP()
+	case KeyAPIKey:
+		if apiKey := c.GetHeader(apiKeyHeader); apiKey != "" {
+			return entity.APIKeyFingerprint(apiKey)
+		}
+		return ""
+	case KeyUsername:
+		return boundKeyValue(strings.ToLower(peekBodyField(c, "user_name")))
+	case KeyClientID:
+		if clientID, _, ok := c.Request.BasicAuth(); ok {
+			return boundKeyValue(clientID)
+		}
+		return boundKeyValue(peekBodyField(c, "client_id"))
+	default:
+		return ""
+	}
+}
+
+func boundKeyValue(value string) string {
+	if len(value) <= maxKeyValue {
+		return value
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// peekBodyField reads a field from a JSON or form body and puts the body back
+// for the handler to bind.
+func peekBodyField(c *gin.Context, field string) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPeekBody))
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+
+	if strings.HasPrefix(c.ContentType(), "application/x-www-form-urlencoded") {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return ""
+		}
+		return values.Get(field)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	value, _ := fields[field].(string)
+	return value
+}
+
+// setRateLimitHeaders writes the IETF RateLimit header fields.
+func setRateLimitHeaders(c *gin.Context, result *dto.RateLimitResult) {
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+func parseRateLimitRules(raw string) (map[string][]rateLimitRule, error) {
+	rules := map[string][]rateLimitRule{}
+
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, spec, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q: want ROUTE:KEY=RATE/PERIOD[:BURST]", entry)
+		}
+
+		key, limitSpec, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q: missing KEY=", entry)
+		}
+		switch key {
+		case KeyIP, KeyUsername, KeyAPIKey, KeyClientID:
+		default:
+			return nil, fmt.Errorf("rate limit rule %q: unknown key %q", entry, key)
+		}
+
+		limitSpec, burstSpec, hasBurst := strings.Cut(limitSpec, ":")
+		rateSpec, periodSpec, ok := strings.Cut(limitSpec, "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit rule %q: want RATE/PERIOD", entry)
+		}
+
+		rate, err := strconv.Atoi(rateSpec)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q: invalid rate %q", entry, rateSpec)
+		}
+
+		period, err := time.ParseDuration(periodSpec)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("rate limit rule %q: invalid period %q", entry, periodSpec)
+		}
+
+		burst := rate
+		if hasBurst {
+			burst, err = strconv.Atoi(burstSpec)
+			if err != nil || burst <= 0 {
+				return nil, fmt.Errorf("rate limit rule %q: invalid burst %q", entry, burstSpec)
+			}
+		}
+
+		rules[route] = append(rules[route], rateLimitRule{
+			key:   key,
+			limit: dto.RateLimit{Rate: rate, Period: period, Burst: burst},
+		})
+	}
+
+	return rules, nil
+}
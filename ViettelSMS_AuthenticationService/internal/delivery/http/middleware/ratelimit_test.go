@@ -0,0 +1,355 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/presenter"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+func TestParseRateLimitRules(t *testing.T) {
+	rules, err := parseRateLimitRules(" login:ip=20/1m, login:username=5/1m:10 ,,token:client_id=1/2s, token:api_key=100/1h:1")
+	if err != nil {
+		t.Fatalf("parseRateLimitRules: %v", err)
+	}
+
+	want := map[string][]rateLimitRule{
+		"login": {
+			{key: KeyIP, limit: dto.RateLimit{Rate: 20, Period: time.Minute, Burst: 20}},
+			{key: KeyUsername, limit: dto.RateLimit{Rate: 5, Period: time.Minute, Burst: 10}},
+		},
+		"token": {
+			{key: KeyClientID, limit: dto.RateLimit{Rate: 1, Period: 2 * time.Second, Burst: 1}},
+			{key: KeyAPIKey, limit: dto.RateLimit{Rate: 100, Period: time.Hour, Burst: 1}},
+		},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("parsed %d routes, want %d", len(rules), len(want))
+	}
+	for route, wantRules := range want {
+		got := rules[route]
+		if len(got) != len(wantRules) {
+			t.Fatalf("route %s: %d rules, want %d", route, len(got), len(wantRules))
+		}
+		for i := range wantRules {
+			if got[i] != wantRules[i] {
+				t.Errorf("route %s rule %d = %+v, want %+v", route, i, got[i], wantRules[i])
+			}
+		}
+	}
+
+	if rules, err := parseRateLimitRules(""); err != nil || len(rules) != 0 {
+		t.Errorf("parseRateLimitRules(\"\") = %v, %v, want no rules", rules, err)
+	}
+}
+
+func TestParseRateLimitRulesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"no route", "ip=20/1m"},
+		{"no key", "login:20/1m"},
+		{"unknown key", "login:email=20/1m"},
+		{"no period", "login:ip=20"},
+		{"rate not a number", "login:ip=many/1m"},
+		{"zero rate", "login:ip=0/1m"},
+		{"negative rate", "login:ip=-1/1m"},
+		{"period without unit", "login:ip=20/60"},
+		{"zero period", "login:ip=20/0s"},
+		{"burst not a number", "login:ip=20/1m:lots"},
+		{"zero burst", "login:ip=20/1m:0"},
+		{"one bad entry", "login:ip=20/1m,login:ip=20"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseRateLimitRules(tt.raw); err == nil {
+				t.Errorf("parseRateLimitRules(%q) succeeded", tt.raw)
+			}
+		})
+	}
+}
+
+func TestTighter(t *testing.T) {
+	allowed := func(remaining int) *dto.RateLimitResult {
+		return &dto.RateLimitResult{Allowed: true, Remaining: remaining}
+	}
+	denied := &dto.RateLimitResult{Allowed: false}
+
+	tests := []struct {
+		name     string
+		result   *dto.RateLimitResult
+		tightest *dto.RateLimitResult
+		want     bool
+	}{
+		{"first result", allowed(5), nil, true},
+		{"fewer remaining", allowed(2), allowed(5), true},
+		{"more remaining", allowed(7), allowed(5), false},
+		{"same remaining", allowed(5), allowed(5), false},
+		{"denial beats allowance", denied, allowed(0), true},
+		{"allowance never beats denial", allowed(0), denied, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tighter(tt.result, tt.tightest); got != tt.want {
+				t.Errorf("tighter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCeilSeconds(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want int
+	}{
+		{0, 0},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1001 * time.Millisecond, 2},
+		{90 * time.Second, 90},
+	}
+	for _, tt := range tests {
+		if got := ceilSeconds(tt.d); got != tt.want {
+			t.Errorf("ceilSeconds(%s) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
+
+// fakeLimiter answers from results keyed by the rule key, "ip", "username"
+// and so on, and records the full keys it was asked about.
+type fakeLimiter struct {
+	results map[string]*dto.RateLimitResult
+	err     error
+	keys    []string
+}
+
+func (f *fakeLimiter) Allow(_ context.Context, key string, _ dto.RateLimit) (*dto.RateLimitResult, error) {
+	f.keys = append(f.keys, key)
+	if f.err != nil {
+		return nil, f.err
+	}
+	parts := strings.SplitN(key, ":", 3)
+	return f.results[parts[1]], nil
+}
+
+func newTestRateLimit(t *testing.T, limiter *fakeLimiter, rules string) RateLimitMiddleware {
+	t.Helper()
+	m, err := NewRateLimitMiddleware(presenter.NewPresenter(), limiter, rules, zap.NewNop())
+	if err != nil {
+		t.Fatalf("NewRateLimitMiddleware: %v", err)
+	}
+	return m
+}
+
+func serveLimited(m RateLimitMiddleware, req *http.Request) (*httptest.ResponseRecorder, string) {
+	var body string
+	router := gin.New()
+	router.POST("/", m.Limit("login"), func(c *gin.Context) {
+		raw, _ := io.ReadAll(c.Request.Body)
+		body = string(raw)
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, body
+}
+
+func TestLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		results    map[string]*dto.RateLimitResult
+		status     int
+		limit      string
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{
+			name: "tightest allowed rule reported",
+			results: map[string]*dto.RateLimitResult{
+				KeyIP:       {Allowed: true, Limit: 20, Remaining: 15, ResetAfter: 15 * time.Second},
+				KeyUsername: {Allowed: true, Limit: 10, Remaining: 3, ResetAfter: 1500 * time.Millisecond},
+			},
+			status: http.StatusOK, limit: "10", remaining: "3", reset: "2",
+		},
+		{
+			name: "denial reported over allowance",
+			results: map[string]*dto.RateLimitResult{
+				KeyIP:       {Allowed: false, Limit: 20, Remaining: 0, RetryAfter: 2500 * time.Millisecond, ResetAfter: 60 * time.Second},
+				KeyUsername: {Allowed: true, Limit: 10, Remaining: 0, ResetAfter: time.Second},
+			},
+			status: http.StatusTooManyRequests, limit: "20", remaining: "0", reset: "60", retryAfter: "3",
+		},
+		{
+			name: "later denial wins",
+			results: map[string]*dto.RateLimitResult{
+				KeyIP:       {Allowed: true, Limit: 20, Remaining: 0, ResetAfter: time.Second},
+				KeyUsername: {Allowed: false, Limit: 10, Remaining: 0, RetryAfter: 100 * time.Millisecond, ResetAfter: 6 * time.Second},
+			},
+			status: http.StatusTooManyRequests, limit: "10", remaining: "0", reset: "6", retryAfter: "1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestRateLimit(t, &fakeLimiter{results: tt.results}, "login:ip=20/1m,login:username=5/1m:10")
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"user_name":"Alice"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w, _ := serveLimited(m, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			for header, want := range map[string]string{
+				"RateLimit-Limit":     tt.limit,
+				"RateLimit-Remaining": tt.remaining,
+				"RateLimit-Reset":     tt.reset,
+				"Retry-After":         tt.retryAfter,
+			} {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimitFailsOpen(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := newTestRateLimit(t, &fakeLimiter{err: errors.New("redis down")}, "login:ip=20/1m")
+
+	before := rateLimitFailOpen.Value()
+	w, _ := serveLimited(m, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want the request let through", w.Code)
+	}
+	if w.Header().Get("RateLimit-Limit") != "" {
+		t.Error("rate limit headers set without a limiter answer")
+	}
+	if got := rateLimitFailOpen.Value() - before; got != 1 {
+		t.Errorf("fail-open counter grew by %d, want 1", got)
+	}
+}
+
+func TestLimitKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	allowed := &dto.RateLimitResult{Allowed: true, Limit: 1, Remaining: 1}
+	results := map[string]*dto.RateLimitResult{KeyIP: allowed, KeyUsername: allowed, KeyAPIKey: allowed, KeyClientID: allowed}
+	longName := strings.Repeat("a", maxKeyValue+1)
+
+	tests := []struct {
+		name        string
+		rules       string
+		contentType string
+		body        string
+		header      map[string]string
+		basicAuth   string
+		want        []string
+	}{
+		{
+			name:  "api key fingerprinted",
+			rules: "login:api_key=1/1m",
+			header: map[string]string{
+				apiKeyHeader: "secret-key",
+			},
+			want: []string{"login:api_key:" + entity.APIKeyFingerprint("secret-key")},
+		},
+		{
+			name:        "username lowered",
+			rules:       "login:username=1/1m",
+			contentType: "application/json",
+			body:        `{"user_name":"Alice"}`,
+			want:        []string{"login:username:alice"},
+		},
+		{
+			name:        "long username hashed",
+			rules:       "login:username=1/1m",
+			contentType: "application/json",
+			body:        `{"user_name":"` + longName + `"}`,
+			want:        []string{"login:username:" + boundKeyValue(longName)},
+		},
+		{
+			name:        "client id from form",
+			rules:       "login:client_id=1/1m",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "client_id=vcs-cli&grant_type=x",
+			want:        []string{"login:client_id:vcs-cli"},
+		},
+		{
+			name:      "client id from basic auth",
+			rules:     "login:client_id=1/1m",
+			basicAuth: "basic-client",
+			want:      []string{"login:client_id:basic-client"},
+		},
+		{
+			name:        "missing values skip their rules",
+			rules:       "login:username=1/1m,login:client_id=1/1m,login:api_key=1/1m",
+			contentType: "application/json",
+			body:        `{"password":"x"}`,
+			want:        nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := &fakeLimiter{results: results}
+			m := newTestRateLimit(t, limiter, tt.rules)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			for name, value := range tt.header {
+				req.Header.Set(name, value)
+			}
+			if tt.basicAuth != "" {
+				req.SetBasicAuth(tt.basicAuth, "secret")
+			}
+
+			w, body := serveLimited(m, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if body != tt.body {
+				t.Errorf("handler read body %q, want %q", body, tt.body)
+			}
+			if strings.Join(limiter.keys, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("keys = %q, want %q", limiter.keys, tt.want)
+			}
+			for _, key := range limiter.keys {
+				if len(key) > len("login:client_id:")+maxKeyValue {
+					t.Errorf("key %q is longer than the bound", key)
+				}
+			}
+		})
+	}
+}
+
+func TestBoundKeyValue(t *testing.T) {
+	short := strings.Repeat("a", maxKeyValue)
+	if got := boundKeyValue(short); got != short {
+		t.Errorf("boundKeyValue kept %q as %q", short, got)
+	}
+
+	long := strings.Repeat("a", maxKeyValue+1)
+	got := boundKeyValue(long)
+	if len(got) != maxKeyValue || got == long[:maxKeyValue] {
+		t.Errorf("boundKeyValue(%d chars) = %q, want a %d character digest", len(long), got, maxKeyValue)
+	}
+	if boundKeyValue(long+"b") == got {
+		t.Error("two long values share a key")
+	}
+}
@@ -0,0 +1,250 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+// scopeExpr is a compiled scope requirement such as
+// "server:update && (server:import || user:scope)".
+type scopeExpr interface {
+	// eval reports whether granted satisfies the expression and, if not,
+	// the smallest part of it that failed.
+	eval(granted []string, matcher srv.ScopeMatcher) (bool, scopeExpr)
+	// scopes lists the scope literals a passing token is relying on, which
+	// are the ones whose IP rules apply. Negated scopes are left out.
+	scopes() []string
+	String() string
+}
+
+type scopeLiteral string
+
+func (s scopeLiteral) eval(granted []string, matcher srv.ScopeMatcher) (bool, scopeExpr) {
+	if matcher.Grants(granted, string(s)) {
+		return true, nil
+	}
+	return false, s
+}
+
+func (s scopeLiteral) scopes() []string { return []string{string(s)} }
+func (s scopeLiteral) String() string   { return string(s) }
+
+type scopeAll []scopeExpr
+
+func (a scopeAll) eval(granted []string, matcher srv.ScopeMatcher) (bool, scopeExpr) {
+	for _, expr := range a {
+		if ok, failed := expr.eval(granted, matcher); !ok {
+			return false, failed
+		}
+	}
+	return true, nil
+}
+
+func (a scopeAll) scopes() []string { return collectScopes(a) }
+func (a scopeAll) String() string   { return joinExprs(a, " && ") }
+
+type scopeAny []scopeExpr
+
+func (a scopeAny) eval(granted []string, matcher srv.ScopeMatcher) (bool, scopeExpr) {
+	for _, expr := range a {
+		if ok, _ := expr.eval(granted, matcher); ok {
+			return true, nil
+		}
+	}
+	return false, a
+}
+
+func (a scopeAny) scopes() []string { return collectScopes(a) }
+func (a scopeAny) String() string   { return joinExprs(a, " || ") }
+
+type scopeNot struct {
+	expr scopeExpr
+}
+
+func (n scopeNot) eval(granted []string, matcher srv.ScopeMatcher) (bool, scopeExpr) {
+	if ok, _ := n.expr.eval(granted, matcher); ok {
+		return false, n
+	}
+	return true, nil
+}
+
+func (n scopeNot) scopes() []string { return nil }
+
+func (n scopeNot) String() string {
+	switch n.expr.(type) {
+	case scopeAll, scopeAny:
+		return "!(" + n.expr.String() + ")"
+	}
+	return "!" + n.expr.String()
+}
+
+func collectScopes(exprs []scopeExpr) []string {
+	var scopes []string
+	for _, expr := range exprs {
+		scopes = append(scopes, expr.scopes()...)
+	}
+	return scopes
+}
+
+func joinExprs(exprs []scopeExpr, sep string) string {
+	parts := make([]string, len(exprs))
+	for i, expr := range exprs {
+		parts[i] = expr.String()
+		// Parenthesise nested lists so the string parses back the same.
+		switch expr.(type) {
+		case scopeAll, scopeAny:
+			if len(exprs) > 1 {
+				parts[i] = "(" + parts[i] + ")"
+			}
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// compileScopeExpr parses a scope expression. The grammar, loosest first:
+//
+//	or   = and { "||" and }
+//	and  = not { "&&" not }
+//	not  = "!" not | atom
+//	atom = SCOPE | "(" or ")"
+func compileScopeExpr(input string, matcher srv.ScopeMatcher) (scopeExpr, error) {
+	tokens, err := tokenizeScopeExpr(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("scope expression %q: empty", input)
+	}
+
+	p := &scopeParser{input: input, tokens: tokens, matcher: matcher}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("scope expression %q: unexpected %q", input, p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+func tokenizeScopeExpr(input string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(input); {
+		switch c := input[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')' || c == '!':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(input[i:], "&&"), strings.HasPrefix(input[i:], "||"):
+			tokens = append(tokens, input[i:i+2])
+			i += 2
+		case isScopeChar(c):
+			start := i
+			for i < len(input) && isScopeChar(input[i]) {
+				i++
+			}
+			tokens = append(tokens, input[start:i])
+		default:
+			return nil, fmt.Errorf("scope expression %q: unexpected character %q at %d", input, c, i)
+		}
+	}
+	return tokens, nil
+}
+
+func isScopeChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == ':' || c == '*' || c == '_' || c == '-'
+}
+
+type scopeParser struct {
+	input   string
+	tokens  []string
+	pos     int
+	matcher srv.ScopeMatcher
+}
+
+func (p *scopeParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *scopeParser) parseOr() (scopeExpr, error) {
+	var terms scopeAny
+	for {
+		term, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if p.peek() != "||" {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *scopeParser) parseAnd() (scopeExpr, error) {
+	var terms scopeAll
+	for {
+		term, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if p.peek() != "&&" {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *scopeParser) parseNot() (scopeExpr, error) {
+	if p.peek() == "!" {
+		p.pos++
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return scopeNot{expr: expr}, nil
+	}
+	return p.parseAtom()
+}
+
+func (p *scopeParser) parseAtom() (scopeExpr, error) {
+	token := p.peek()
+	switch token {
+	case "":
+		return nil, fmt.Errorf("scope expression %q: unexpected end", p.input)
+	case "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("scope expression %q: missing )", p.input)
+		}
+		p.pos++
+		return expr, nil
+	case ")", "&&", "||":
+		return nil, fmt.Errorf("scope expression %q: unexpected %q", p.input, token)
+	}
+
+	if !p.matcher.Valid(token) {
+		return nil, fmt.Errorf("scope expression %q: invalid scope %q", p.input, token)
+	}
+	p.pos++
+	return scopeLiteral(token), nil
+}
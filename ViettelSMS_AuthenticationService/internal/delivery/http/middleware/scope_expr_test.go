@@ -0,0 +1,276 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/delivery/http/presenter"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/scope"
+)
+
+func newTestMatcher(t *testing.T) srv.ScopeMatcher {
+	t.Helper()
+	m, err := scope.NewMatcher(scope.Grammar{
+		Actions: map[string][]string{"update": {"read"}},
+	})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+	return m
+}
+
+func TestCompileScopeExprPrecedence(t *testing.T) {
+	m := newTestMatcher(t)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"single scope", "a:read", "a:read"},
+		{"and", "a:read && b:read", "a:read && b:read"},
+		{"or", "a:read || b:read", "a:read || b:read"},
+		{"and binds tighter than or", "a:read || b:read && c:read", "a:read || (b:read && c:read)"},
+		{"and binds tighter than or on the left", "a:read && b:read || c:read", "(a:read && b:read) || c:read"},
+		{"not binds tighter than and", "!a:read && b:read", "!a:read && b:read"},
+		{"not binds tighter than or", "!a:read || b:read", "!a:read || b:read"},
+		{"double negation", "!!a:read", "!!a:read"},
+		{"parentheses override precedence", "(a:read || b:read) && c:read", "(a:read || b:read) && c:read"},
+		{"negated group", "!(a:read || b:read)", "!(a:read || b:read)"},
+		{"redundant parentheses", "((a:read))", "a:read"},
+		{"nested groups", "a:read && (b:read || (c:read && d:read))", "a:read && (b:read || (c:read && d:read))"},
+		{"wildcards", "server:* || *:read", "server:* || *:read"},
+		{"whitespace", "\ta:read&&b:read  ||c:read", "(a:read && b:read) || c:read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := compileScopeExpr(tt.input, m)
+			if err != nil {
+				t.Fatalf("compileScopeExpr(%q): %v", tt.input, err)
+			}
+			if got := expr.String(); got != tt.want {
+				t.Errorf("compileScopeExpr(%q).String() = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileScopeExprErrors(t *testing.T) {
+	m := newTestMatcher(t)
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", "empty"},
+		{"blank", "   ", "empty"},
+		{"single ampersand", "a:read & b:read", "unexpected character '&'"},
+		{"single pipe", "a:read | b:read", "unexpected character '|'"},
+		{"upper case", "A:read", "unexpected character 'A'"},
+		{"missing close", "(a:read || b:read", "missing )"},
+		{"missing close nested", "a:read && (b:read || (c:read)", "missing )"},
+		{"extra close", "a:read)", `unexpected ")"`},
+		{"empty group", "()", `unexpected ")"`},
+		{"dangling and", "a:read &&", "unexpected end"},
+		{"dangling or", "a:read ||", "unexpected end"},
+		{"dangling not", "!", "unexpected end"},
+		{"leading and", "&& a:read", `unexpected "&&"`},
+		{"leading or", "|| a:read", `unexpected "||"`},
+		{"double operator", "a:read && || b:read", `unexpected "||"`},
+		{"missing operator", "a:read b:read", `unexpected "b:read"`},
+		{"invalid scope", "server", `invalid scope "server"`},
+		{"invalid scope in group", "a:read && (b:)", `invalid scope "b:"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileScopeExpr(tt.input, m)
+			if err == nil {
+				t.Fatalf("compileScopeExpr(%q) succeeded", tt.input)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("compileScopeExpr(%q) error = %q, want it to contain %q", tt.input, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeExprEval(t *testing.T) {
+	m := newTestMatcher(t)
+
+	tests := []struct {
+		name    string
+		input   string
+		granted []string
+		want    bool
+		failed  string
+	}{
+		{"literal granted", "a:read", []string{"a:read"}, true, ""},
+		{"literal through implication", "a:read", []string{"a:update"}, true, ""},
+		{"literal through wildcard", "a:read", []string{"a:*"}, true, ""},
+		{"literal missing", "a:read", nil, false, "a:read"},
+
+		{"and all granted", "a:read && b:read", []string{"a:read", "b:read"}, true, ""},
+		{"and reports first missing", "a:read && b:read && c:read", []string{"a:read"}, false, "b:read"},
+		{"or one granted", "a:read || b:read", []string{"b:read"}, true, ""},
+		{"or reports whole list", "a:read || b:read", []string{"c:read"}, false, "a:read || b:read"},
+
+		{"not satisfied", "!a:delete", []string{"a:read"}, true, ""},
+		{"not reports itself", "!a:delete", []string{"a:*"}, false, "!a:delete"},
+		{"negated group reports itself", "a:read && !(b:read || c:read)", []string{"a:read", "c:read"}, false, "!(b:read || c:read)"},
+
+		{"precedence: or of and", "a:read || b:read && c:read", []string{"a:read"}, true, ""},
+		{"precedence: and inside or fails", "a:read || b:read && c:read", []string{"b:read"}, false, "a:read || (b:read && c:read)"},
+		{"group inside and reports group", "a:read && (b:read || c:read)", []string{"a:read"}, false, "b:read || c:read"},
+		{"group inside and passes", "a:read && (b:read || c:read)", []string{"a:read", "c:read"}, true, ""},
+		{"nested and reports innermost", "a:read && (b:read && c:read)", []string{"a:read", "b:read"}, false, "c:read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := compileScopeExpr(tt.input, m)
+			if err != nil {
+				t.Fatalf("compileScopeExpr(%q): %v", tt.input, err)
+			}
+
+			ok, failed := expr.eval(tt.granted, m)
+			if ok != tt.want {
+				t.Fatalf("eval(%q, %q) = %v, want %v", tt.input, tt.granted, ok, tt.want)
+			}
+			if ok {
+				if failed != nil {
+					t.Errorf("eval(%q) passed but reported failed %q", tt.input, failed)
+				}
+				return
+			}
+			if failed == nil {
+				t.Fatalf("eval(%q) failed without reporting a sub-expression", tt.input)
+			}
+			if got := failed.String(); got != tt.failed {
+				t.Errorf("eval(%q) failed = %q, want %q", tt.input, got, tt.failed)
+			}
+		})
+	}
+}
+
+func TestScopeExprScopes(t *testing.T) {
+	m := newTestMatcher(t)
+
+	expr, err := compileScopeExpr("a:read && (b:read || !c:read) && !(d:read && e:read)", m)
+	if err != nil {
+		t.Fatalf("compileScopeExpr: %v", err)
+	}
+
+	got := strings.Join(expr.scopes(), ",")
+	if want := "a:read,b:read"; got != want {
+		t.Errorf("scopes() = %q, want %q", got, want)
+	}
+}
+
+func TestScopeExprStringRoundTrip(t *testing.T) {
+	m := newTestMatcher(t)
+
+	inputs := []string{
+		"a:read",
+		"a:read && b:read && c:read",
+		"a:read || b:read && c:read",
+		"(a:read || b:read) && c:read",
+		"!a:read && !(b:read || c:read)",
+		"!(a:read && b:read) || !!c:read",
+		"a:read && (b:read || (c:read && (d:read || e:read)))",
+		"((a:read && b:read) || (c:read && d:read)) && !e:*",
+		"*:* || server:* && *:read",
+	}
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			expr, err := compileScopeExpr(input, m)
+			if err != nil {
+				t.Fatalf("compileScopeExpr(%q): %v", input, err)
+			}
+
+			printed := expr.String()
+			reparsed, err := compileScopeExpr(printed, m)
+			if err != nil {
+				t.Fatalf("String() = %q does not parse: %v", printed, err)
+			}
+			if got := reparsed.String(); got != printed {
+				t.Errorf("round trip of %q: %q, then %q", input, printed, got)
+			}
+		})
+	}
+}
+
+type allowAllIPs struct{}
+
+func (allowAllIPs) Allowed(context.Context, string, ...dto.IPSubject) (bool, error) { return true, nil }
+func (allowAllIPs) Invalidate()                                                     {}
+
+func TestRequireScopeExprDenial(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := &jwtMiddleware{
+		presenter: presenter.NewPresenter(),
+		ipAccess:  allowAllIPs{},
+		scopes:    newTestMatcher(t),
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		granted  []string
+		status   int
+		required string
+		failed   string
+	}{
+		{"passes", "a:read && (b:read || c:read)", []string{"a:update", "c:read"}, http.StatusOK, "", ""},
+		{"missing literal", "a:read && b:read", []string{"a:read"}, http.StatusForbidden, "a:read && b:read", "b:read"},
+		{"missing group", "a:read && (b:read || c:read)", []string{"a:read"}, http.StatusForbidden, "a:read && (b:read || c:read)", "b:read || c:read"},
+		{"forbidden scope held", "a:read && !a:delete", []string{"a:*"}, http.StatusForbidden, "a:read && !a:delete", "!a:delete"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				c.Set("scopes", tt.granted)
+			}, s.RequireScopeExpr(tt.expr), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Error struct {
+					Details dto.ScopeDenial `json:"details"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode body %s: %v", w.Body, err)
+			}
+			if got := body.Error.Details; got.Required != tt.required || got.Failed != tt.failed {
+				t.Errorf("denial = %+v, want required %q, failed %q", got, tt.required, tt.failed)
+			}
+		})
+	}
+}
+
+func TestRequireScopeExprPanicsOnMalformedExpr(t *testing.T) {
+	s := &jwtMiddleware{scopes: newTestMatcher(t)}
+	defer func() {
+		if recover() == nil {
+			t.Error("RequireScopeExpr accepted a malformed expression")
+		}
+	}()
+	s.RequireScopeExpr("a:read &&")
+}
@@ -0,0 +1,114 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/response"
+)
+
+type errorMapping struct {
+	err     error
+	status  int
+	code    string
+	message string
+	// oauthCode is the RFC 6749 / RFC 8628 error code used on OAuth endpoints.
+	oauthCode string
+}
+
+// errorMappings translates every error in domain/errors to an HTTP response.
+// Credential failures all share one response so a client cannot tell an
+// unknown username from a wrong password.
+var errorMappings = []errorMapping{
+	{domain.ErrInvalidCredentials, http.StatusUnauthorized, response.CodeAuthError, "Invalid username or password", response.OAuthInvalidGrant},
+	{domain.ErrUserNotFound, http.StatusNotFound, response.CodeNotFound, "User not found", response.OAuthInvalidGrant},
+	{domain.ErrUserConflict, http.StatusConflict, response.CodeConflict, "Username or email already exists", response.OAuthInvalidRequest},
+	{domain.ErrInvalidToken, http.StatusUnauthorized, response.CodeInvalidToken, "Invalid token", response.OAuthInvalidGrant},
+	{domain.ErrSessionRevoked, http.StatusUnauthorized, response.CodeInvalidToken, "Session revoked", response.OAuthInvalidGrant},
+
+	{domain.ErrClientCertRequired, http.StatusUnauthorized, response.CodeUnauthorized, "Client certificate required", response.OAuthInvalidRequest},
+	{domain.ErrMachineNotFound, http.StatusUnauthorized, response.CodeAuthError, "Unknown client certificate", response.OAuthInvalidGrant},
+	{domain.ErrMachineBlocked, http.StatusForbidden, response.CodeForbidden, "Machine identity is blocked", response.OAuthAccessDenied},
+
+	{domain.ErrUnsupportedGrantType, http.StatusBadRequest, response.CodeBadRequest, "Unsupported grant type", response.OAuthUnsupportedGrantType},
+	{domain.ErrAuthorizationPending, http.StatusBadRequest, response.CodeBadRequest, "Authorization pending", response.OAuthAuthorizationPending},
+	{domain.ErrSlowDown, http.StatusBadRequest, response.CodeBadRequest, "Polling too frequently", response.OAuthSlowDown},
+	{domain.ErrAccessDenied, http.StatusForbidden, response.CodeForbidden, "Authorization request denied", response.OAuthAccessDenied},
+	{domain.ErrInvalidClient, http.StatusUnauthorized, response.CodeUnauthorized, "Client does not match the authorization request", response.OAuthInvalidClient},
+	{domain.ErrUnknownClient, http.StatusUnauthorized, response.CodeUnauthorized, "Unknown client", response.OAuthInvalidClient},
+	{domain.ErrExpiredToken, http.StatusBadRequest, response.CodeTokenExpired, "Device code expired", response.OAuthExpiredToken},
+	{domain.ErrInvalidUserCode, http.StatusBadRequest, response.CodeBadRequest, "Invalid or expired user code", response.OAuthInvalidRequest},
+
+	{domain.ErrInvalidResetToken, http.StatusBadRequest, response.CodeInvalidToken, "Invalid or expired reset token", response.OAuthInvalidGrant},
+	{domain.ErrWeakPassword, http.StatusBadRequest, response.CodeValidationError, "New password does not meet the password policy", response.OAuthInvalidRequest},
+	{domain.ErrPasswordChangeRequired, http.StatusForbidden, response.CodeForbidden, "Password change required", response.OAuthAccessDenied},
+	{domain.ErrRateLimited, http.StatusTooManyRequests, response.CodeTooManyRequests, "Too many requests", response.OAuthSlowDown},
+	{domain.ErrChallengeRequired, http.StatusPreconditionRequired, response.CodeChallengeRequired, "Solve the challenge from /auth/challenge and retry", response.OAuthInvalidRequest},
+	{domain.ErrInvalidChallenge, http.StatusPreconditionRequired, response.CodeChallengeRequired, "Challenge answer is invalid or expired", response.OAuthInvalidRequest},
+	{domain.ErrLoginBlocked, http.StatusForbidden, response.CodeForbidden, "Login blocked as suspicious, contact an administrator", response.OAuthAccessDenied},
+	{domain.ErrInvalidMFACode, http.StatusUnauthorized, response.CodeAuthError, "Invalid or expired verification code", response.OAuthInvalidGrant},
+	{domain.ErrIPNotAllowed, http.StatusForbidden, response.CodeForbidden, "Access from this network is not allowed", response.OAuthAccessDenied},
+
+	{domain.ErrInvalidIPRule, http.StatusBadRequest, response.CodeValidationError, "Invalid IP rule", response.OAuthInvalidRequest},
+	{domain.ErrIPRuleNotFound, http.StatusNotFound, response.CodeNotFound, "IP rule not found", response.OAuthInvalidRequest},
+	{domain.ErrIPRuleConflict, http.StatusConflict, response.CodeConflict, "IP rule already exists", response.OAuthInvalidRequest},
+
+	{domain.ErrRoleNotFound, http.StatusNotFound, response.CodeNotFound, "Role not found", response.OAuthInvalidRequest},
+	{domain.ErrRoleConflict, http.StatusConflict, response.CodeConflict, "Role already exists", response.OAuthInvalidRequest},
+	{domain.ErrRoleBuiltIn, http.StatusConflict, response.CodeConflict, "Built-in roles cannot be changed or deleted", response.OAuthInvalidRequest},
+	{domain.ErrInvalidRole, http.StatusBadRequest, response.CodeValidationError, "Invalid role", response.OAuthInvalidRequest},
+
+	{domain.ErrInvalidPermission, http.StatusBadRequest, response.CodeValidationError, "Invalid resource permission", response.OAuthInvalidRequest},
+	{domain.ErrPermissionNotFound, http.StatusNotFound, response.CodeNotFound, "Resource permission not found", response.OAuthInvalidRequest},
+	{domain.ErrPermissionConflict, http.StatusConflict, response.CodeConflict, "Resource permission already exists", response.OAuthInvalidRequest},
+
+	{domain.ErrGroupNotFound, http.StatusNotFound, response.CodeNotFound, "Group not found", response.OAuthInvalidRequest},
+	{domain.ErrGroupConflict, http.StatusConflict, response.CodeConflict, "Group already exists", response.OAuthInvalidRequest},
+	{domain.ErrGroupCycle, http.StatusConflict, response.CodeConflict, "Group nesting would create a cycle", response.OAuthInvalidRequest},
+	{domain.ErrInvalidGroup, http.StatusBadRequest, response.CodeValidationError, "Invalid group", response.OAuthInvalidRequest},
+
+	{domain.ErrInternalServer, http.StatusInternalServerError, response.CodeInternalServerError, "Internal server error", response.OAuthServerError},
+}
+
+func lookupError(err error) errorMapping {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			return mapping
+		}
+	}
+	return errorMapping{
+		err:       domain.ErrInternalServer,
+		status:    http.StatusInternalServerError,
+		code:      response.CodeInternalServerError,
+		message:   "Internal server error",
+		oauthCode: response.OAuthServerError,
+	}
+}
+
+// Error writes the response for an error returned by a usecase. Errors that
+// are not in domain/errors become a 500 without their text, which may carry
+// database or driver details.
+func (p *presenter) Error(c *gin.Context, err error) {
+	mapping := lookupError(err)
+
+	var details interface{} = mapping.err.Error()
+	var policyErr *domain.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		details = policyErr.Violations
+	}
+
+	c.JSON(mapping.status, response.NewErrorResponse(mapping.code, mapping.message, details))
+}
+
+// OAuthDomainError is Error for the OAuth endpoints.
+func (p *presenter) OAuthDomainError(c *gin.Context, err error) {
+	mapping := lookupError(err)
+	status := mapping.status
+	// RFC 6749 section 5.2 reports grant errors as 400, except server errors.
+	if status != http.StatusInternalServerError && status != http.StatusTooManyRequests {
+		status = http.StatusBadRequest
+	}
+	p.OAuthError(c, status, mapping.oauthCode, mapping.err)
+}
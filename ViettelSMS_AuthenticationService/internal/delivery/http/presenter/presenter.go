@@ -8,12 +8,26 @@ import (
 )
 
 type Presenter interface {
+	// Error maps a domain error to its status code and response code.
+	Error(c *gin.Context, err error)
+
 	InvalidRequest(c *gin.Context, message string, err error)
 	InternalError(c *gin.Context, message string, err error)
+	ValidationError(c *gin.Context, message string, details interface{})
 
 	LoginSuccess(c *gin.Context, message string, data interface{})
+	Success(c *gin.Context, message string, data interface{})
+	Created(c *gin.Context, message string, data interface{})
+	Deleted(c *gin.Context, message string)
 	Unauthorized(c *gin.Context, message string, err error)
 	Forbidden(c *gin.Context, message string, err error)
+	ForbiddenDetails(c *gin.Context, message string, details interface{})
+	NotFound(c *gin.Context, message string, err error)
+	TooManyRequests(c *gin.Context, message string, err error)
+
+	OAuthSuccess(c *gin.Context, data interface{})
+	OAuthError(c *gin.Context, status int, code string, err error)
+	OAuthDomainError(c *gin.Context, err error)
 }
 
 type presenter struct{}
@@ -38,6 +52,14 @@ func (p *presenter) InternalError(c *gin.Context, message string, err error) {
 	))
 }
 
+func (p *presenter) ValidationError(c *gin.Context, message string, details interface{}) {
+	c.JSON(http.StatusBadRequest, response.NewErrorResponse(
+		response.CodeValidationError,
+		message,
+		details,
+	))
+}
+
 func (p *presenter) LoginSuccess(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusOK, response.NewSuccessResponse(
 		response.CodeSuccess,
@@ -46,6 +68,30 @@ func (p *presenter) LoginSuccess(c *gin.Context, message string, data interface{
 	))
 }
 
+func (p *presenter) Success(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusOK, response.NewSuccessResponse(
+		response.CodeSuccess,
+		message,
+		data,
+	))
+}
+
+func (p *presenter) Created(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusCreated, response.NewSuccessResponse(
+		response.CodeCreated,
+		message,
+		data,
+	))
+}
+
+func (p *presenter) Deleted(c *gin.Context, message string) {
+	c.JSON(http.StatusOK, response.NewSuccessResponse(
+		response.CodeDeleted,
+		message,
+		nil,
+	))
+}
+
 func (p *presenter) Unauthorized(c *gin.Context, message string, err error) {
 	c.JSON(http.StatusUnauthorized, response.NewErrorResponse(
 		response.CodeUnauthorized,
@@ -61,3 +107,40 @@ func (p *presenter) Forbidden(c *gin.Context, message string, err error) {
 		err.Error(),
 	))
 }
+
+func (p *presenter) ForbiddenDetails(c *gin.Context, message string, details interface{}) {
+	c.JSON(http.StatusForbidden, response.NewErrorResponse(
+		response.CodeForbidden,
+		message,
+		details,
+	))
+}
+
+func (p *presenter) NotFound(c *gin.Context, message string, err error) {
+	c.JSON(http.StatusNotFound, response.NewErrorResponse(
+		response.CodeNotFound,
+		message,
+		err.Error(),
+	))
+}
+
+func (p *presenter) TooManyRequests(c *gin.Context, message string, err error) {
+	c.JSON(http.StatusTooManyRequests, response.NewErrorResponse(
+		response.CodeTooManyRequests,
+		message,
+		err.Error(),
+	))
+}
+
+// OAuthSuccess writes the bare body expected by OAuth clients (RFC 6749, RFC 8628),
+// which do not understand the APIResponse envelope.
+func (p *presenter) OAuthSuccess(c *gin.Context, data interface{}) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, data)
+}
+
+// OAuthError writes an RFC 6749 section 5.2 error response.
+func (p *presenter) OAuthError(c *gin.Context, status int, code string, err error) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, response.NewOAuthErrorResponse(code, err.Error()))
+}
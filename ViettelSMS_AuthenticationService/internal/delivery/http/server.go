@@ -2,8 +2,12 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"expvar"
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -24,6 +28,7 @@ type (
 		config        *config.Config
 		controller    *controller.Controller
 		jwtMiddleware middleware.JWTMiddleware
+		rateLimit     middleware.RateLimitMiddleware
 		logger        *zap.Logger
 	}
 )
@@ -32,34 +37,83 @@ func NewHttpServer(
 	config *config.Config,
 	controller *controller.Controller,
 	jwtMiddleware middleware.JWTMiddleware,
+	rateLimit middleware.RateLimitMiddleware,
 	logger *zap.Logger,
 ) Server {
 	return &server{
 		config:        config,
 		controller:    controller,
 		jwtMiddleware: jwtMiddleware,
+		rateLimit:     rateLimit,
 		logger:        logger,
 	}
 }
 
 func (s *server) RegisterRoutes() *gin.Engine {
 	router := gin.New()
+	if err := router.SetTrustedProxies(s.config.Server.TrustedProxies); err != nil {
+		s.logger.Fatal("Invalid TRUSTED_PROXIES", zap.Error(err))
+	}
 	router.Use(gin.Recovery())
 	router.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
+		ExposeHeaders: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 	}))
 
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 	})
+	router.GET("/debug/vars", gin.WrapH(expvar.Handler()))
 
 	auth := router.Group("/auth")
 	{
 		auth.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-		auth.POST("/login", s.controller.Login)
+		auth.POST("/login", s.rateLimit.Limit("login"), s.controller.Login)
+		auth.POST("/login/verify", s.rateLimit.Limit("login_verify"), s.controller.VerifyLogin)
+		auth.POST("/challenge", s.rateLimit.Limit("challenge"), s.controller.Challenge)
 		auth.POST("/refresh/{user_id}", s.controller.RefreshToken)
+		auth.POST("/machine/token", s.rateLimit.Limit("machine_token"), s.controller.MachineToken)
+		auth.POST("/token", s.rateLimit.Limit("token"), s.controller.Token)
+		auth.POST("/device/code", s.rateLimit.Limit("device_code"), s.controller.DeviceCode)
+		auth.POST("/device/approve", s.jwtMiddleware.RequireAuth(), s.controller.ApproveDevice)
+		auth.POST("/password/forgot", s.rateLimit.Limit("password_forgot"), s.controller.ForgotPassword)
+		auth.POST("/password/reset", s.rateLimit.Limit("password_reset"), s.controller.ResetPassword)
+		auth.POST("/password/change", s.rateLimit.Limit("password_change"), s.jwtMiddleware.RequireAuthAllowRestricted(), s.controller.ChangePassword)
+		auth.POST("/users/:user_name/unlock", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:update"), s.controller.UnlockUser)
+
+		auth.GET("/scopes", s.jwtMiddleware.RequireAuthAllowMachine(), s.controller.ListScopes)
+
+		auth.GET("/roles", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.ListRoles)
+		auth.POST("/roles", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.CreateRole)
+		auth.PUT("/roles/:name", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.UpdateRole)
+		auth.DELETE("/roles/:name", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.DeleteRole)
+		auth.GET("/users/:user_name/roles", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.GetUserRoles)
+		auth.PUT("/users/:user_name/roles/:role", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.AssignRole)
+		auth.DELETE("/users/:user_name/roles/:role", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.UnassignRole)
+
+		auth.GET("/groups", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.ListGroups)
+		auth.POST("/groups", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.CreateGroup)
+		auth.PUT("/groups/:name", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.UpdateGroup)
+		auth.DELETE("/groups/:name", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.DeleteGroup)
+		auth.GET("/groups/:name/members", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.GetGroupMembers)
+		auth.PUT("/groups/:name/members/:user_name", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.AddGroupMember)
+		auth.DELETE("/groups/:name/members/:user_name", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.RemoveGroupMember)
+		auth.GET("/users/:user_name/effective-scopes", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.GetEffectiveScopes)
+
+		auth.GET("/ip-rules", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:update"), s.controller.ListIPRules)
+		auth.POST("/ip-rules", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:update"), s.controller.CreateIPRule)
+		auth.DELETE("/ip-rules/:id", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:update"), s.controller.DeleteIPRule)
+
+		auth.POST("/authorize", s.jwtMiddleware.RequireAuthAllowMachine(), s.jwtMiddleware.RequireScope("auth:authorize"), s.controller.Authorize)
+		auth.POST("/introspect", s.jwtMiddleware.RequireAuthAllowMachine(), s.jwtMiddleware.RequireScope("auth:authorize"), s.controller.Introspect)
+
+		auth.GET("/permissions", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.ListResourcePermissions)
+		auth.POST("/permissions", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.CreateResourcePermission)
+		auth.DELETE("/permissions/:id", s.jwtMiddleware.RequireAuth(), s.jwtMiddleware.RequireScope("user:scope"), s.controller.DeleteResourcePermission)
+		auth.POST("/permissions/check", s.jwtMiddleware.RequireAuthAllowMachine(), s.jwtMiddleware.RequireScope("auth:authorize"), s.controller.CheckPermission)
+		auth.GET("/permissions/resources", s.jwtMiddleware.RequireAuthAllowMachine(), s.jwtMiddleware.RequireScope("auth:authorize"), s.controller.PermittedResources)
 	}
 
 	return router
@@ -71,6 +125,24 @@ func (s *server) Start(ctx context.Context) error {
 		Handler: s.RegisterRoutes(),
 	}
 
+	if s.config.TLS.Enabled {
+		tlsConfig, err := s.loadTLSConfig()
+		if err != nil {
+			s.logger.Error("Failed to load TLS configuration", zap.Error(err))
+			return err
+		}
+		server.TLSConfig = tlsConfig
+
+		s.logger.Info("Starting HTTPS server",
+			zap.String("address", server.Addr),
+			zap.String("client_auth", s.config.TLS.ClientAuth))
+
+		if err := server.ListenAndServeTLS(s.config.TLS.CertFile, s.config.TLS.KeyFile); err != nil && err != http.ErrServerClosed {
+			s.logger.Fatal("Failed to start HTTPS server", zap.Error(err))
+		}
+		return nil
+	}
+
 	s.logger.Info("Starting HTTP server", zap.String("address", server.Addr))
 
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -78,3 +150,34 @@ func (s *server) Start(ctx context.Context) error {
 	}
 	return nil
 }
+
+// loadTLSConfig builds the server TLS configuration. Client certificates are
+// verified against the agent CA; "request" keeps password logins working for
+// clients without a certificate, "require" rejects them at the handshake.
+func (s *server) loadTLSConfig() (*tls.Config, error) {
+	caPEM, err := os.ReadFile(s.config.TLS.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", s.config.TLS.ClientCAFile)
+	}
+
+	var clientAuth tls.ClientAuthType
+	switch s.config.TLS.ClientAuth {
+	case "request":
+		clientAuth = tls.VerifyClientCertIfGiven
+	case "require":
+		clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("unsupported TLS client auth mode: %s", s.config.TLS.ClientAuth)
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientCAs:  clientCAs,
+		ClientAuth: clientAuth,
+	}, nil
+}
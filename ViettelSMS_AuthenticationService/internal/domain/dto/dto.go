@@ -1,6 +1,10 @@
 package dto
 
-import "github.com/golang-jwt/jwt/v5"
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
 
 type (
 	UserEvent struct {
@@ -9,10 +13,13 @@ type (
 	}
 
 	UserCreate struct {
-		Username string   `json:"user_name"`
-		Password string   `json:"password"`
-		Blocked  bool     `json:"blocked"`
-		Scopes   []string `json:"scopes"`
+		Username           string   `json:"user_name"`
+		Email              string   `json:"email"`
+		Password           string   `json:"password"`
+		Blocked            bool     `json:"blocked"`
+		Scopes             []string `json:"scopes"`
+		Roles              []string `json:"roles"`
+		MustChangePassword bool     `json:"must_change_password"`
 	}
 
 	UserDelete struct {
@@ -21,12 +28,16 @@ type (
 
 	UserUpdate struct {
 		UserName string `json:"user_name"`
+		Email    string `json:"email"`
 		Blocked  bool   `json:"blocked"`
 	}
 
 	UserPasswordUpdate struct {
 		UserName string `json:"user_name"`
 		Password string `json:"password"`
+		// MustChangePassword is set when an administrator reset the password
+		// and the user has to choose a new one at next login.
+		MustChangePassword bool `json:"must_change_password"`
 	}
 
 	UserScope struct {
@@ -34,9 +45,121 @@ type (
 		Scope    string `json:"scope"`
 	}
 
+	ScopeResponse struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Service     string `json:"service"`
+		Deprecated  bool   `json:"deprecated"`
+	}
+
+	UserRole struct {
+		UserName string `json:"user_name"`
+		Role     string `json:"role"`
+	}
+
+	// RoleRequest creates or replaces a role. It is also the payload of the
+	// role.created and role.updated events.
+	RoleRequest struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+	}
+
+	RoleUpdateRequest struct {
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+	}
+
+	RoleDelete struct {
+		Name string `json:"name"`
+	}
+
+	RoleResponse struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		BuiltIn     bool     `json:"built_in"`
+		Scopes      []string `json:"scopes"`
+	}
+
+	// GroupRequest creates a group. Members inherit its scopes and roles,
+	// and those of the parent groups it is nested in.
+	GroupRequest struct {
+		Name        string   `json:"name" binding:"required"`
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+		Roles       []string `json:"roles"`
+		Parents     []string `json:"parents"`
+	}
+
+	GroupUpdateRequest struct {
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+		Roles       []string `json:"roles"`
+		Parents     []string `json:"parents"`
+	}
+
+	GroupResponse struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+		Roles       []string `json:"roles"`
+		Parents     []string `json:"parents"`
+	}
+
+	// EffectiveScopesResponse lists the scopes a user's next token carries,
+	// each with every grant it comes from.
+	EffectiveScopesResponse struct {
+		UserName string            `json:"user_name"`
+		Scopes   []*EffectiveScope `json:"scopes"`
+	}
+
+	EffectiveScope struct {
+		Scope   string        `json:"scope"`
+		Sources []ScopeSource `json:"sources"`
+	}
+
+	// ScopeSource is one grant behind an effective scope. Type is "user" for
+	// the user's own scopes, "role" or "group". Path is the chain of groups
+	// from the one the user belongs to up to the one holding the grant.
+	// Grant is the scope actually granted when the effective one is only
+	// covered by it, through a wildcard or an implication.
+	ScopeSource struct {
+		Type  string   `json:"type"`
+		Role  string   `json:"role,omitempty"`
+		Group string   `json:"group,omitempty"`
+		Path  []string `json:"path,omitempty"`
+		Grant string   `json:"grant,omitempty"`
+	}
+
 	LoginRequest struct {
 		UserName string `json:"user_name" binding:"required"`
 		Password string `json:"password" binding:"required"`
+		// Challenge and Nonce answer a proof-of-work challenge from
+		// /auth/challenge; CaptchaToken answers a CAPTCHA. Either is only
+		// needed after repeated failed logins.
+		Challenge    string `json:"challenge,omitempty"`
+		Nonce        string `json:"nonce,omitempty"`
+		CaptchaToken string `json:"captcha_token,omitempty"`
+	}
+
+	// ClientInfo describes where a request came from.
+	ClientInfo struct {
+		IP        string
+		UserAgent string
+		// APIKey is the X-API-Key header, if the request carried one.
+		APIKey string
+	}
+
+	// ChallengeResponse describes the challenge a client must solve before
+	// logging in. For "pow", find a nonce such that
+	// SHA-256(challenge + ":" + nonce) starts with Difficulty zero bits.
+	ChallengeResponse struct {
+		Type       string `json:"type"`
+		Challenge  string `json:"challenge,omitempty"`
+		Algorithm  string `json:"algorithm,omitempty"`
+		Difficulty int    `json:"difficulty,omitempty"`
+		ExpiresIn  int    `json:"expires_in,omitempty"`
+		SiteKey    string `json:"site_key,omitempty"`
 	}
 
 	RefreshTokenRequest struct {
@@ -44,14 +167,301 @@ type (
 	}
 
 	Claims struct {
-		Sub     uint     `json:"sub"`
-		Scopes  []string `json:"scopes"`
-		Blocked bool     `json:"blocked"`
+		Sub     uint          `json:"sub"`
+		Sid     string        `json:"sid,omitempty"`
+		Scopes  []string      `json:"scopes"`
+		Blocked bool          `json:"blocked"`
+		Machine string        `json:"machine,omitempty"`
+		Cnf     *Confirmation `json:"cnf,omitempty"`
+		// Restricted tokens only grant access to the change-password endpoint.
+		Restricted bool `json:"restricted,omitempty"`
 		jwt.RegisteredClaims
 	}
 
+	// Confirmation is the RFC 8705 confirmation claim binding a token to
+	// the client certificate it was issued for.
+	Confirmation struct {
+		X5tS256 string `json:"x5t#S256"`
+	}
+
 	LoginResponse struct {
+		AccessToken            string `json:"access_token,omitempty"`
+		RefreshToken           string `json:"refresh_token,omitempty"`
+		PasswordChangeRequired bool   `json:"password_change_required"`
+		PasswordExpiresInDays  *int   `json:"password_expires_in_days,omitempty"`
+		// MFARequired is set instead of tokens when the login looked risky.
+		// The code sent by MFAMethod is submitted with MFAToken to
+		// /auth/login/verify.
+		MFARequired bool   `json:"mfa_required,omitempty"`
+		MFAToken    string `json:"mfa_token,omitempty"`
+		MFAMethod   string `json:"mfa_method,omitempty"`
+	}
+
+	LoginVerifyRequest struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	// IPSubject names what an IP rule is attached to; Type is one of the
+	// entity.IPSubject constants.
+	IPSubject struct {
+		Type  string
+		Value string
+	}
+
+	IPRuleRequest struct {
+		SubjectType string `json:"subject_type" binding:"required,oneof=user scope machine api_key"`
+		// Subject is a user name, a scope, a machine identity name or an API
+		// key. API keys are stored by fingerprint only.
+		Subject     string `json:"subject" binding:"required"`
+		Action      string `json:"action" binding:"required,oneof=allow deny"`
+		CIDR        string `json:"cidr" binding:"required"`
+		Description string `json:"description"`
+	}
+
+	IPRuleResponse struct {
+		ID          uint      `json:"id"`
+		SubjectType string    `json:"subject_type"`
+		Subject     string    `json:"subject"`
+		Action      string    `json:"action"`
+		CIDR        string    `json:"cidr"`
+		Description string    `json:"description"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
+	// ResourcePermissionRequest grants Action on the resources matching
+	// Resource, an ID or a pattern using "*", to a user (by user name), a role
+	// or a machine identity.
+	ResourcePermissionRequest struct {
+		SubjectType  string `json:"subject_type" binding:"required,oneof=user role machine"`
+		Subject      string `json:"subject" binding:"required"`
+		ResourceType string `json:"resource_type" binding:"required"`
+		Resource     string `json:"resource" binding:"required"`
+		Action       string `json:"action" binding:"required"`
+	}
+
+	ResourcePermissionResponse struct {
+		ID           uint      `json:"id"`
+		SubjectType  string    `json:"subject_type"`
+		Subject      string    `json:"subject"`
+		ResourceType string    `json:"resource_type"`
+		Resource     string    `json:"resource"`
+		Action       string    `json:"action"`
+		CreatedAt    time.Time `json:"created_at"`
+	}
+
+	PermissionCheckRequest struct {
+		SubjectType string `json:"subject_type" binding:"required,oneof=user machine"`
+		// Subject is a user name or a machine identity name.
+		Subject      string `json:"subject" binding:"required"`
+		ResourceType string `json:"resource_type" binding:"required"`
+		ResourceID   string `json:"resource_id" binding:"required"`
+		Action       string `json:"action" binding:"required"`
+	}
+
+	PermissionCheckResponse struct {
+		Allowed bool `json:"allowed"`
+		// Via is "scope" when a scope grants the action on every resource of
+		// the type and "permission" when a resource permission grants it.
+		Via        string                      `json:"via,omitempty"`
+		Permission *ResourcePermissionResponse `json:"permission,omitempty"`
+	}
+
+	PermittedResourcesRequest struct {
+		SubjectType  string `form:"subject_type" binding:"required,oneof=user machine"`
+		Subject      string `form:"subject" binding:"required"`
+		ResourceType string `form:"resource_type" binding:"required"`
+		Action       string `form:"action" binding:"required"`
+	}
+
+	PermittedResourcesResponse struct {
+		ResourceType string `json:"resource_type"`
+		Action       string `json:"action"`
+		// All is set when a scope grants the action on every resource of the
+		// type; Resources then needs no filtering.
+		All bool `json:"all"`
+		// Resources lists resource IDs and "*" patterns of IDs.
+		Resources []string `json:"resources"`
+	}
+
+	// ServerRegistered is the payload of server.registered from
+	// ServerService. The owner, a user name, may do anything to the server.
+	ServerRegistered struct {
+		ServerID string `json:"server_id"`
+		Owner    string `json:"owner"`
+	}
+
+	ServerDeleted struct {
+		ServerID string `json:"server_id"`
+	}
+
+	IntrospectRequest struct {
+		Token string `json:"token" form:"token" binding:"required"`
+		// ResourceType, when set, adds the resource permissions the token
+		// holder has on that type.
+		ResourceType string `json:"resource_type" form:"resource_type"`
+	}
+
+	// IntrospectResponse is the RFC 7662 introspection response. Anything
+	// wrong with the token only yields Active false.
+	IntrospectResponse struct {
+		Active      bool                          `json:"active"`
+		Scope       string                        `json:"scope,omitempty"`
+		Sub         string                        `json:"sub,omitempty"`
+		Machine     string                        `json:"machine,omitempty"`
+		Sid         string                        `json:"sid,omitempty"`
+		TokenType   string                        `json:"token_type,omitempty"`
+		Exp         int64                         `json:"exp,omitempty"`
+		Iat         int64                         `json:"iat,omitempty"`
+		Permissions []*ResourcePermissionResponse `json:"permissions,omitempty"`
+	}
+
+	// GeoLocation is where an IP address is registered, as found in the
+	// GeoIP database.
+	GeoLocation struct {
+		Country   string  `json:"country"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+	}
+
+	// LoginRisk is the outcome of the login heuristics. Reasons lists the
+	// signals that contributed to Score.
+	LoginRisk struct {
+		Score     int      `json:"score"`
+		Reasons   []string `json:"reasons"`
+		NewDevice bool     `json:"new_device"`
+	}
+
+	DeviceCodeRequest struct {
+		ClientID string `json:"client_id" form:"client_id" binding:"required"`
+	}
+
+	// DeviceCodeResponse is the RFC 8628 device authorization response.
+	DeviceCodeResponse struct {
+		DeviceCode              string `json:"device_code"`
+		UserCode                string `json:"user_code"`
+		VerificationURI         string `json:"verification_uri"`
+		VerificationURIComplete string `json:"verification_uri_complete"`
+		ExpiresIn               int    `json:"expires_in"`
+		Interval                int    `json:"interval"`
+	}
+
+	DeviceApproveRequest struct {
+		UserCode string `json:"user_code" binding:"required"`
+		Approve  bool   `json:"approve"`
+	}
+
+	TokenRequest struct {
+		GrantType  string `json:"grant_type" form:"grant_type" binding:"required"`
+		DeviceCode string `json:"device_code" form:"device_code"`
+		ClientID   string `json:"client_id" form:"client_id"`
+	}
+
+	// TokenResponse is the RFC 6749 token endpoint response.
+	TokenResponse struct {
 		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+
+	ForgotPasswordRequest struct {
+		UserName string `json:"user_name"`
+		Email    string `json:"email"`
+	}
+
+	ResetPasswordRequest struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=8"`
+	}
+
+	// RateLimit allows Rate requests per Period on average, with bursts of up
+	// to Burst requests.
+	RateLimit struct {
+		Rate   int
+		Period time.Duration
+		Burst  int
+	}
+
+	RateLimitResult struct {
+		Allowed    bool
+		Limit      int
+		Remaining  int
+		RetryAfter time.Duration
+		ResetAfter time.Duration
+	}
+
+	// PolicyViolation is one password policy rule a candidate password broke.
+	PolicyViolation struct {
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+
+	ChangePasswordRequest struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=8"`
+	}
+
+	// ResetClaims are carried by the single-use token mailed for a password reset.
+	ResetClaims struct {
+		Sub     uint   `json:"sub"`
+		Purpose string `json:"purpose"`
+		// PasswordHash is a digest of the hash the token was issued against, so
+		// the token dies as soon as the password changes by any other path.
+		PasswordHash string `json:"pwh"`
+		jwt.RegisteredClaims
+	}
+
+	MachineTokenResponse struct {
+		AccessToken string   `json:"access_token"`
+		TokenType   string   `json:"token_type"`
+		ExpiresIn   int      `json:"expires_in"`
+		Machine     string   `json:"machine"`
+		Scopes      []string `json:"scopes"`
+	}
+
+	// ScopeDenial explains which scope requirement of a route a token failed.
+	ScopeDenial struct {
+		Required string `json:"required"`
+		Failed   string `json:"failed"`
+	}
+
+	// AuthorizeRequest asks whether a subject may perform an action on a
+	// resource. Attributes are free-form and matched by the policies as
+	// "subject.<name>", "resource.<name>" and "context.<name>".
+	AuthorizeRequest struct {
+		Subject  AuthorizeSubject  `json:"subject"`
+		Action   string            `json:"action" binding:"required"`
+		Resource AuthorizeResource `json:"resource"`
+		Context  AuthorizeContext  `json:"context"`
+	}
+
+	AuthorizeSubject struct {
+		Type string `json:"type" binding:"required,oneof=user machine"`
+		// ID is a user name or a machine identity name.
+		ID         string                 `json:"id" binding:"required"`
+		Attributes map[string]interface{} `json:"attributes"`
+	}
+
+	AuthorizeResource struct {
+		Type       string                 `json:"type" binding:"required"`
+		ID         string                 `json:"id"`
+		Attributes map[string]interface{} `json:"attributes"`
+	}
+
+	AuthorizeContext struct {
+		IP string `json:"ip" binding:"omitempty,ip"`
+		// Time defaults to now.
+		Time       *time.Time             `json:"time"`
+		Attributes map[string]interface{} `json:"attributes"`
+	}
+
+	AuthorizeResponse struct {
+		Allowed  bool   `json:"allowed"`
+		Decision string `json:"decision"`
+		// Rule is the ID of the policy rule that decided, or "default" when
+		// none matched.
+		Rule        string `json:"rule"`
+		Description string `json:"description,omitempty"`
 	}
 )
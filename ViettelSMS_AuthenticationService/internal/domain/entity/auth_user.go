@@ -1,11 +1,19 @@
 package entity
 
-import "github.com/lib/pq"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type AuthUser struct {
-	ID       uint           `gorm:"primaryKey"`
-	Username string         `gorm:"unique;not null"`
-	Password string         `gorm:"not null"`
-	Blocked  bool           `gorm:"not null;default:false"`
-	Scopes   pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
+	ID                 uint           `gorm:"primaryKey"`
+	Username           string         `gorm:"unique;not null"`
+	Email              string         `gorm:"not null;default:''"`
+	Password           string         `gorm:"not null"`
+	Blocked            bool           `gorm:"not null;default:false"`
+	Scopes             pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
+	PasswordChangedAt  time.Time      `gorm:"not null;default:now()"`
+	MustChangePassword bool           `gorm:"not null;default:false"`
+	LockedUntil        *time.Time
 }
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// Group is a team of users. Members hold the group's scopes and roles, and
+// those of every group it is nested in.
+type Group struct {
+	ID          uint          `gorm:"primaryKey"`
+	Name        string        `gorm:"unique;not null"`
+	Description string        `gorm:"not null;default:''"`
+	Scopes      []GroupScope  `gorm:"foreignKey:GroupID"`
+	Roles       []GroupRole   `gorm:"foreignKey:GroupID"`
+	Parents     []GroupParent `gorm:"foreignKey:GroupID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type GroupScope struct {
+	GroupID uint   `gorm:"primaryKey"`
+	Scope   string `gorm:"primaryKey"`
+}
+
+type GroupRole struct {
+	GroupID uint `gorm:"primaryKey"`
+	RoleID  uint `gorm:"primaryKey"`
+}
+
+// GroupParent nests GroupID in ParentID.
+type GroupParent struct {
+	GroupID  uint `gorm:"primaryKey"`
+	ParentID uint `gorm:"primaryKey"`
+}
+
+type GroupMember struct {
+	GroupID uint `gorm:"primaryKey"`
+	UserID  uint `gorm:"primaryKey"`
+}
+
+// ScopeNames returns the scopes of the group.
+func (g *Group) ScopeNames() []string {
+	scopes := make([]string, len(g.Scopes))
+	for i, scope := range g.Scopes {
+		scopes[i] = scope.Scope
+	}
+	return scopes
+}
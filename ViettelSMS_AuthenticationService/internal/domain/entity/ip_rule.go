@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	IPSubjectUser    = "user"
+	IPSubjectScope   = "scope"
+	IPSubjectMachine = "machine"
+	IPSubjectAPIKey  = "api_key"
+
+	IPRuleAllow = "allow"
+	IPRuleDeny  = "deny"
+)
+
+// IPRule allows or denies a network for a subject: a user (by ID), a scope,
+// a machine identity (by name) or an API key (by APIKeyFingerprint). A
+// subject with allow rules may only be used from those networks; a deny rule
+// always wins.
+type IPRule struct {
+	ID          uint   `gorm:"primaryKey"`
+	SubjectType string `gorm:"not null"`
+	Subject     string `gorm:"not null"`
+	Action      string `gorm:"not null"`
+	CIDR        string `gorm:"column:cidr;type:cidr;not null"`
+	Description string `gorm:"not null;default:''"`
+	CreatedBy   *uint
+	CreatedAt   time.Time
+}
+
+// APIKeyFingerprint identifies an API key in IP rules and rate limit keys
+// without storing the key itself.
+func APIKeyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// KnownDevice is a browser or client a user has logged in from before,
+// identified by a fingerprint of its user agent and IP subnet. The location
+// fields describe the most recent login from it.
+type KnownDevice struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"not null;index"`
+	Fingerprint string `gorm:"not null"`
+	UserAgent   string `gorm:"not null;default:''"`
+	IP          string `gorm:"not null;default:''"`
+	Country     string `gorm:"not null;default:''"`
+	Latitude    *float64
+	Longitude   *float64
+	FirstSeenAt time.Time `gorm:"not null"`
+	LastSeenAt  time.Time `gorm:"not null"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// MachineIdentity binds a client certificate, by subject or by pinned
+// SHA-256 fingerprint, to a monitored server and the scopes it may use.
+type MachineIdentity struct {
+	ID          uint           `gorm:"primaryKey"`
+	Name        string         `gorm:"unique;not null"`
+	Subject     string         `gorm:"not null"`
+	Fingerprint string         `gorm:"not null;default:''"`
+	Blocked     bool           `gorm:"not null;default:false"`
+	Scopes      pq.StringArray `gorm:"type:text[];not null;default:'{}'"`
+	CreatedAt   time.Time
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type PasswordHistory struct {
+	ID           uint   `gorm:"primaryKey"`
+	UserID       uint   `gorm:"not null;index"`
+	PasswordHash string `gorm:"not null"`
+	CreatedAt    time.Time
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_history"
+}
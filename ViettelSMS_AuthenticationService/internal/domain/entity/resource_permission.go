@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+const (
+	PermissionSubjectUser    = "user"
+	PermissionSubjectRole    = "role"
+	PermissionSubjectMachine = "machine"
+
+	// PermissionWildcard as the action grants every action; in a resource
+	// pattern it matches any run of characters, as in "hn-*".
+	PermissionWildcard = "*"
+)
+
+// ResourcePermission grants one action on individual resources to a user
+// (by ID), a role (by name) or a machine identity (by name), on top of
+// whatever its scopes grant on every resource of the type.
+type ResourcePermission struct {
+	ID           uint   `gorm:"primaryKey"`
+	SubjectType  string `gorm:"not null"`
+	Subject      string `gorm:"not null"`
+	ResourceType string `gorm:"not null"`
+	// Resource is a resource ID or a pattern of IDs.
+	Resource  string `gorm:"not null"`
+	Action    string `gorm:"not null"`
+	CreatedBy *uint
+	CreatedAt time.Time
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// Role is a named set of scopes. Users hold roles through user_roles, and
+// their tokens carry the scopes of every role next to their own.
+type Role struct {
+	ID          uint        `gorm:"primaryKey"`
+	Name        string      `gorm:"unique;not null"`
+	Description string      `gorm:"not null;default:''"`
+	BuiltIn     bool        `gorm:"not null;default:false"`
+	Scopes      []RoleScope `gorm:"foreignKey:RoleID"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type RoleScope struct {
+	RoleID uint   `gorm:"primaryKey"`
+	Scope  string `gorm:"primaryKey"`
+}
+
+type UserRole struct {
+	UserID uint `gorm:"primaryKey"`
+	RoleID uint `gorm:"primaryKey"`
+}
+
+// ScopeNames returns the scopes of the role.
+func (r *Role) ScopeNames() []string {
+	scopes := make([]string, len(r.Scopes))
+	for i, scope := range r.Scopes {
+		scopes[i] = scope.Scope
+	}
+	return scopes
+}
@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+// Scope is an entry of the scope catalogue. Only catalogued scopes, or
+// wildcards covering one, can be granted.
+type Scope struct {
+	Name        string `gorm:"primaryKey"`
+	Description string `gorm:"not null;default:''"`
+	Service     string `gorm:"not null"`
+	Deprecated  bool   `gorm:"not null;default:false"`
+	CreatedAt   time.Time
+}
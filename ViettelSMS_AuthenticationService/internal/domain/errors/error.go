@@ -1,6 +1,11 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strings"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+)
 
 var (
 	ErrUserConflict       = errors.New("username or email already exist")
@@ -8,4 +13,65 @@ var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrInvalidCredentials = errors.New("invalid credentials")
 	ErrInvalidToken       = errors.New("invalid token")
+
+	ErrClientCertRequired = errors.New("verified client certificate required")
+	ErrMachineNotFound    = errors.New("machine identity not found")
+	ErrMachineBlocked     = errors.New("machine identity is blocked")
+
+	ErrUnsupportedGrantType = errors.New("unsupported grant type")
+	ErrAuthorizationPending = errors.New("authorization pending")
+	ErrSlowDown             = errors.New("polling too frequently")
+	ErrAccessDenied         = errors.New("authorization request denied")
+	ErrInvalidClient        = errors.New("client does not match the authorization request")
+	ErrUnknownClient        = errors.New("unknown client")
+	ErrExpiredToken         = errors.New("device code expired")
+	ErrInvalidUserCode      = errors.New("invalid or expired user code")
+
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	ErrSessionRevoked    = errors.New("session has been revoked")
+	ErrWeakPassword      = errors.New("password does not meet the password policy")
+
+	ErrPasswordChangeRequired = errors.New("password change required")
+	ErrRateLimited            = errors.New("rate limit exceeded")
+	ErrChallengeRequired      = errors.New("challenge required")
+	ErrInvalidChallenge       = errors.New("invalid or expired challenge")
+	ErrLoginBlocked           = errors.New("login blocked as suspicious")
+	ErrInvalidMFACode         = errors.New("invalid or expired verification code")
+	ErrIPNotAllowed           = errors.New("access from this address is not allowed")
+	ErrInvalidIPRule          = errors.New("invalid ip rule")
+	ErrIPRuleNotFound         = errors.New("ip rule not found")
+	ErrIPRuleConflict         = errors.New("ip rule already exists")
+	ErrRoleNotFound           = errors.New("role not found")
+	ErrRoleConflict           = errors.New("role already exists")
+	ErrRoleBuiltIn            = errors.New("built-in roles cannot be changed")
+	ErrInvalidRole            = errors.New("invalid role")
+	ErrInvalidPermission      = errors.New("invalid resource permission")
+	ErrPermissionNotFound     = errors.New("resource permission not found")
+	ErrPermissionConflict     = errors.New("resource permission already exists")
+	ErrGroupNotFound          = errors.New("group not found")
+	ErrGroupConflict          = errors.New("group already exists")
+	ErrGroupCycle             = errors.New("group nesting would create a cycle")
+	ErrInvalidGroup           = errors.New("invalid group")
 )
+
+// PasswordPolicyError carries the rules a rejected password broke. It
+// matches ErrWeakPassword with errors.Is.
+type PasswordPolicyError struct {
+	Violations []dto.PolicyViolation
+}
+
+func NewPasswordPolicyError(violations []dto.PolicyViolation) *PasswordPolicyError {
+	return &PasswordPolicyError{Violations: violations}
+}
+
+func (e *PasswordPolicyError) Error() string {
+	rules := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		rules[i] = violation.Rule
+	}
+	return ErrWeakPassword.Error() + ": " + strings.Join(rules, ", ")
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrWeakPassword
+}
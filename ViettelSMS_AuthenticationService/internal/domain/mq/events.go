@@ -0,0 +1,32 @@
+package mq
+
+const (
+	// AUTH_TOPIC carries events published by the authentication service for
+	// UserService and notification consumers.
+	AUTH_TOPIC = "auth-events"
+
+	EventUserCreated          = "user.created"
+	EventUserUpdatedPassword  = "user.updated_password"
+	EventUserPasswordChanged  = "user.password_changed"
+	EventUserPasswordRejected = "user.password_rejected"
+	EventUserAddedScope       = "user.added_scope"
+	EventUserScopeRejected    = "user.scope_rejected"
+
+	EventAccountLocked   = "account.locked"
+	EventAccountUnlocked = "account.unlocked"
+
+	EventRoleCreated        = "role.created"
+	EventRoleUpdated        = "role.updated"
+	EventRoleDeleted        = "role.deleted"
+	EventUserRoleAssigned   = "user.assigned_role"
+	EventUserRoleUnassigned = "user.unassigned_role"
+
+	EventGroupCreated       = "group.created"
+	EventGroupUpdated       = "group.updated"
+	EventGroupDeleted       = "group.deleted"
+	EventGroupMemberAdded   = "group.member_added"
+	EventGroupMemberRemoved = "group.member_removed"
+
+	EventLoginNewDevice = "login.new_device"
+	EventLoginRisky     = "login.risky"
+)
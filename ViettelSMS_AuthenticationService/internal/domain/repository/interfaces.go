@@ -9,8 +9,62 @@ import (
 type Repository interface {
 	GetUserByUsername(ctx context.Context, username string) (*entity.AuthUser, error)
 	GetUserByID(ctx context.Context, id uint) (*entity.AuthUser, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.AuthUser, error)
 
 	CreateUser(ctx context.Context, user *entity.AuthUser) error
 	UpdateUser(ctx context.Context, user *entity.AuthUser) error
+	UpdateUserColumns(ctx context.Context, id uint, columns map[string]interface{}) error
+	AddUserScope(ctx context.Context, id uint, scope string) (bool, error)
+	RemoveUserScope(ctx context.Context, id uint, scope string) error
 	DeleteUser(ctx context.Context, id uint) error
+
+	AddPasswordHistory(ctx context.Context, entry *entity.PasswordHistory) error
+	GetPasswordHistory(ctx context.Context, userID uint, limit int) ([]*entity.PasswordHistory, error)
+	PrunePasswordHistory(ctx context.Context, userID uint, keep int) error
+	DeletePasswordHistory(ctx context.Context, userID uint) error
+
+	GetKnownDevices(ctx context.Context, userID uint) ([]*entity.KnownDevice, error)
+	SaveKnownDevice(ctx context.Context, device *entity.KnownDevice) error
+	DeleteKnownDevices(ctx context.Context, userID uint) error
+
+	ListRoles(ctx context.Context) ([]*entity.Role, error)
+	GetRoleByName(ctx context.Context, name string) (*entity.Role, error)
+	CreateRole(ctx context.Context, role *entity.Role) error
+	UpdateRole(ctx context.Context, role *entity.Role) error
+	DeleteRole(ctx context.Context, id uint) error
+
+	GetUserRoles(ctx context.Context, userID uint) ([]*entity.Role, error)
+	AssignRole(ctx context.Context, userID, roleID uint) error
+	UnassignRole(ctx context.Context, userID, roleID uint) error
+	DeleteUserRoles(ctx context.Context, userID uint) error
+
+	ListGroups(ctx context.Context) ([]*entity.Group, error)
+	GetGroupByName(ctx context.Context, name string) (*entity.Group, error)
+	CreateGroup(ctx context.Context, group *entity.Group) error
+	UpdateGroup(ctx context.Context, group *entity.Group, checkNesting func(groups []*entity.Group) error) error
+	DeleteGroup(ctx context.Context, id uint) error
+
+	GetGroupMembers(ctx context.Context, groupID uint) ([]*entity.AuthUser, error)
+	GetUserGroupIDs(ctx context.Context, userID uint) ([]uint, error)
+	AddGroupMember(ctx context.Context, groupID, userID uint) error
+	RemoveGroupMember(ctx context.Context, groupID, userID uint) error
+	DeleteUserGroups(ctx context.Context, userID uint) error
+
+	ListScopes(ctx context.Context) ([]*entity.Scope, error)
+
+	ListResourcePermissions(ctx context.Context, resourceType string, subjects map[string][]string) ([]*entity.ResourcePermission, error)
+	CreateResourcePermission(ctx context.Context, permission *entity.ResourcePermission) error
+	GrantResourcePermission(ctx context.Context, permission *entity.ResourcePermission) error
+	DeleteResourcePermission(ctx context.Context, id uint) error
+	DeleteResourcePermissions(ctx context.Context, resourceType, resource string) error
+	DeleteSubjectPermissions(ctx context.Context, subjectType, subject string) error
+
+	ListIPRules(ctx context.Context) ([]*entity.IPRule, error)
+	CreateIPRule(ctx context.Context, rule *entity.IPRule) error
+	DeleteIPRule(ctx context.Context, id uint) error
+
+	GetMachineIdentityByFingerprint(ctx context.Context, fingerprint string) (*entity.MachineIdentity, error)
+	GetMachineIdentityBySubject(ctx context.Context, subject string) (*entity.MachineIdentity, error)
+	GetMachineIdentityByName(ctx context.Context, name string) (*entity.MachineIdentity, error)
+	CreateMachineIdentity(ctx context.Context, identity *entity.MachineIdentity) error
 }
@@ -16,6 +16,12 @@ type ErrorInfo struct {
 	Details interface{} `json:"details,omitempty"`
 }
 
+// OAuthErrorResponse is the error body defined by RFC 6749 section 5.2
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
 // Common response codes
 const (
 	// Success codes
@@ -32,12 +38,27 @@ const (
 	CodeConflict            = "CONFLICT"
 	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
 	CodeValidationError     = "VALIDATION_ERROR"
+	CodeTooManyRequests     = "TOO_MANY_REQUESTS"
+	CodeChallengeRequired   = "CHALLENGE_REQUIRED"
 	CodeDatabaseError       = "DATABASE_ERROR"
 	CodeAuthError           = "AUTH_ERROR"
 	CodeTokenExpired        = "TOKEN_EXPIRED"
 	CodeInvalidToken        = "INVALID_TOKEN"
 )
 
+// OAuth error codes (RFC 6749, RFC 8628)
+const (
+	OAuthInvalidRequest       = "invalid_request"
+	OAuthInvalidGrant         = "invalid_grant"
+	OAuthInvalidClient        = "invalid_client"
+	OAuthUnsupportedGrantType = "unsupported_grant_type"
+	OAuthServerError          = "server_error"
+	OAuthAuthorizationPending = "authorization_pending"
+	OAuthSlowDown             = "slow_down"
+	OAuthAccessDenied         = "access_denied"
+	OAuthExpiredToken         = "expired_token"
+)
+
 // NewSuccessResponse creates a new success response
 func NewSuccessResponse(code, message string, data interface{}) *APIResponse {
 	return &APIResponse{
@@ -61,3 +82,11 @@ func NewErrorResponse(code, message string, details interface{}) *APIResponse {
 		},
 	}
 }
+
+// NewOAuthErrorResponse creates a new OAuth error response
+func NewOAuthErrorResponse(code, description string) *OAuthErrorResponse {
+	return &OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	}
+}
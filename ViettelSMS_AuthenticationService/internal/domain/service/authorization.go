@@ -0,0 +1,14 @@
+package srv
+
+import "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+
+// PolicyEngine evaluates the attribute-based authorization policies.
+type PolicyEngine interface {
+	// Decide evaluates attributes flattened into keys such as "action",
+	// "subject.id", "resource.type" or "context.ip" and returns the decision
+	// with the rule that made it.
+	Decide(attributes map[string]interface{}) dto.AuthorizeResponse
+	// Version identifies the loaded policies, so cached decisions do not
+	// survive a policy change.
+	Version() string
+}
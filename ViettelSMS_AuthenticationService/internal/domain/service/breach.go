@@ -0,0 +1,7 @@
+package srv
+
+type BreachedPasswordChecker interface {
+	// IsBreached reports whether the password appears in the local corpus of
+	// known-compromised passwords.
+	IsBreached(password string) (bool, error)
+}
@@ -0,0 +1,8 @@
+package srv
+
+import "context"
+
+type CaptchaVerifier interface {
+	// Verify checks a CAPTCHA response token submitted by the client at remoteIP.
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
@@ -0,0 +1,9 @@
+package srv
+
+import "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+
+type GeoIPLocator interface {
+	// Lookup returns the location of ip, or false when it is not in the
+	// database or no database is configured.
+	Lookup(ip string) (*dto.GeoLocation, bool)
+}
@@ -0,0 +1,17 @@
+package srv
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+)
+
+// IPAccessList enforces the IP rules attached to users, scopes and machine
+// identities.
+type IPAccessList interface {
+	// Allowed reports whether ip may act as every one of subjects. A deny
+	// rule matching ip wins; a subject with allow rules needs one matching ip.
+	Allowed(ctx context.Context, ip string, subjects ...dto.IPSubject) (bool, error)
+	// Invalidate drops the cached rules after they were changed.
+	Invalidate()
+}
@@ -0,0 +1,5 @@
+package srv
+
+type MailService interface {
+	Send(to, subject, body string) error
+}
@@ -3,4 +3,14 @@ package srv
 type PasswordService interface {
 	Hash(password string) (string, error)
 	Verify(hashedPassword, password string) (bool, error)
+
+	// Supports reports whether hashedPassword is in a format this service can verify.
+	Supports(hashedPassword string) bool
+	// Validate fully decodes hashedPassword and checks that its cost
+	// parameters are within the configured limits, so a hash taken from
+	// outside cannot make Verify fail or run unbounded.
+	Validate(hashedPassword string) error
+	// NeedsRehash reports whether hashedPassword was produced with an outdated
+	// algorithm or parameters and should be replaced on the next successful login.
+	NeedsRehash(hashedPassword string) bool
 }
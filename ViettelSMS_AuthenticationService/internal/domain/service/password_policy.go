@@ -0,0 +1,9 @@
+package srv
+
+import "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+
+type PasswordPolicy interface {
+	// Validate returns every rule the password breaks; an empty result means
+	// the password is acceptable.
+	Validate(username, password string) []dto.PolicyViolation
+}
@@ -0,0 +1,12 @@
+package srv
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+)
+
+type RateLimiter interface {
+	// Allow counts one request against key and reports whether it fits in limit.
+	Allow(ctx context.Context, key string, limit dto.RateLimit) (*dto.RateLimitResult, error)
+}
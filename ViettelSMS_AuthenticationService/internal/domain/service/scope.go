@@ -0,0 +1,13 @@
+package srv
+
+// ScopeMatcher understands wildcard ("server:*", "*:read") and implied
+// ("server:update" grants "server:read") scopes.
+type ScopeMatcher interface {
+	// Grants reports whether the granted scopes cover required.
+	Grants(granted []string, required string) bool
+	// Expand returns granted together with every known scope it covers,
+	// sorted and without duplicates.
+	Expand(granted []string) []string
+	// Valid reports whether scope is a well-formed RESOURCE:ACTION pattern.
+	Valid(scope string) bool
+}
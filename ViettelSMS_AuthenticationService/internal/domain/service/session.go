@@ -0,0 +1,14 @@
+package srv
+
+import (
+	"context"
+	"time"
+)
+
+// SessionStore tracks the login sessions behind issued tokens so they can be
+// revoked before the tokens themselves expire.
+type SessionStore interface {
+	Create(ctx context.Context, userID uint, ttl time.Duration) (string, error)
+	Exists(ctx context.Context, sessionID string) (bool, error)
+	RevokeAll(ctx context.Context, userID uint, exceptSessionID string) error
+}
@@ -0,0 +1,299 @@
+package abac
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+	// DefaultRule is reported when no rule matched and access is denied.
+	DefaultRule = "default"
+
+	wildcard = "*"
+	// refPrefix marks a condition value naming another attribute, as in
+	// "$subject.id".
+	refPrefix = "$"
+)
+
+var operators = map[string]bool{
+	"eq": true, "ne": true,
+	"in": true, "not_in": true,
+	"contains": true, "cidr": true, "not_cidr": true,
+	"gt": true, "gte": true, "lt": true, "lte": true,
+	"exists": true,
+}
+
+// Policies is the content of the policy file.
+type Policies struct {
+	Rules []Rule `mapstructure:"rules" json:"rules"`
+}
+
+type Rule struct {
+	ID          string `mapstructure:"id" json:"id"`
+	Description string `mapstructure:"description" json:"description"`
+	Effect      string `mapstructure:"effect" json:"effect"`
+	// Actions and Resources restrict the rule to some actions and resource
+	// types; "*" matches any.
+	Actions   []string `mapstructure:"actions" json:"actions"`
+	Resources []string `mapstructure:"resources" json:"resources"`
+	// Scope, when set, must be granted to the subject.
+	Scope string `mapstructure:"scope" json:"scope"`
+	// When lists conditions that must all hold.
+	When []Condition `mapstructure:"when" json:"when"`
+}
+
+type Condition struct {
+	Attribute string      `mapstructure:"attribute" json:"attribute"`
+	Operator  string      `mapstructure:"operator" json:"operator"`
+	Value     interface{} `mapstructure:"value" json:"value"`
+}
+
+type engine struct {
+	rules    []Rule
+	prefixes map[string][]netip.Prefix
+	scopes   srv.ScopeMatcher
+	version  string
+}
+
+// LoadEngine reads the policies from a YAML file. Without a file every
+// request is denied by the default rule.
+func LoadEngine(file string, scopes srv.ScopeMatcher) (srv.PolicyEngine, error) {
+	if file == "" {
+		return NewEngine(Policies{}, scopes)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read policy file: %w", err)
+	}
+
+	var policies Policies
+	if err := v.Unmarshal(&policies); err != nil {
+		return nil, fmt.Errorf("parse policy file %s: %w", file, err)
+	}
+	return NewEngine(policies, scopes)
+}
+
+func NewEngine(policies Policies, scopes srv.ScopeMatcher) (srv.PolicyEngine, error) {
+	e := &engine{
+		rules:    policies.Rules,
+		prefixes: map[string][]netip.Prefix{},
+		scopes:   scopes,
+	}
+
+	seen := map[string]bool{}
+	for _, rule := range policies.Rules {
+		if rule.ID == "" || rule.ID == DefaultRule || seen[rule.ID] {
+			return nil, fmt.Errorf("rule %q: missing, reserved or duplicate id", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		if rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			return nil, fmt.Errorf("rule %q: effect must be %s or %s", rule.ID, EffectAllow, EffectDeny)
+		}
+		if rule.Scope != "" && !scopes.Valid(rule.Scope) {
+			return nil, fmt.Errorf("rule %q: invalid scope %q", rule.ID, rule.Scope)
+		}
+
+		for i, cond := range rule.When {
+			if cond.Attribute == "" || !operators[cond.Operator] {
+				return nil, fmt.Errorf("rule %q: condition %d: invalid attribute or operator", rule.ID, i)
+			}
+			if cond.Operator == "cidr" || cond.Operator == "not_cidr" {
+				prefixes, err := parsePrefixes(cond.Value)
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: condition %d: %w", rule.ID, i, err)
+				}
+				e.prefixes[conditionKey(rule.ID, i)] = prefixes
+			}
+		}
+	}
+
+	raw, err := json.Marshal(policies)
+	if err != nil {
+		return nil, fmt.Errorf("hash policies: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	e.version = hex.EncodeToString(sum[:8])
+
+	return e, nil
+}
+
+func (e *engine) Version() string {
+	return e.version
+}
+
+// Decide applies deny-overrides: any matching deny rule wins, otherwise the
+// first matching allow rule, otherwise the request is denied.
+func (e *engine) Decide(attributes map[string]interface{}) dto.AuthorizeResponse {
+	var allow *Rule
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if !e.matches(rule, attributes) {
+			continue
+		}
+		if rule.Effect == EffectDeny {
+			return decision(false, rule.ID, rule.Description)
+		}
+		if allow == nil {
+			allow = rule
+		}
+	}
+
+	if allow != nil {
+		return decision(true, allow.ID, allow.Description)
+	}
+	return decision(false, DefaultRule, "no policy rule allows the request")
+}
+
+func decision(allowed bool, rule, description string) dto.AuthorizeResponse {
+	effect := EffectDeny
+	if allowed {
+		effect = EffectAllow
+	}
+	return dto.AuthorizeResponse{
+		Allowed:     allowed,
+		Decision:    effect,
+		Rule:        rule,
+		Description: description,
+	}
+}
+
+func (e *engine) matches(rule *Rule, attributes map[string]interface{}) bool {
+	if !matchesAny(rule.Actions, fmt.Sprint(attributes["action"])) {
+		return false
+	}
+	if !matchesAny(rule.Resources, fmt.Sprint(attributes["resource.type"])) {
+		return false
+	}
+	if rule.Scope != "" && !e.scopes.Grants(toStrings(attributes["subject.scopes"]), rule.Scope) {
+		return false
+	}
+
+	for i, cond := range rule.When {
+		if !e.holds(conditionKey(rule.ID, i), cond, attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	return slices.Contains(patterns, wildcard) || slices.Contains(patterns, value)
+}
+
+// holds evaluates one condition. A missing attribute fails every operator
+// but "exists".
+func (e *engine) holds(key string, cond Condition, attributes map[string]interface{}) bool {
+	actual, ok := attributes[cond.Attribute]
+	if cond.Operator == "exists" {
+		want, _ := strconv.ParseBool(fmt.Sprint(cond.Value))
+		return ok == want
+	}
+	if !ok || actual == nil {
+		return false
+	}
+
+	expected := cond.Value
+	if ref, isRef := expected.(string); isRef && strings.HasPrefix(ref, refPrefix) {
+		if expected, ok = attributes[strings.TrimPrefix(ref, refPrefix)]; !ok || expected == nil {
+			return false
+		}
+	}
+
+	switch cond.Operator {
+	case "eq":
+		return fmt.Sprint(actual) == fmt.Sprint(expected)
+	case "ne":
+		return fmt.Sprint(actual) != fmt.Sprint(expected)
+	case "in":
+		return slices.Contains(toStrings(expected), fmt.Sprint(actual))
+	case "not_in":
+		return !slices.Contains(toStrings(expected), fmt.Sprint(actual))
+	case "contains":
+		return slices.Contains(toStrings(actual), fmt.Sprint(expected))
+	case "cidr", "not_cidr":
+		addr, err := netip.ParseAddr(fmt.Sprint(actual))
+		if err != nil {
+			return false
+		}
+		addr = addr.Unmap()
+		in := slices.ContainsFunc(e.prefixes[key], func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+		return in == (cond.Operator == "cidr")
+	case "gt", "gte", "lt", "lte":
+		a, errA := strconv.ParseFloat(fmt.Sprint(actual), 64)
+		b, errB := strconv.ParseFloat(fmt.Sprint(expected), 64)
+		if errA != nil || errB != nil {
+			return false
+		}
+		switch cond.Operator {
+		case "gt":
+			return a > b
+		case "gte":
+			return a >= b
+		case "lt":
+			return a < b
+		}
+		return a <= b
+	}
+	return false
+}
+
+func conditionKey(ruleID string, index int) string {
+	return ruleID + "#" + strconv.Itoa(index)
+}
+
+func parsePrefixes(value interface{}) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, raw := range toStrings(value) {
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q", raw)
+		}
+		// Addresses are unmapped before matching, so an IPv4-mapped network
+		// has to be too.
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("cidr needs at least one network")
+	}
+	return prefixes, nil
+}
+
+// toStrings reads a list attribute, or a single value as a list of one.
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []string:
+		return v
+	case []interface{}:
+		result := make([]string, len(v))
+		for i, item := range v {
+			result[i] = fmt.Sprint(item)
+		}
+		return result
+	}
+	return []string{fmt.Sprint(value)}
+}
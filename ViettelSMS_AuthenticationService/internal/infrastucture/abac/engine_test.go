@@ -0,0 +1,289 @@
+package abac
+
+import (
+	"testing"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/scope"
+)
+
+func newTestMatcher(t *testing.T) srv.ScopeMatcher {
+	t.Helper()
+	m, err := scope.NewMatcher(scope.Grammar{Actions: map[string][]string{"update": {"read"}}})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+	return m
+}
+
+func newTestEngine(t *testing.T, rules ...Rule) *engine {
+	t.Helper()
+	e, err := NewEngine(Policies{Rules: rules}, newTestMatcher(t))
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	return e.(*engine)
+}
+
+func TestDecide(t *testing.T) {
+	e := newTestEngine(t,
+		Rule{ID: "deny-blocked", Effect: EffectDeny, Actions: []string{"*"}, Resources: []string{"*"},
+			When: []Condition{{Attribute: "subject.blocked", Operator: "eq", Value: true}}},
+		Rule{ID: "read", Effect: EffectAllow, Actions: []string{"read"}, Resources: []string{"server"}, Scope: "server:read"},
+		Rule{ID: "read-again", Effect: EffectAllow, Actions: []string{"read"}, Resources: []string{"server"}},
+		Rule{ID: "own", Effect: EffectAllow, Actions: []string{"update", "delete"}, Resources: []string{"server"},
+			When: []Condition{{Attribute: "resource.owner", Operator: "eq", Value: "$subject.id"}}},
+		Rule{ID: "delete-internal", Effect: EffectDeny, Actions: []string{"delete"}, Resources: []string{"server"},
+			When: []Condition{{Attribute: "context.ip", Operator: "not_cidr", Value: []interface{}{"10.0.0.0/8"}}}},
+		Rule{ID: "any-resource", Effect: EffectAllow, Actions: []string{"ping"}},
+	)
+
+	base := func(extra map[string]interface{}) map[string]interface{} {
+		attributes := map[string]interface{}{
+			"resource.type":   "server",
+			"subject.id":      "alice",
+			"subject.blocked": false,
+			"subject.scopes":  []string{"server:update"},
+			"context.ip":      "10.1.2.3",
+		}
+		for name, value := range extra {
+			attributes[name] = value
+		}
+		return attributes
+	}
+
+	tests := []struct {
+		name       string
+		attributes map[string]interface{}
+		allowed    bool
+		rule       string
+	}{
+		{"first allow wins", base(map[string]interface{}{"action": "read"}), true, "read"},
+		{"later allow when the first does not match", base(map[string]interface{}{"action": "read", "subject.scopes": []string{}}), true, "read-again"},
+		{"deny overrides an earlier allow", base(map[string]interface{}{"action": "delete", "resource.owner": "alice", "context.ip": "203.0.113.9"}), false, "delete-internal"},
+		{"deny overrides a later allow", base(map[string]interface{}{"action": "read", "subject.blocked": true}), false, "deny-blocked"},
+		{"condition with reference", base(map[string]interface{}{"action": "update", "resource.owner": "alice"}), true, "own"},
+		{"condition with reference fails", base(map[string]interface{}{"action": "update", "resource.owner": "bob"}), false, DefaultRule},
+		{"allowed delete from inside", base(map[string]interface{}{"action": "delete", "resource.owner": "alice"}), true, "own"},
+		{"no resources means any", base(map[string]interface{}{"action": "ping", "resource.type": "printer"}), true, "any-resource"},
+		{"default deny", base(map[string]interface{}{"action": "reboot"}), false, DefaultRule},
+		{"default deny on other resource type", base(map[string]interface{}{"action": "read", "resource.type": "user"}), false, DefaultRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.Decide(tt.attributes)
+			if got.Allowed != tt.allowed || got.Rule != tt.rule {
+				t.Fatalf("Decide = %+v, want allowed %v by %q", got, tt.allowed, tt.rule)
+			}
+			wantDecision := EffectDeny
+			if tt.allowed {
+				wantDecision = EffectAllow
+			}
+			if got.Decision != wantDecision {
+				t.Errorf("Decide = %+v, want decision %q", got, wantDecision)
+			}
+		})
+	}
+}
+
+func TestDecideWithoutRules(t *testing.T) {
+	e := newTestEngine(t)
+	if got := e.Decide(map[string]interface{}{"action": "read", "resource.type": "server"}); got.Allowed || got.Rule != DefaultRule {
+		t.Errorf("Decide = %+v, want the default deny", got)
+	}
+}
+
+func TestHolds(t *testing.T) {
+	attributes := map[string]interface{}{
+		"subject.id":     "alice",
+		"subject.scopes": []string{"server:read", "user:read"},
+		"resource.owner": "alice",
+		"resource.tags":  []interface{}{"prod", "edge"},
+		"resource.size":  42,
+		"resource.ratio": "0.5",
+		"resource.name":  "srv-1",
+		"resource.nil":   nil,
+		"context.hour":   9,
+		"context.ip":     "10.1.2.3",
+		"context.ip6":    "2001:db8::1",
+		"context.mapped": "::ffff:192.168.1.10",
+		"context.bad_ip": "not-an-ip",
+		"max.size":       40,
+	}
+
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{"eq", Condition{"subject.id", "eq", "alice"}, true},
+		{"eq mismatch", Condition{"subject.id", "eq", "bob"}, false},
+		{"eq across types", Condition{"resource.size", "eq", "42"}, true},
+		{"eq reference", Condition{"resource.owner", "eq", "$subject.id"}, true},
+		{"eq missing reference", Condition{"resource.owner", "eq", "$subject.location"}, false},
+		{"eq nil reference", Condition{"resource.owner", "eq", "$resource.nil"}, false},
+		{"ne", Condition{"subject.id", "ne", "bob"}, true},
+		{"ne equal", Condition{"subject.id", "ne", "alice"}, false},
+		{"ne reference", Condition{"resource.name", "ne", "$subject.id"}, true},
+		{"ne missing reference", Condition{"resource.name", "ne", "$subject.location"}, false},
+
+		{"in", Condition{"subject.id", "in", []interface{}{"bob", "alice"}}, true},
+		{"in single value", Condition{"subject.id", "in", "alice"}, true},
+		{"in mismatch", Condition{"subject.id", "in", []interface{}{"bob"}}, false},
+		{"not_in", Condition{"subject.id", "not_in", []interface{}{"bob"}}, true},
+		{"not_in present", Condition{"subject.id", "not_in", []interface{}{"alice"}}, false},
+		{"contains string list", Condition{"subject.scopes", "contains", "user:read"}, true},
+		{"contains interface list", Condition{"resource.tags", "contains", "edge"}, true},
+		{"contains mismatch", Condition{"resource.tags", "contains", "dev"}, false},
+		{"contains reference", Condition{"resource.tags", "contains", "$missing"}, false},
+
+		{"gt", Condition{"resource.size", "gt", 41}, true},
+		{"gt equal", Condition{"resource.size", "gt", 42}, false},
+		{"gte", Condition{"resource.size", "gte", 42}, true},
+		{"lt", Condition{"context.hour", "lt", 18}, true},
+		{"lt equal", Condition{"context.hour", "lt", 9}, false},
+		{"lte", Condition{"context.hour", "lte", 9}, true},
+		{"lte string number", Condition{"resource.ratio", "lte", "0.5"}, true},
+		{"gt reference", Condition{"resource.size", "gt", "$max.size"}, true},
+		{"gt not a number", Condition{"resource.name", "gt", 1}, false},
+		{"gt against not a number", Condition{"resource.size", "gt", "many"}, false},
+
+		{"exists", Condition{"resource.owner", "exists", true}, true},
+		{"exists missing", Condition{"resource.location", "exists", true}, false},
+		{"not exists missing", Condition{"resource.location", "exists", false}, true},
+		{"not exists present", Condition{"resource.owner", "exists", false}, false},
+		{"exists nil", Condition{"resource.nil", "exists", true}, true},
+
+		{"missing eq", Condition{"resource.location", "eq", "hanoi"}, false},
+		{"missing ne", Condition{"resource.location", "ne", "hanoi"}, false},
+		{"missing not_in", Condition{"resource.location", "not_in", []interface{}{"hanoi"}}, false},
+		{"missing not_cidr", Condition{"context.missing", "not_cidr", []interface{}{"10.0.0.0/8"}}, false},
+		{"nil eq", Condition{"resource.nil", "eq", "<nil>"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTestEngine(t, Rule{ID: "r", Effect: EffectAllow, When: []Condition{tt.cond}})
+			if got := e.holds(conditionKey("r", 0), tt.cond, attributes); got != tt.want {
+				t.Errorf("holds(%+v) = %v, want %v", tt.cond, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoldsCIDR(t *testing.T) {
+	tests := []struct {
+		name     string
+		ip       string
+		networks []interface{}
+		in       bool
+	}{
+		{"ipv4 inside", "10.1.2.3", []interface{}{"10.0.0.0/8"}, true},
+		{"ipv4 outside", "11.1.2.3", []interface{}{"10.0.0.0/8"}, false},
+		{"ipv4 second network", "192.168.1.10", []interface{}{"10.0.0.0/8", "192.168.0.0/16"}, true},
+		{"unmasked network", "10.9.9.9", []interface{}{"10.1.2.3/8"}, true},
+		{"single host", "10.1.2.3", []interface{}{"10.1.2.3/32"}, true},
+		{"ipv6 inside", "2001:db8::1", []interface{}{"2001:db8::/32"}, true},
+		{"ipv6 outside", "2001:db9::1", []interface{}{"2001:db8::/32"}, false},
+		{"mapped address, ipv4 network", "::ffff:192.168.1.10", []interface{}{"192.168.0.0/16"}, true},
+		{"mapped address outside", "::ffff:8.8.8.8", []interface{}{"192.168.0.0/16"}, false},
+		{"ipv4 address, mapped network", "192.168.1.10", []interface{}{"::ffff:192.168.0.0/112"}, true},
+		{"mapped address, mapped network", "::ffff:192.168.1.10", []interface{}{"::ffff:192.168.0.0/112"}, true},
+		{"ipv4 address, ipv6 network", "10.1.2.3", []interface{}{"::/0"}, false},
+		{"single string network", "10.1.2.3", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var networks interface{} = tt.networks
+			if tt.networks == nil {
+				networks = "10.0.0.0/8"
+			}
+			for _, operator := range []string{"cidr", "not_cidr"} {
+				cond := Condition{Attribute: "context.ip", Operator: operator, Value: networks}
+				e := newTestEngine(t, Rule{ID: "r", Effect: EffectAllow, When: []Condition{cond}})
+				want := tt.in == (operator == "cidr")
+				if got := e.holds(conditionKey("r", 0), cond, map[string]interface{}{"context.ip": tt.ip}); got != want {
+					t.Errorf("%s %s in %v = %v, want %v", operator, tt.ip, networks, got, want)
+				}
+			}
+		})
+	}
+
+	// An attribute that is not an address fails both operators.
+	for _, operator := range []string{"cidr", "not_cidr"} {
+		cond := Condition{Attribute: "context.ip", Operator: operator, Value: "10.0.0.0/8"}
+		e := newTestEngine(t, Rule{ID: "r", Effect: EffectAllow, When: []Condition{cond}})
+		if e.holds(conditionKey("r", 0), cond, map[string]interface{}{"context.ip": "not-an-ip"}) {
+			t.Errorf("%s held for a malformed address", operator)
+		}
+	}
+}
+
+func TestNewEngineValidation(t *testing.T) {
+	valid := Rule{ID: "ok", Effect: EffectAllow}
+
+	tests := []struct {
+		name  string
+		rules []Rule
+	}{
+		{"missing id", []Rule{{Effect: EffectAllow}}},
+		{"reserved id", []Rule{{ID: DefaultRule, Effect: EffectAllow}}},
+		{"duplicate id", []Rule{valid, valid}},
+		{"missing effect", []Rule{{ID: "r"}}},
+		{"unknown effect", []Rule{{ID: "r", Effect: "permit"}}},
+		{"invalid scope", []Rule{{ID: "r", Effect: EffectAllow, Scope: "server"}}},
+		{"condition without attribute", []Rule{{ID: "r", Effect: EffectAllow, When: []Condition{{Operator: "eq", Value: "x"}}}}},
+		{"unknown operator", []Rule{{ID: "r", Effect: EffectAllow, When: []Condition{{Attribute: "a", Operator: "like", Value: "x"}}}}},
+		{"invalid cidr", []Rule{{ID: "r", Effect: EffectAllow, When: []Condition{{Attribute: "context.ip", Operator: "cidr", Value: "10.0.0.0/33"}}}}},
+		{"not a cidr", []Rule{{ID: "r", Effect: EffectAllow, When: []Condition{{Attribute: "context.ip", Operator: "not_cidr", Value: []interface{}{"10.0.0.1"}}}}}},
+		{"empty cidr list", []Rule{{ID: "r", Effect: EffectAllow, When: []Condition{{Attribute: "context.ip", Operator: "cidr", Value: []interface{}{}}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEngine(Policies{Rules: tt.rules}, newTestMatcher(t)); err == nil {
+				t.Error("NewEngine accepted invalid policies")
+			}
+		})
+	}
+}
+
+func TestVersion(t *testing.T) {
+	a := newTestEngine(t, Rule{ID: "a", Effect: EffectAllow})
+	b := newTestEngine(t, Rule{ID: "a", Effect: EffectAllow})
+	c := newTestEngine(t, Rule{ID: "a", Effect: EffectDeny})
+
+	if a.Version() == "" || a.Version() != b.Version() {
+		t.Errorf("equal policies have versions %q and %q", a.Version(), b.Version())
+	}
+	if a.Version() == c.Version() {
+		t.Error("different policies share a version")
+	}
+}
+
+func TestLoadEngine(t *testing.T) {
+	e, err := LoadEngine("../../../config/policies.yaml", newTestMatcher(t))
+	if err != nil {
+		t.Fatalf("LoadEngine: %v", err)
+	}
+
+	got := e.Decide(map[string]interface{}{
+		"action":          "read",
+		"resource.type":   "server",
+		"subject.blocked": false,
+		"subject.scopes":  []string{"server:read"},
+	})
+	if !got.Allowed || got.Rule != "server-read" {
+		t.Errorf("Decide = %+v, want allowed by server-read", got)
+	}
+
+	if _, err := LoadEngine("testdata/missing.yaml", newTestMatcher(t)); err == nil {
+		t.Error("LoadEngine accepted a missing file")
+	}
+
+	e, err = LoadEngine("", newTestMatcher(t))
+	if err != nil {
+		t.Fatalf("LoadEngine without a file: %v", err)
+	}
+	if got := e.Decide(map[string]interface{}{"action": "read"}); got.Allowed {
+		t.Errorf("Decide without policies = %+v, want the default deny", got)
+	}
+}
@@ -0,0 +1,165 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testParams keep argon2 cheap enough to hash in every test.
+var (
+	testParams = Params{Memory: 64, Time: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	testLimits = Limits{MaxMemory: 1024, MaxTime: 3, MaxParallelism: 4}
+)
+
+const (
+	testSalt = "c2FsdHNhbHRzYWx0c2FsdA"
+	testKey  = "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"
+)
+
+func testHash(params string) string {
+	return "$argon2id$v=19$" + params + "$" + testSalt + "$" + testKey
+}
+
+func TestRoundTrip(t *testing.T) {
+	a := NewArgon2Service(testParams, testLimits)
+
+	hashed, err := a.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash = %q, want the PHC format with the configured parameters", hashed)
+	}
+	if !a.Supports(hashed) {
+		t.Error("Supports rejected its own hash")
+	}
+	if err := a.Validate(hashed); err != nil {
+		t.Errorf("Validate rejected its own hash: %v", err)
+	}
+	if a.NeedsRehash(hashed) {
+		t.Error("NeedsRehash on a hash with the current parameters")
+	}
+
+	if ok, err := a.Verify(hashed, "correct horse"); err != nil || !ok {
+		t.Errorf("Verify(right password) = %v, %v", ok, err)
+	}
+	if ok, err := a.Verify(hashed, "wrong horse"); err != nil || ok {
+		t.Errorf("Verify(wrong password) = %v, %v", ok, err)
+	}
+
+	other, err := a.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if other == hashed {
+		t.Error("two hashes of the same password share a salt")
+	}
+}
+
+func TestDecodeHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		hash   string
+		wantOK bool
+	}{
+		{"valid", testHash("m=64,t=1,p=1"), true},
+		{"minimum memory per lane", testHash("m=32,t=1,p=4"), true},
+
+		{"zero time", testHash("m=64,t=0,p=1"), false},
+		{"zero parallelism", testHash("m=64,t=1,p=0"), false},
+		{"memory below 8 KiB per lane", testHash("m=31,t=1,p=4"), false},
+		{"zero memory", testHash("m=0,t=1,p=1"), false},
+		{"parallelism overflows uint8", testHash("m=4096,t=1,p=256"), false},
+		{"negative time", testHash("m=64,t=-1,p=1"), false},
+		{"missing parameter", testHash("m=64,t=1"), false},
+		{"parameters out of order", testHash("t=1,m=64,p=1"), false},
+
+		{"other version", "$argon2id$v=16$m=64,t=1,p=1$" + testSalt + "$" + testKey, false},
+		{"missing version", "$argon2id$m=64,t=1,p=1$" + testSalt + "$" + testKey, false},
+		{"argon2i", "$argon2i$v=19$m=64,t=1,p=1$" + testSalt + "$" + testKey, false},
+		{"salt not base64", "$argon2id$v=19$m=64,t=1,p=1$not*base64$" + testKey, false},
+		{"key not base64", "$argon2id$v=19$m=64,t=1,p=1$" + testSalt + "$not*base64", false},
+		{"empty key", "$argon2id$v=19$m=64,t=1,p=1$" + testSalt + "$", false},
+		{"too few fields", "$argon2id$v=19$m=64,t=1,p=1$" + testSalt, false},
+		{"too many fields", testHash("m=64,t=1,p=1") + "$extra", false},
+		{"empty", "", false},
+		{"bcrypt", "$2b$10$abcdefghijklmnopqrstuuabcdefghijklmnopqrstuvwxyz01234", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := decodeHash(tt.hash)
+			if tt.wantOK && err != nil {
+				t.Fatalf("decodeHash(%q): %v", tt.hash, err)
+			}
+			if !tt.wantOK && !errors.Is(err, ErrInvalidHash) {
+				t.Fatalf("decodeHash(%q) error = %v, want ErrInvalidHash", tt.hash, err)
+			}
+		})
+	}
+}
+
+// TestVerifyRejectsUnsafeParams makes sure a hash argon2.IDKey would panic
+// on is refused before it gets there.
+func TestVerifyRejectsUnsafeParams(t *testing.T) {
+	a := NewArgon2Service(testParams, testLimits)
+
+	for _, params := range []string{"m=64,t=0,p=1", "m=64,t=1,p=0", "m=0,t=1,p=1"} {
+		if _, err := a.Verify(testHash(params), "password"); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("Verify with %s error = %v, want ErrInvalidHash", params, err)
+		}
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	a := NewArgon2Service(testParams, testLimits)
+
+	tests := []struct {
+		name   string
+		params string
+		want   error
+	}{
+		{"within limits", "m=64,t=1,p=1", nil},
+		{"at limits", "m=1024,t=3,p=4", nil},
+		{"memory over limit", "m=1025,t=1,p=1", ErrParamsLimits},
+		{"time over limit", "m=64,t=4,p=1", ErrParamsLimits},
+		{"parallelism over limit", "m=64,t=1,p=5", ErrParamsLimits},
+		{"malformed", "m=64,t=0,p=1", ErrInvalidHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := a.Validate(testHash(tt.params))
+			if tt.want == nil && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Validate error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	a := NewArgon2Service(testParams, testLimits)
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"current parameters", testHash("m=64,t=1,p=1"), false},
+		{"other memory", testHash("m=128,t=1,p=1"), true},
+		{"other time", testHash("m=64,t=2,p=1"), true},
+		{"other parallelism", testHash("m=64,t=1,p=2"), true},
+		{"shorter salt", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$" + testKey, true},
+		{"shorter key", "$argon2id$v=19$m=64,t=1,p=1$" + testSalt + "$a2V5", true},
+		{"malformed", "$argon2id$v=19$m=64,t=1,p=1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash(%q) = %v, want %v", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
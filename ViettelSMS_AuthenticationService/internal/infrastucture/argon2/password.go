@@ -0,0 +1,144 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"golang.org/x/crypto/argon2"
+)
+
+const hashPrefix = "$argon2id$"
+
+var (
+	ErrInvalidHash  = errors.New("invalid argon2id hash")
+	ErrParamsLimits = errors.New("argon2id parameters exceed the configured limits")
+)
+
+// Params are the Argon2id cost parameters. Memory is in KiB.
+type Params struct {
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Limits bound the parameters Validate accepts in a hash made elsewhere.
+// Memory is in KiB.
+type Limits struct {
+	MaxMemory      uint32
+	MaxTime        uint32
+	MaxParallelism uint8
+}
+
+type argon2Service struct {
+	params Params
+	limits Limits
+}
+
+func NewArgon2Service(params Params, limits Limits) srv.PasswordService {
+	return &argon2Service{params: params, limits: limits}
+}
+
+// Hash encodes the result in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func (a *argon2Service) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Time, a.params.Memory, a.params.Parallelism, a.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		hashPrefix,
+		argon2.Version,
+		a.params.Memory,
+		a.params.Time,
+		a.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a *argon2Service) Verify(hashedPassword, password string) (bool, error) {
+	params, salt, key, err := decodeHash(hashedPassword)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, candidate) == 1, nil
+}
+
+func (a *argon2Service) Supports(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, hashPrefix)
+}
+
+func (a *argon2Service) Validate(hashedPassword string) error {
+	params, _, _, err := decodeHash(hashedPassword)
+	if err != nil {
+		return err
+	}
+	if params.Memory > a.limits.MaxMemory || params.Time > a.limits.MaxTime || params.Parallelism > a.limits.MaxParallelism {
+		return fmt.Errorf("%w: m=%d,t=%d,p=%d", ErrParamsLimits, params.Memory, params.Time, params.Parallelism)
+	}
+	return nil
+}
+
+func (a *argon2Service) NeedsRehash(hashedPassword string) bool {
+	params, salt, key, err := decodeHash(hashedPassword)
+	if err != nil {
+		return true
+	}
+	return params.Memory != a.params.Memory ||
+		params.Time != a.params.Time ||
+		params.Parallelism != a.params.Parallelism ||
+		uint32(len(salt)) != a.params.SaltLength ||
+		uint32(len(key)) != a.params.KeyLength
+}
+
+func decodeHash(hashedPassword string) (*Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidHash, version)
+	}
+
+	params := &Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+	// argon2.IDKey panics on a zero time or parallelism, and needs at least
+	// 8 KiB of memory per lane.
+	if params.Time == 0 || params.Parallelism == 0 || params.Memory < 8*uint32(params.Parallelism) {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrInvalidHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
@@ -0,0 +1,125 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testSaltAndHash is 53 characters of bcrypt's base64 alphabet, the part of
+// a hash after "$2b$NN$".
+var testSaltAndHash = strings.Repeat("abcdefghij", 5) + "ABC"
+
+func TestRoundTrip(t *testing.T) {
+	b := NewBcryptService(bcrypt.MinCost, bcrypt.MinCost)
+
+	hashed, err := b.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !b.Supports(hashed) {
+		t.Error("Supports rejected its own hash")
+	}
+	if err := b.Validate(hashed); err != nil {
+		t.Errorf("Validate rejected its own hash: %v", err)
+	}
+	if b.NeedsRehash(hashed) {
+		t.Error("NeedsRehash on a hash with the current cost")
+	}
+
+	if ok, err := b.Verify(hashed, "correct horse"); err != nil || !ok {
+		t.Errorf("Verify(right password) = %v, %v", ok, err)
+	}
+	if ok, err := b.Verify(hashed, "wrong horse"); err != nil || ok {
+		t.Errorf("Verify(wrong password) = %v, %v", ok, err)
+	}
+	if _, err := b.Verify("$2b$04$short", "correct horse"); err == nil {
+		t.Error("Verify accepted a malformed hash")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	b := NewBcryptService(bcrypt.MinCost, 12)
+
+	tests := []struct {
+		name string
+		hash string
+		want error
+	}{
+		{"2b", "$2b$10$" + testSaltAndHash, nil},
+		{"2a", "$2a$10$" + testSaltAndHash, nil},
+		{"2y", "$2y$10$" + testSaltAndHash, nil},
+		{"at max cost", "$2b$12$" + testSaltAndHash, nil},
+		{"minimum cost", "$2b$04$" + testSaltAndHash, nil},
+
+		{"over max cost", "$2b$13$" + testSaltAndHash, ErrCostLimit},
+		{"huge cost", "$2b$31$" + testSaltAndHash, ErrCostLimit},
+		{"cost below minimum", "$2b$03$" + testSaltAndHash, ErrInvalidHash},
+		{"cost not a number", "$2b$xx$" + testSaltAndHash, ErrInvalidHash},
+		{"too short", "$2b$10$" + testSaltAndHash[1:], ErrInvalidHash},
+		{"too long", "$2b$10$" + testSaltAndHash + "A", ErrInvalidHash},
+		{"outside the alphabet", "$2b$10$" + testSaltAndHash[:52] + "+", ErrInvalidHash},
+		{"dollar in the salt", "$2b$10$" + testSaltAndHash[:52] + "$", ErrInvalidHash},
+		{"unknown prefix", "$2x$10$" + testSaltAndHash, ErrInvalidHash},
+		{"argon2id", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5", ErrInvalidHash},
+		{"empty", "", ErrInvalidHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.Validate(tt.hash)
+			if tt.want == nil && err != nil {
+				t.Fatalf("Validate(%q): %v", tt.hash, err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("Validate(%q) error = %v, want %v", tt.hash, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBcryptServiceBounds(t *testing.T) {
+	tests := []struct {
+		name          string
+		cost, maxCost int
+		wantCost      int
+		wantMaxCost   int
+	}{
+		{"as given", 10, 12, 10, 12},
+		{"max below cost", 10, 8, 10, 10},
+		{"cost too low", 2, 12, bcrypt.DefaultCost, 12},
+		{"cost too high", 40, 12, bcrypt.DefaultCost, 12},
+		{"max too high", 10, 40, 10, bcrypt.MaxCost},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBcryptService(tt.cost, tt.maxCost).(*bcryptService)
+			if b.cost != tt.wantCost || b.maxCost != tt.wantMaxCost {
+				t.Errorf("cost, maxCost = %d, %d, want %d, %d", b.cost, b.maxCost, tt.wantCost, tt.wantMaxCost)
+			}
+		})
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	b := NewBcryptService(10, 12)
+
+	tests := []struct {
+		name string
+		hash string
+		want bool
+	}{
+		{"current cost", "$2b$10$" + testSaltAndHash, false},
+		{"lower cost", "$2b$08$" + testSaltAndHash, true},
+		{"higher cost", "$2b$12$" + testSaltAndHash, true},
+		{"malformed", "$2b$", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash(%q) = %v, want %v", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
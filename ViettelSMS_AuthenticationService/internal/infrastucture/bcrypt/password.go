@@ -1,28 +1,92 @@
 package password
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type bcryptService struct{}
+// hashLength is the length of a bcrypt hash: "$2b$10$" followed by 22
+// characters of salt and 31 of hash.
+const hashLength = 60
+
+const bcryptAlphabet = "./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+var (
+	ErrInvalidHash = errors.New("invalid bcrypt hash")
+	ErrCostLimit   = errors.New("bcrypt cost exceeds the configured limit")
+)
 
-func NewBcryptService() srv.PasswordService {
-	return &bcryptService{}
+type bcryptService struct {
+	cost    int
+	maxCost int
+}
+
+// NewBcryptService hashes with cost and accepts hashes up to maxCost in
+// Validate.
+func NewBcryptService(cost, maxCost int) srv.PasswordService {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	maxCost = min(max(maxCost, cost), bcrypt.MaxCost)
+	return &bcryptService{cost: cost, maxCost: maxCost}
 }
 
 func (b *bcryptService) Hash(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
 	if err != nil {
 		return "", err
 	}
 	return string(hashedPassword), err
 }
 
+// Verify reports a wrong password as (false, nil); an error means the hash
+// itself could not be used.
 func (b *bcryptService) Verify(hashedPassword, password string) (bool, error) {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
 	if err != nil {
 		return false, err
 	}
 	return true, nil
 }
+
+func (b *bcryptService) Supports(hashedPassword string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hashedPassword, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *bcryptService) Validate(hashedPassword string) error {
+	if !b.Supports(hashedPassword) || len(hashedPassword) != hashLength {
+		return ErrInvalidHash
+	}
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidHash, err)
+	}
+	if cost > b.maxCost {
+		return fmt.Errorf("%w: %d", ErrCostLimit, cost)
+	}
+	// Salt and hash use bcrypt's own base64 alphabet.
+	if strings.Trim(hashedPassword[7:], bcryptAlphabet) != "" {
+		return ErrInvalidHash
+	}
+	return nil
+}
+
+func (b *bcryptService) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return true
+	}
+	return cost != b.cost
+}
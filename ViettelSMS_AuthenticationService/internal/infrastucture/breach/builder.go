@@ -0,0 +1,276 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BuildOptions controls how a corpus is built from hash list dumps.
+type BuildOptions struct {
+	Kind Kind
+	// MinCount drops hashes seen fewer times than this in HIBP style
+	// "HASH:COUNT" lines. Lines without a count always pass.
+	MinCount int64
+	// ChunkSize is the number of hashes sorted in memory at a time; larger
+	// inputs are sorted in chunks and merged.
+	ChunkSize int
+	// TempDir holds the sorted chunks; it defaults to the output directory.
+	TempDir string
+}
+
+type BuildStats struct {
+	Read    int64
+	Skipped int64
+	Written int64
+}
+
+// Build reads hex hash lists (one hash per line, optionally followed by
+// ":COUNT" as in the HIBP downloads) and writes a sorted, de-duplicated
+// corpus to out. The file is written next to out and renamed into place, so a
+// running server never sees a partial corpus.
+func Build(out string, inputs []io.Reader, opts BuildOptions) (*BuildStats, error) {
+	size := opts.Kind.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("unknown hash kind %d", opts.Kind)
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 1 << 24
+	}
+	if opts.TempDir == "" {
+		opts.TempDir = filepath.Dir(out)
+	}
+
+	stats := &BuildStats{}
+	var chunks []string
+	defer func() {
+		for _, chunk := range chunks {
+			os.Remove(chunk)
+		}
+	}()
+
+	buf := make([]byte, 0, opts.ChunkSize*size)
+	flush := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		chunk, err := writeChunk(opts.TempDir, buf, size)
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+		buf = buf[:0]
+		return nil
+	}
+
+	for _, input := range inputs {
+		scanner := bufio.NewScanner(input)
+		for scanner.Scan() {
+			hash, ok := parseLine(scanner.Text(), size, opts.MinCount)
+			if !ok {
+				stats.Skipped++
+				continue
+			}
+			stats.Read++
+
+			buf = append(buf, hash...)
+			if len(buf) == cap(buf) {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	written, err := mergeChunks(out, chunks, opts.Kind)
+	if err != nil {
+		return nil, err
+	}
+	stats.Written = written
+	return stats, nil
+}
+
+// parseLine accepts "HEX" or "HEX:COUNT" and returns the decoded hash.
+func parseLine(line string, size int, minCount int64) ([]byte, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, false
+	}
+
+	hexHash, countStr, hasCount := strings.Cut(line, ":")
+	if len(hexHash) != size*2 {
+		return nil, false
+	}
+
+	if hasCount && minCount > 0 {
+		count, err := strconv.ParseInt(strings.TrimSpace(countStr), 10, 64)
+		if err != nil || count < minCount {
+			return nil, false
+		}
+	}
+
+	hash, err := hex.DecodeString(hexHash)
+	if err != nil {
+		return nil, false
+	}
+	return hash, true
+}
+
+func writeChunk(dir string, buf []byte, size int) (string, error) {
+	records := &recordSlice{data: buf, size: size, tmp: make([]byte, size)}
+	sort.Sort(records)
+
+	file, err := os.CreateTemp(dir, "breach-chunk-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(buf); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+func mergeChunks(out string, chunks []string, kind Kind) (int64, error) {
+	size := kind.Size()
+
+	tmp, err := os.CreateTemp(filepath.Dir(out), filepath.Base(out)+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w := bufio.NewWriterSize(tmp, 1<<20)
+	if _, err := w.Write(encodeHeader(kind)); err != nil {
+		return 0, err
+	}
+
+	h := &chunkHeap{}
+	for _, chunk := range chunks {
+		file, err := os.Open(chunk)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+
+		reader := &chunkReader{r: bufio.NewReaderSize(file, 1<<16), current: make([]byte, size)}
+		ok, err := reader.next()
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			heap.Push(h, reader)
+		}
+	}
+
+	var written int64
+	last := make([]byte, size)
+	for h.Len() > 0 {
+		reader := (*h)[0]
+		if written == 0 || !bytes.Equal(reader.current, last) {
+			if _, err := w.Write(reader.current); err != nil {
+				return 0, err
+			}
+			copy(last, reader.current)
+			written++
+		}
+
+		ok, err := reader.next()
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), out); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// recordSlice sorts fixed-size records packed into one byte slice, avoiding
+// a slice header per hash.
+type recordSlice struct {
+	data []byte
+	size int
+	tmp  []byte
+}
+
+func (r *recordSlice) Len() int { return len(r.data) / r.size }
+
+func (r *recordSlice) Less(i, j int) bool {
+	return bytes.Compare(r.at(i), r.at(j)) < 0
+}
+
+func (r *recordSlice) Swap(i, j int) {
+	copy(r.tmp, r.at(i))
+	copy(r.at(i), r.at(j))
+	copy(r.at(j), r.tmp)
+}
+
+func (r *recordSlice) at(i int) []byte {
+	return r.data[i*r.size : (i+1)*r.size]
+}
+
+type chunkReader struct {
+	r       *bufio.Reader
+	current []byte
+}
+
+func (c *chunkReader) next() (bool, error) {
+	if _, err := io.ReadFull(c.r, c.current); err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+type chunkHeap []*chunkReader
+
+func (h chunkHeap) Len() int           { return len(h) }
+func (h chunkHeap) Less(i, j int) bool { return bytes.Compare(h[i].current, h[j].current) < 0 }
+func (h chunkHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *chunkHeap) Push(x any)        { *h = append(*h, x.(*chunkReader)) }
+
+func (h *chunkHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
@@ -0,0 +1,98 @@
+package breach
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+type checker struct {
+	file  *os.File
+	kind  Kind
+	count int64
+}
+
+// NewBreachedPasswordChecker opens the corpus built by cmd/breach. Without a
+// configured file every password is reported as not breached.
+func NewBreachedPasswordChecker(cfg *config.Config) (srv.BreachedPasswordChecker, error) {
+	path := cfg.BreachedPassword.File
+	if path == "" {
+		return &checker{}, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password corpus: %w", err)
+	}
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCorpus, err)
+	}
+
+	kind, err := decodeHeader(header)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	body := info.Size() - headerSize
+	if body%int64(kind.Size()) != 0 {
+		file.Close()
+		return nil, fmt.Errorf("%w: truncated record", ErrInvalidCorpus)
+	}
+
+	return &checker{
+		file:  file,
+		kind:  kind,
+		count: body / int64(kind.Size()),
+	}, nil
+}
+
+func (c *checker) IsBreached(password string) (bool, error) {
+	if c.file == nil || c.count == 0 {
+		return false, nil
+	}
+
+	target := c.kind.Sum(password)
+	record := make([]byte, c.kind.Size())
+
+	var readErr error
+	i := sort.Search(int(c.count), func(i int) bool {
+		if readErr != nil {
+			return true
+		}
+		if _, err := c.file.ReadAt(record, c.offset(int64(i))); err != nil {
+			readErr = err
+			return true
+		}
+		return bytes.Compare(record, target) >= 0
+	})
+	if readErr != nil {
+		return false, fmt.Errorf("failed to read breached password corpus: %w", readErr)
+	}
+	if int64(i) == c.count {
+		return false, nil
+	}
+
+	if _, err := c.file.ReadAt(record, c.offset(int64(i))); err != nil {
+		return false, fmt.Errorf("failed to read breached password corpus: %w", err)
+	}
+	return bytes.Equal(record, target), nil
+}
+
+func (c *checker) offset(i int64) int64 {
+	return headerSize + i*int64(c.kind.Size())
+}
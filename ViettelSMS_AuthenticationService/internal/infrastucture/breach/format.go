@@ -0,0 +1,111 @@
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// A corpus file is a 16 byte header followed by fixed-size password hashes in
+// ascending byte order, with no duplicates, so a lookup is a binary search on
+// disk and the file never has to be loaded into memory.
+//
+//	magic   [8]byte  "VSBREACH"
+//	version uint8
+//	kind    uint8    KindSHA1 or KindNTLM
+//	_       [6]byte
+const (
+	magic         = "VSBREACH"
+	formatVersion = 1
+	headerSize    = 16
+)
+
+type Kind uint8
+
+const (
+	KindSHA1 Kind = 1
+	KindNTLM Kind = 2
+)
+
+var ErrInvalidCorpus = errors.New("invalid breached password corpus")
+
+func ParseKind(name string) (Kind, error) {
+	switch name {
+	case "sha1":
+		return KindSHA1, nil
+	case "ntlm":
+		return KindNTLM, nil
+	default:
+		return 0, fmt.Errorf("unknown hash kind %q, want sha1 or ntlm", name)
+	}
+}
+
+func (k Kind) String() string {
+	switch k {
+	case KindSHA1:
+		return "sha1"
+	case KindNTLM:
+		return "ntlm"
+	default:
+		return fmt.Sprintf("kind(%d)", uint8(k))
+	}
+}
+
+// Size is the length in bytes of one hash of this kind.
+func (k Kind) Size() int {
+	switch k {
+	case KindSHA1:
+		return sha1.Size
+	case KindNTLM:
+		return md4.Size
+	default:
+		return 0
+	}
+}
+
+// Sum hashes a plaintext password the way the corpus was hashed: SHA-1 of the
+// UTF-8 bytes for HIBP SHA-1 dumps, MD4 of the UTF-16LE bytes for NTLM dumps.
+func (k Kind) Sum(password string) []byte {
+	switch k {
+	case KindNTLM:
+		h := md4.New()
+		for _, unit := range utf16.Encode([]rune(password)) {
+			h.Write([]byte{byte(unit), byte(unit >> 8)})
+		}
+		return h.Sum(nil)
+	default:
+		sum := sha1.Sum([]byte(password))
+		return sum[:]
+	}
+}
+
+func encodeHeader(kind Kind) []byte {
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	header[8] = formatVersion
+	header[9] = byte(kind)
+	return header
+}
+
+func decodeHeader(header []byte) (Kind, error) {
+	if len(header) < headerSize || string(header[:len(magic)]) != magic {
+		return 0, ErrInvalidCorpus
+	}
+	if header[8] != formatVersion {
+		return 0, fmt.Errorf("%w: unsupported version %d", ErrInvalidCorpus, header[8])
+	}
+
+	kind := Kind(header[9])
+	if kind.Size() == 0 {
+		return 0, fmt.Errorf("%w: unknown hash kind %d", ErrInvalidCorpus, header[9])
+	}
+	// Reserved bytes must stay zero so they can be given a meaning later.
+	if binary.BigEndian.Uint32(header[10:14]) != 0 || binary.BigEndian.Uint16(header[14:16]) != 0 {
+		return 0, fmt.Errorf("%w: non-zero reserved bytes", ErrInvalidCorpus)
+	}
+	return kind, nil
+}
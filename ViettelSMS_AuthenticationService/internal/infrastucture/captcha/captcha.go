@@ -0,0 +1,44 @@
+package captcha
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+const (
+	ProviderStub       = "stub"
+	ProviderSiteVerify = "siteverify"
+)
+
+// NewCaptchaVerifier selects the verifier named by CHALLENGE_CAPTCHA_PROVIDER.
+func NewCaptchaVerifier(cfg *config.Config) (srv.CaptchaVerifier, error) {
+	switch cfg.Challenge.CaptchaProvider {
+	case ProviderStub:
+		return NewStubVerifier(cfg.Challenge.CaptchaStubToken), nil
+	case ProviderSiteVerify:
+		return NewSiteVerifyVerifier(cfg.Challenge.CaptchaVerifyURL, cfg.Challenge.CaptchaSecret)
+	default:
+		return nil, fmt.Errorf("unsupported captcha provider: %s", cfg.Challenge.CaptchaProvider)
+	}
+}
+
+// stubVerifier accepts exactly one configured token. It stands in for a real
+// CAPTCHA service in development and tests.
+type stubVerifier struct {
+	token string
+}
+
+func NewStubVerifier(token string) srv.CaptchaVerifier {
+	return &stubVerifier{token: token}
+}
+
+func (s *stubVerifier) Verify(_ context.Context, token, _ string) (bool, error) {
+	if s.token == "" {
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1, nil
+}
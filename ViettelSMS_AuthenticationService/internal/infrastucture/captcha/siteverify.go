@@ -0,0 +1,70 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+// siteVerifyVerifier speaks the siteverify protocol shared by reCAPTCHA,
+// hCaptcha, Turnstile and self-hosted CAPTCHA servers: a form POST of
+// secret, response and remoteip answered with {"success": bool}.
+type siteVerifyVerifier struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewSiteVerifyVerifier(verifyURL, secret string) (srv.CaptchaVerifier, error) {
+	if verifyURL == "" || secret == "" {
+		return nil, errors.New("CHALLENGE_CAPTCHA_VERIFY_URL and CHALLENGE_CAPTCHA_SECRET are required")
+	}
+	return &siteVerifyVerifier{
+		url:    verifyURL,
+		secret: secret,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+func (s *siteVerifyVerifier) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	form := url.Values{
+		"secret":   {s.secret},
+		"response": {token},
+		"remoteip": {remoteIP},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("captcha verification failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha verification failed: status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("captcha verification failed: %w", err)
+	}
+	return result.Success, nil
+}
@@ -0,0 +1,131 @@
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+type ipRange struct {
+	start    netip.Addr
+	end      netip.Addr
+	location dto.GeoLocation
+}
+
+type locator struct {
+	ranges []ipRange
+}
+
+// NewGeoIPLocator loads the local GeoIP database named by RISK_GEOIP_FILE.
+// Without a file every lookup misses, which turns off the location based
+// login heuristics.
+func NewGeoIPLocator(cfg *config.Config) (srv.GeoIPLocator, error) {
+	if cfg.Risk.GeoIPFile == "" {
+		return &locator{}, nil
+	}
+
+	file, err := os.Open(cfg.Risk.GeoIPFile)
+	if err != nil {
+		return nil, fmt.Errorf("open geoip database: %w", err)
+	}
+	defer file.Close()
+
+	ranges, err := parseRanges(file)
+	if err != nil {
+		return nil, fmt.Errorf("load geoip database %s: %w", cfg.Risk.GeoIPFile, err)
+	}
+	return &locator{ranges: ranges}, nil
+}
+
+// parseRanges reads CSV lines of
+// START_IP,END_IP,COUNTRY,LATITUDE,LONGITUDE. Lines starting with '#' are
+// comments. Ranges must not overlap; they are sorted after loading.
+func parseRanges(r io.Reader) ([]ipRange, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 5
+	reader.TrimLeadingSpace = true
+
+	var ranges []ipRange
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		start, err := netip.ParseAddr(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start address %q", line, record[0])
+		}
+		end, err := netip.ParseAddr(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end address %q", line, record[1])
+		}
+		start, end = start.Unmap(), end.Unmap()
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, start, end)
+		}
+
+		lat, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid latitude %q", line, record[3])
+		}
+		lon, err := strconv.ParseFloat(record[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid longitude %q", line, record[4])
+		}
+
+		ranges = append(ranges, ipRange{
+			start: start,
+			end:   end,
+			location: dto.GeoLocation{
+				Country:   strings.ToUpper(record[2]),
+				Latitude:  lat,
+				Longitude: lon,
+			},
+		})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Less(ranges[j].start)
+	})
+	return ranges, nil
+}
+
+func (l *locator) Lookup(ip string) (*dto.GeoLocation, bool) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil, false
+	}
+	addr = addr.Unmap()
+
+	// The last range starting at or before addr is the only one that can
+	// contain it.
+	i := sort.Search(len(l.ranges), func(i int) bool {
+		return addr.Less(l.ranges[i].start)
+	}) - 1
+	if i < 0 {
+		return nil, false
+	}
+
+	r := l.ranges[i]
+	if r.start.Is4() != addr.Is4() || r.end.Less(addr) {
+		return nil, false
+	}
+	location := r.location
+	return &location, true
+}
@@ -0,0 +1,67 @@
+package hasher
+
+import (
+	"errors"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// multiHasher hashes new passwords with the preferred algorithm and verifies
+// stored hashes with whichever algorithm recognises their prefix, so hashes
+// can be migrated one successful login at a time.
+type multiHasher struct {
+	preferred srv.PasswordService
+	legacy    []srv.PasswordService
+}
+
+func NewMultiHasher(preferred srv.PasswordService, legacy ...srv.PasswordService) srv.PasswordService {
+	return &multiHasher{
+		preferred: preferred,
+		legacy:    legacy,
+	}
+}
+
+func (m *multiHasher) Hash(password string) (string, error) {
+	return m.preferred.Hash(password)
+}
+
+func (m *multiHasher) Verify(hashedPassword, password string) (bool, error) {
+	hasher := m.detect(hashedPassword)
+	if hasher == nil {
+		return false, ErrUnknownHashFormat
+	}
+	return hasher.Verify(hashedPassword, password)
+}
+
+func (m *multiHasher) Supports(hashedPassword string) bool {
+	return m.detect(hashedPassword) != nil
+}
+
+func (m *multiHasher) Validate(hashedPassword string) error {
+	hasher := m.detect(hashedPassword)
+	if hasher == nil {
+		return ErrUnknownHashFormat
+	}
+	return hasher.Validate(hashedPassword)
+}
+
+func (m *multiHasher) NeedsRehash(hashedPassword string) bool {
+	if !m.preferred.Supports(hashedPassword) {
+		return true
+	}
+	return m.preferred.NeedsRehash(hashedPassword)
+}
+
+func (m *multiHasher) detect(hashedPassword string) srv.PasswordService {
+	if m.preferred.Supports(hashedPassword) {
+		return m.preferred
+	}
+	for _, hasher := range m.legacy {
+		if hasher.Supports(hashedPassword) {
+			return hasher
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package ipaccess
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	repo "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/repository"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"go.uber.org/zap"
+)
+
+type subjectRules struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+type accessList struct {
+	repo    repo.Repository
+	refresh time.Duration
+	logger  *zap.Logger
+
+	mu       sync.RWMutex
+	rules    map[dto.IPSubject]*subjectRules
+	loadedAt time.Time
+}
+
+// NewIPAccessList serves the IP rules from memory, reloading them from the
+// database every refresh. Invalidate only reaches this replica; the others
+// pick a change up on their next reload.
+func NewIPAccessList(repo repo.Repository, refresh time.Duration, logger *zap.Logger) srv.IPAccessList {
+	return &accessList{
+		repo:    repo,
+		refresh: refresh,
+		logger:  logger,
+	}
+}
+
+func (a *accessList) Allowed(ctx context.Context, ip string, subjects ...dto.IPSubject) (bool, error) {
+	rules, err := a.load(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(rules) == 0 {
+		return true, nil
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		// Without a usable address only subjects without rules may pass.
+		for _, subject := range subjects {
+			if _, ok := rules[subject]; ok {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	addr = addr.Unmap()
+
+	for _, subject := range subjects {
+		r, ok := rules[subject]
+		if !ok {
+			continue
+		}
+		if containsAddr(r.deny, addr) {
+			return false, nil
+		}
+		if len(r.allow) > 0 && !containsAddr(r.allow, addr) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (a *accessList) Invalidate() {
+	a.mu.Lock()
+	a.loadedAt = time.Time{}
+	a.mu.Unlock()
+}
+
+// load returns the cached rules, reloading them when they are stale. If the
+// database cannot be reached the stale rules stay in force.
+func (a *accessList) load(ctx context.Context) (map[dto.IPSubject]*subjectRules, error) {
+	a.mu.RLock()
+	rules, loadedAt := a.rules, a.loadedAt
+	a.mu.RUnlock()
+	if !loadedAt.IsZero() && time.Since(loadedAt) < a.refresh {
+		return rules, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.loadedAt.IsZero() && time.Since(a.loadedAt) < a.refresh {
+		return a.rules, nil
+	}
+
+	entries, err := a.repo.ListIPRules(ctx)
+	if err != nil {
+		if a.rules != nil {
+			a.logger.Error("Failed to reload ip rules, keeping the previous set", zap.Error(err))
+			return a.rules, nil
+		}
+		return nil, err
+	}
+
+	a.rules = buildRules(entries, a.logger)
+	a.loadedAt = time.Now()
+	return a.rules, nil
+}
+
+func buildRules(entries []*entity.IPRule, logger *zap.Logger) map[dto.IPSubject]*subjectRules {
+	rules := make(map[dto.IPSubject]*subjectRules)
+	for _, entry := range entries {
+		prefix, err := netip.ParsePrefix(entry.CIDR)
+		if err != nil {
+			logger.Warn("Skipping ip rule with invalid cidr", zap.Uint("id", entry.ID), zap.String("cidr", entry.CIDR))
+			continue
+		}
+
+		subject := dto.IPSubject{Type: entry.SubjectType, Value: entry.Subject}
+		r, ok := rules[subject]
+		if !ok {
+			r = &subjectRules{}
+			rules[subject] = r
+		}
+
+		switch entry.Action {
+		case entity.IPRuleAllow:
+			r.allow = append(r.allow, prefix.Masked())
+		case entity.IPRuleDeny:
+			r.deny = append(r.deny, prefix.Masked())
+		}
+	}
+	return rules
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
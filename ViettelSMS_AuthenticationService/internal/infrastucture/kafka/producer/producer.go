@@ -44,10 +44,14 @@ func (d messageBroker) Send(event mq.Message) error {
 		Value:   sarama.ByteEncoder(event.Body),
 		Headers: headers,
 	}
+	if event.Key != "" {
+		msg.Key = sarama.StringEncoder(event.Key)
+	}
 
+	// The body may carry password hashes, so only the envelope is logged.
 	d.logger.Info("Sending message to broker",
 		zap.String("topic", event.Topic),
-		zap.ByteString("body", event.Body),
+		zap.String("key", event.Key),
 	)
 
 	_, _, err := d.producer.SendMessage(msg)
@@ -0,0 +1,83 @@
+package pepper
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+)
+
+const minKeyLength = 32
+
+// LoadKeys reads the pepper keys from PASSWORD_PEPPER_FILE and
+// PASSWORD_PEPPER_KEYS. Both hold "VERSION:BASE64KEY" entries, one per line
+// in the file and comma separated in the env; the file wins on conflicts.
+// The current version defaults to the highest one. No keys means peppering is
+// disabled and a zero version is returned.
+func LoadKeys(cfg *config.Config) (map[int][]byte, int, error) {
+	keys := map[int][]byte{}
+
+	if err := parseKeys(keys, strings.Split(cfg.Password.PepperKeys, ",")); err != nil {
+		return nil, 0, fmt.Errorf("PASSWORD_PEPPER_KEYS: %w", err)
+	}
+
+	if cfg.Password.PepperFile != "" {
+		raw, err := os.ReadFile(cfg.Password.PepperFile)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read pepper file: %w", err)
+		}
+		if err := parseKeys(keys, strings.Split(string(raw), "\n")); err != nil {
+			return nil, 0, fmt.Errorf("%s: %w", cfg.Password.PepperFile, err)
+		}
+	}
+
+	if len(keys) == 0 {
+		return keys, 0, nil
+	}
+
+	current := cfg.Password.PepperVersion
+	if current == 0 {
+		for version := range keys {
+			current = max(current, version)
+		}
+	}
+	if _, ok := keys[current]; !ok {
+		return nil, 0, fmt.Errorf("%w: PASSWORD_PEPPER_VERSION=%d has no key", ErrUnknownPepperVersion, current)
+	}
+
+	return keys, current, nil
+}
+
+func parseKeys(keys map[int][]byte, entries []string) error {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		versionStr, encoded, ok := strings.Cut(entry, ":")
+		if !ok {
+			return errors.New("pepper key must be VERSION:BASE64KEY")
+		}
+
+		version, err := strconv.Atoi(strings.TrimSpace(versionStr))
+		if err != nil || version <= 0 {
+			return fmt.Errorf("invalid pepper version %q", versionStr)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return fmt.Errorf("pepper key %d is not valid base64: %w", version, err)
+		}
+		if len(key) < minKeyLength {
+			return fmt.Errorf("pepper key %d must be at least %d bytes", version, minKeyLength)
+		}
+
+		keys[version] = key
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package pepper
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+)
+
+func encodedKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), minKeyLength)))
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []string
+		versions []int
+		wantErr  bool
+	}{
+		{"single", []string{"1:" + encodedKey('a')}, []int{1}, false},
+		{"several", []string{"1:" + encodedKey('a'), "3:" + encodedKey('b')}, []int{1, 3}, false},
+		{"spaces, blanks and comments", []string{"  2 : " + encodedKey('a') + " ", "", "# retired: 1"}, []int{2}, false},
+
+		{"missing separator", []string{encodedKey('a')}, nil, true},
+		{"version not a number", []string{"v1:" + encodedKey('a')}, nil, true},
+		{"zero version", []string{"0:" + encodedKey('a')}, nil, true},
+		{"negative version", []string{"-1:" + encodedKey('a')}, nil, true},
+		{"not base64", []string{"1:not*base64"}, nil, true},
+		{"key too short", []string{"1:" + base64.StdEncoding.EncodeToString([]byte("short"))}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := map[int][]byte{}
+			err := parseKeys(keys, tt.entries)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("parseKeys accepted a malformed entry")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseKeys: %v", err)
+			}
+			if len(keys) != len(tt.versions) {
+				t.Fatalf("parseKeys found %d keys, want %d", len(keys), len(tt.versions))
+			}
+			for _, version := range tt.versions {
+				if len(keys[version]) != minKeyLength {
+					t.Errorf("key %d has %d bytes, want %d", version, len(keys[version]), minKeyLength)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pepper")
+	if err := os.WriteFile(file, []byte("# pepper keys\n2:"+encodedKey('f')+"\n3:"+encodedKey('g')+"\n"), 0o600); err != nil {
+		t.Fatalf("write pepper file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		keys    string
+		file    string
+		version int
+		current int
+		count   int
+		wantErr error
+	}{
+		{"disabled", "", "", 0, 0, 0, nil},
+		{"env only, highest is current", "1:" + encodedKey('a') + ",2:" + encodedKey('b'), "", 0, 2, 2, nil},
+		{"explicit current", "1:" + encodedKey('a') + ",2:" + encodedKey('b'), "", 1, 1, 2, nil},
+		{"file and env merged", "1:" + encodedKey('a'), file, 0, 3, 3, nil},
+		{"current without key", "1:" + encodedKey('a'), "", 2, 0, 0, ErrUnknownPepperVersion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Password.PepperKeys = tt.keys
+			cfg.Password.PepperFile = tt.file
+			cfg.Password.PepperVersion = tt.version
+
+			keys, current, err := LoadKeys(cfg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("LoadKeys error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadKeys: %v", err)
+			}
+			if current != tt.current || len(keys) != tt.count {
+				t.Errorf("LoadKeys = %d keys, current %d, want %d keys, current %d", len(keys), current, tt.count, tt.current)
+			}
+		})
+	}
+}
+
+func TestLoadKeysFileWins(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pepper")
+	if err := os.WriteFile(file, []byte("1:"+encodedKey('f')+"\n"), 0o600); err != nil {
+		t.Fatalf("write pepper file: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.Password.PepperKeys = "1:" + encodedKey('e')
+	cfg.Password.PepperFile = file
+
+	keys, _, err := LoadKeys(cfg)
+	if err != nil {
+		t.Fatalf("LoadKeys: %v", err)
+	}
+	if keys[1][0] != 'f' {
+		t.Error("the env key won over the file key for the same version")
+	}
+}
+
+func TestLoadKeysMissingFile(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Password.PepperFile = filepath.Join(t.TempDir(), "missing")
+	if _, _, err := LoadKeys(cfg); err == nil {
+		t.Error("LoadKeys accepted a missing pepper file")
+	}
+}
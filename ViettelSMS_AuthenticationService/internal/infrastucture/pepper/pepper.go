@@ -0,0 +1,118 @@
+package pepper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+// hashPrefix tags a peppered hash with the version of the key it was made
+// with: $pepper$v=2$<inner hash>
+const hashPrefix = "$pepper$v="
+
+var ErrUnknownPepperVersion = errors.New("unknown pepper version")
+
+// pepperedService runs the password through HMAC-SHA256 with a secret kept
+// outside the database before handing it to the wrapped service, so a leaked
+// auth_users table cannot be cracked offline without the key as well.
+// Hashes made before peppering was enabled, or with an older key, still
+// verify and are reported by NeedsRehash so they are re-peppered on login.
+type pepperedService struct {
+	inner   srv.PasswordService
+	keys    map[int][]byte
+	current int
+}
+
+func NewPepperedService(inner srv.PasswordService, keys map[int][]byte, current int) (srv.PasswordService, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("%w: current version %d has no key", ErrUnknownPepperVersion, current)
+	}
+	return &pepperedService{
+		inner:   inner,
+		keys:    keys,
+		current: current,
+	}, nil
+}
+
+func (p *pepperedService) Hash(password string) (string, error) {
+	hashedPassword, err := p.inner.Hash(p.pepper(p.keys[p.current], password))
+	if err != nil {
+		return "", err
+	}
+	return hashPrefix + strconv.Itoa(p.current) + hashedPassword, nil
+}
+
+func (p *pepperedService) Verify(hashedPassword, password string) (bool, error) {
+	version, inner, peppered := split(hashedPassword)
+	if !peppered {
+		return p.inner.Verify(hashedPassword, password)
+	}
+
+	key, ok := p.keys[version]
+	if !ok {
+		return false, fmt.Errorf("%w: %d", ErrUnknownPepperVersion, version)
+	}
+	return p.inner.Verify(inner, p.pepper(key, password))
+}
+
+func (p *pepperedService) Supports(hashedPassword string) bool {
+	version, inner, peppered := split(hashedPassword)
+	if !peppered {
+		return p.inner.Supports(hashedPassword)
+	}
+	_, ok := p.keys[version]
+	return ok && p.inner.Supports(inner)
+}
+
+func (p *pepperedService) Validate(hashedPassword string) error {
+	version, inner, peppered := split(hashedPassword)
+	if !peppered {
+		return p.inner.Validate(hashedPassword)
+	}
+	if _, ok := p.keys[version]; !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownPepperVersion, version)
+	}
+	return p.inner.Validate(inner)
+}
+
+func (p *pepperedService) NeedsRehash(hashedPassword string) bool {
+	version, inner, peppered := split(hashedPassword)
+	if !peppered || version != p.current {
+		return true
+	}
+	return p.inner.NeedsRehash(inner)
+}
+
+// pepper returns the HMAC as base64 so the inner service gets a short
+// printable string; bcrypt ignores anything past 72 bytes.
+func (p *pepperedService) pepper(key []byte, password string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(password))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// split separates "$pepper$v=N" from the inner hash, which keeps its own
+// leading "$".
+func split(hashedPassword string) (int, string, bool) {
+	rest, ok := strings.CutPrefix(hashedPassword, hashPrefix)
+	if !ok {
+		return 0, "", false
+	}
+
+	end := strings.IndexByte(rest, '$')
+	if end <= 0 {
+		return 0, "", false
+	}
+
+	version, err := strconv.Atoi(rest[:end])
+	if err != nil {
+		return 0, "", false
+	}
+	return version, rest[end:], true
+}
@@ -0,0 +1,215 @@
+package pepper
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+const fakePrefix = "$fake$"
+
+var errFakeHash = errors.New("not a fake hash")
+
+// fakeHasher "hashes" by prefixing, so tests can see exactly what the
+// decorator passed down. It never asks for a rehash itself.
+type fakeHasher struct{}
+
+func (fakeHasher) Hash(password string) (string, error) { return fakePrefix + password, nil }
+
+func (fakeHasher) Verify(hashedPassword, password string) (bool, error) {
+	inner, ok := strings.CutPrefix(hashedPassword, fakePrefix)
+	if !ok {
+		return false, errFakeHash
+	}
+	return inner == password, nil
+}
+
+func (fakeHasher) Supports(hashedPassword string) bool {
+	return strings.HasPrefix(hashedPassword, fakePrefix)
+}
+
+func (fakeHasher) Validate(hashedPassword string) error {
+	if !strings.HasPrefix(hashedPassword, fakePrefix) {
+		return errFakeHash
+	}
+	return nil
+}
+
+func (fakeHasher) NeedsRehash(string) bool { return false }
+
+var testKeys = map[int][]byte{
+	1: bytes.Repeat([]byte{1}, minKeyLength),
+	2: bytes.Repeat([]byte{2}, minKeyLength),
+}
+
+func newTestService(t *testing.T, current int) srv.PasswordService {
+	t.Helper()
+	p, err := NewPepperedService(fakeHasher{}, testKeys, current)
+	if err != nil {
+		t.Fatalf("NewPepperedService: %v", err)
+	}
+	return p
+}
+
+func TestRoundTrip(t *testing.T) {
+	p := newTestService(t, 2)
+
+	hashed, err := p.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hashed, "$pepper$v=2$fake$") {
+		t.Fatalf("Hash = %q, want the current version and the inner hash", hashed)
+	}
+	if strings.Contains(hashed, "correct horse") {
+		t.Fatalf("Hash = %q passed the plain password to the inner service", hashed)
+	}
+
+	if ok, err := p.Verify(hashed, "correct horse"); err != nil || !ok {
+		t.Errorf("Verify(right password) = %v, %v", ok, err)
+	}
+	if ok, err := p.Verify(hashed, "wrong horse"); err != nil || ok {
+		t.Errorf("Verify(wrong password) = %v, %v", ok, err)
+	}
+	if !p.Supports(hashed) {
+		t.Error("Supports rejected its own hash")
+	}
+	if err := p.Validate(hashed); err != nil {
+		t.Errorf("Validate rejected its own hash: %v", err)
+	}
+	if p.NeedsRehash(hashed) {
+		t.Error("NeedsRehash on a hash with the current version")
+	}
+}
+
+func TestVersions(t *testing.T) {
+	old := newTestService(t, 1)
+	current := newTestService(t, 2)
+
+	oldHash, err := old.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	currentHash, err := current.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if strings.TrimPrefix(oldHash, "$pepper$v=1") == strings.TrimPrefix(currentHash, "$pepper$v=2") {
+		t.Fatal("two pepper keys produced the same inner hash")
+	}
+
+	// An older version still verifies with its own key, and asks to be
+	// re-peppered with the current one.
+	if ok, err := current.Verify(oldHash, "correct horse"); err != nil || !ok {
+		t.Errorf("Verify(older version) = %v, %v", ok, err)
+	}
+	if !current.NeedsRehash(oldHash) {
+		t.Error("NeedsRehash = false for an older pepper version")
+	}
+
+	// Relabelling a hash with another version must not verify.
+	relabelled := "$pepper$v=1" + strings.TrimPrefix(currentHash, "$pepper$v=2")
+	if ok, err := current.Verify(relabelled, "correct horse"); err != nil || ok {
+		t.Errorf("Verify(hash under the wrong version) = %v, %v", ok, err)
+	}
+}
+
+func TestUnknownVersion(t *testing.T) {
+	p := newTestService(t, 2)
+	hashed := "$pepper$v=3$fake$whatever"
+
+	if _, err := p.Verify(hashed, "correct horse"); !errors.Is(err, ErrUnknownPepperVersion) {
+		t.Errorf("Verify error = %v, want ErrUnknownPepperVersion", err)
+	}
+	if err := p.Validate(hashed); !errors.Is(err, ErrUnknownPepperVersion) {
+		t.Errorf("Validate error = %v, want ErrUnknownPepperVersion", err)
+	}
+	if p.Supports(hashed) {
+		t.Error("Supports accepted an unknown pepper version")
+	}
+	if !p.NeedsRehash(hashed) {
+		t.Error("NeedsRehash = false for an unknown pepper version")
+	}
+}
+
+func TestUnpepperedHash(t *testing.T) {
+	p := newTestService(t, 2)
+	hashed := fakePrefix + "correct horse"
+
+	if ok, err := p.Verify(hashed, "correct horse"); err != nil || !ok {
+		t.Errorf("Verify(unpeppered) = %v, %v", ok, err)
+	}
+	if !p.Supports(hashed) {
+		t.Error("Supports rejected an unpeppered hash of the inner format")
+	}
+	if err := p.Validate(hashed); err != nil {
+		t.Errorf("Validate(unpeppered) = %v", err)
+	}
+	if !p.NeedsRehash(hashed) {
+		t.Error("NeedsRehash = false for an unpeppered hash")
+	}
+}
+
+// TestMalformedPrefix checks that a broken "$pepper$" tag is not read as a
+// version: the whole string goes to the inner service, which rejects it.
+func TestMalformedPrefix(t *testing.T) {
+	p := newTestService(t, 2)
+
+	tests := []string{
+		"$pepper$v=",
+		"$pepper$v=2",
+		"$pepper$v=$fake$x",
+		"$pepper$v=two$fake$x",
+		"$pepper$v=2x$fake$x",
+		"$pepper$2$fake$x",
+		"$pepper$$fake$x",
+	}
+	for _, hashed := range tests {
+		t.Run(hashed, func(t *testing.T) {
+			if _, _, peppered := split(hashed); peppered {
+				t.Errorf("split(%q) found a version", hashed)
+			}
+			if _, err := p.Verify(hashed, "x"); !errors.Is(err, errFakeHash) {
+				t.Errorf("Verify error = %v, want the inner service's error", err)
+			}
+			if p.Supports(hashed) {
+				t.Error("Supports accepted a malformed pepper tag")
+			}
+			if err := p.Validate(hashed); err == nil {
+				t.Error("Validate accepted a malformed pepper tag")
+			}
+			if !p.NeedsRehash(hashed) {
+				t.Error("NeedsRehash = false for a malformed pepper tag")
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		hashed   string
+		version  int
+		inner    string
+		peppered bool
+	}{
+		{"$pepper$v=2$fake$x", 2, "$fake$x", true},
+		{"$pepper$v=10$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5", 10, "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5", true},
+		{"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5", 0, "", false},
+		{"", 0, "", false},
+	}
+	for _, tt := range tests {
+		version, inner, peppered := split(tt.hashed)
+		if version != tt.version || inner != tt.inner || peppered != tt.peppered {
+			t.Errorf("split(%q) = %d, %q, %v, want %d, %q, %v", tt.hashed, version, inner, peppered, tt.version, tt.inner, tt.peppered)
+		}
+	}
+}
+
+func TestNewPepperedServiceRequiresCurrentKey(t *testing.T) {
+	if _, err := NewPepperedService(fakeHasher{}, testKeys, 3); !errors.Is(err, ErrUnknownPepperVersion) {
+		t.Errorf("NewPepperedService error = %v, want ErrUnknownPepperVersion", err)
+	}
+}
@@ -0,0 +1,149 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+const (
+	RuleMinLength      = "min_length"
+	RuleMaxLength      = "max_length"
+	RuleCharClasses    = "character_classes"
+	RuleMaxRepeat      = "max_repeat"
+	RuleBannedWord     = "banned_word"
+	RuleUsername       = "username"
+	RuleStrengthScore  = "strength_score"
+	minBannedWordMatch = 3
+)
+
+type passwordPolicy struct {
+	cfg         config.PasswordPolicy
+	bannedWords []string
+}
+
+func NewPasswordPolicy(cfg *config.Config) srv.PasswordPolicy {
+	bannedWords := make([]string, 0, len(cfg.PasswordPolicy.BannedWords))
+	for _, word := range cfg.PasswordPolicy.BannedWords {
+		if word = strings.ToLower(strings.TrimSpace(word)); len(word) >= minBannedWordMatch {
+			bannedWords = append(bannedWords, word)
+		}
+	}
+
+	return &passwordPolicy{
+		cfg:         cfg.PasswordPolicy,
+		bannedWords: bannedWords,
+	}
+}
+
+func (p *passwordPolicy) Validate(username, password string) []dto.PolicyViolation {
+	violations := []dto.PolicyViolation{}
+	length := len([]rune(password))
+
+	if length < p.cfg.MinLength {
+		violations = append(violations, dto.PolicyViolation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("must be at least %d characters long", p.cfg.MinLength),
+		})
+	}
+
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		violations = append(violations, dto.PolicyViolation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("must be at most %d characters long", p.cfg.MaxLength),
+		})
+	}
+
+	if classes := countCharClasses(password); classes < p.cfg.MinCharClasses {
+		violations = append(violations, dto.PolicyViolation{
+			Rule:    RuleCharClasses,
+			Message: fmt.Sprintf("must mix at least %d of: lowercase, uppercase, digits, symbols", p.cfg.MinCharClasses),
+		})
+	}
+
+	if p.cfg.MaxRepeat > 0 && longestRepeat(password) > p.cfg.MaxRepeat {
+		violations = append(violations, dto.PolicyViolation{
+			Rule:    RuleMaxRepeat,
+			Message: fmt.Sprintf("must not repeat the same character more than %d times in a row", p.cfg.MaxRepeat),
+		})
+	}
+
+	normalized := normalize(password)
+
+	if username != "" && len(username) >= minBannedWordMatch && strings.Contains(normalized, strings.ToLower(username)) {
+		violations = append(violations, dto.PolicyViolation{
+			Rule:    RuleUsername,
+			Message: "must not contain the username",
+		})
+	}
+
+	for _, word := range p.bannedWords {
+		if strings.Contains(normalized, word) {
+			violations = append(violations, dto.PolicyViolation{
+				Rule:    RuleBannedWord,
+				Message: fmt.Sprintf("must not contain the word %q", word),
+			})
+			break
+		}
+	}
+
+	if score := Score(password, username); score < p.cfg.MinScore {
+		violations = append(violations, dto.PolicyViolation{
+			Rule:    RuleStrengthScore,
+			Message: fmt.Sprintf("is too easy to guess (strength %d of 4, need %d)", score, p.cfg.MinScore),
+		})
+	}
+
+	return violations
+}
+
+func countCharClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	return lower + upper + digit + symbol
+}
+
+func longestRepeat(password string) int {
+	longest, current := 0, 0
+	var prev rune
+	for i, r := range []rune(password) {
+		if i > 0 && r == prev {
+			current++
+		} else {
+			current = 1
+		}
+		prev = r
+		longest = max(longest, current)
+	}
+	return longest
+}
+
+// leetReplacer undoes the common character substitutions so "P@ssw0rd"
+// still matches "password".
+var leetReplacer = strings.NewReplacer(
+	"@", "a", "4", "a",
+	"3", "e",
+	"1", "i", "!", "i",
+	"0", "o",
+	"$", "s", "5", "s",
+	"7", "t",
+)
+
+func normalize(password string) string {
+	return leetReplacer.Replace(strings.ToLower(password))
+}
@@ -0,0 +1,126 @@
+package policy
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+func newTestPolicy(minScore int) srv.PasswordPolicy {
+	cfg := &config.Config{}
+	cfg.PasswordPolicy = config.PasswordPolicy{
+		MinLength:      8,
+		MaxLength:      64,
+		MinCharClasses: 3,
+		MaxRepeat:      3,
+		// "ab" is too short to match and is dropped.
+		BannedWords: []string{"viettel", "sms", "  Acme ", "ab"},
+		MinScore:    minScore,
+	}
+	return NewPasswordPolicy(cfg)
+}
+
+func TestValidate(t *testing.T) {
+	p := newTestPolicy(0)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		want     []string
+	}{
+		{"acceptable", "alice", "Str0ng-Enough", nil},
+		{"too short", "alice", "Sh0rt!", []string{RuleMinLength}},
+		{"exactly min length", "alice", "Sh0rt!-x", nil},
+		{"too long", "alice", strings.Repeat("aB3-", 17), []string{RuleMaxLength}},
+		{"length counts runes", "alice", "Đủ-8ký-tự", nil},
+		{"one character class", "alice", "alllowercase", []string{RuleCharClasses}},
+		{"two character classes", "alice", "lower-and-dash", []string{RuleCharClasses}},
+		{"repeat over limit", "alice", "Aaaa1111!", []string{RuleMaxRepeat}},
+		{"repeat at limit", "alice", "Aaaa111!x", nil},
+		{"contains username", "alice", "xAlice-99", []string{RuleUsername}},
+		{"contains username in leet", "alice", "x@l1ce-99Q", []string{RuleUsername}},
+		{"short username ignored", "al", "Al-pha-9x", nil},
+		{"banned word", "alice", "MyViettel-9", []string{RuleBannedWord}},
+		{"banned word in leet", "alice", "V1ett3l-2024", []string{RuleBannedWord}},
+		{"banned word trimmed and lowered", "alice", "ACME-rocks-1", []string{RuleBannedWord}},
+		{"banned word reported once", "alice", "Viettel-sms-9", []string{RuleBannedWord}},
+		{"short banned word dropped", "alice", "Fab-ulous-1", nil},
+		{"several rules", "alice", "alice", []string{RuleMinLength, RuleCharClasses, RuleUsername}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, violation := range p.Validate(tt.username, tt.password) {
+				if violation.Message == "" {
+					t.Errorf("violation %s has no message", violation.Rule)
+				}
+				got = append(got, violation.Rule)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Validate(%q, %q) = %q, want %q", tt.username, tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateStrength(t *testing.T) {
+	p := newTestPolicy(3)
+
+	for _, password := range []string{"Password1!", "Qwerty-1234", "Summer2024!"} {
+		violations := p.Validate("alice", password)
+		if !slices.ContainsFunc(violations, func(v dto.PolicyViolation) bool { return v.Rule == RuleStrengthScore }) {
+			t.Errorf("Validate(%q) did not flag a guessable password", password)
+		}
+	}
+
+	if violations := p.Validate("alice", "x7#Kq9!vLm2@Wp4"); len(violations) != 0 {
+		t.Errorf("Validate(random) = %+v, want no violations", violations)
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		password string
+		username string
+		max      int
+		min      int
+	}{
+		{"", "", 0, 0},
+		{"password", "", 0, 0},
+		{"12345678", "", 0, 0},
+		{"qwertyuiop", "", 1, 0},
+		{"aaaaaaaaaaaa", "", 1, 0},
+		{"alice2024", "alice", 2, 0},
+		{"x7#Kq9!vLm2@Wp4", "", 4, 4},
+		{"correct-horse-battery-staple", "", 4, 3},
+	}
+	for _, tt := range tests {
+		if got := Score(tt.password, tt.username); got < tt.min || got > tt.max {
+			t.Errorf("Score(%q, %q) = %d, want %d..%d", tt.password, tt.username, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestLongestRepeat(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abc", 1},
+		{"aabbbc", 3},
+		{"ééé", 3},
+		{"abbbbba", 5},
+	}
+	for _, tt := range tests {
+		if got := longestRepeat(tt.password); got != tt.want {
+			t.Errorf("longestRepeat(%q) = %d, want %d", tt.password, got, tt.want)
+		}
+	}
+}
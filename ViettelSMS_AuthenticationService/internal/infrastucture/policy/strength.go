@@ -0,0 +1,231 @@
+package policy
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Score estimates how hard a password is to guess on the zxcvbn 0-4 scale.
+// Like zxcvbn it splits the password into the cheapest sequence of guessable
+// patterns (dictionary words, keyboard walks, sequences, repeats, years) and
+// falls back to brute force for whatever is left; the score follows from the
+// estimated number of guesses.
+func Score(password, username string) int {
+	guesses := estimateGuessesLog10(password, username)
+
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	default:
+		return 4
+	}
+}
+
+const (
+	bruteforceCardinality = 10
+	minPatternLength      = 3
+)
+
+type match struct {
+	end     int
+	guesses float64 // log10
+}
+
+func estimateGuessesLog10(password, username string) float64 {
+	runes := []rune(password)
+	n := len(runes)
+	if n == 0 {
+		return 0
+	}
+
+	lower := []rune(strings.ToLower(password))
+	leet := []rune(normalize(password))
+
+	dictionary := commonWords
+	if username != "" {
+		dictionary = append([]string{strings.ToLower(username)}, commonWords...)
+	}
+
+	// best[i] is the cheapest log10 guess count for runes[:i].
+	best := make([]float64, n+1)
+	for i := 1; i <= n; i++ {
+		best[i] = math.Inf(1)
+	}
+
+	for start := 0; start < n; start++ {
+		if math.IsInf(best[start], 1) {
+			continue
+		}
+
+		candidates := []match{{end: start + 1, guesses: math.Log10(bruteforceCardinality)}}
+		candidates = append(candidates, dictionaryMatches(runes, lower, leet, start, dictionary)...)
+		candidates = append(candidates, sequenceMatches(lower, start)...)
+		candidates = append(candidates, repeatMatches(lower, start)...)
+		candidates = append(candidates, keyboardMatches(lower, start)...)
+		candidates = append(candidates, yearMatches(lower, start)...)
+
+		for _, m := range candidates {
+			if cost := best[start] + m.guesses; cost < best[m.end] {
+				best[m.end] = cost
+			}
+		}
+	}
+
+	return best[n]
+}
+
+func dictionaryMatches(runes, lower, leet []rune, start int, dictionary []string) []match {
+	matches := []match{}
+	for rank, word := range dictionary {
+		w := []rune(word)
+		end := start + len(w)
+		if len(w) < minPatternLength || end > len(lower) {
+			continue
+		}
+
+		plain := string(lower[start:end]) == word
+		substituted := !plain && string(leet[start:end]) == word
+		if !plain && !substituted {
+			continue
+		}
+
+		guesses := math.Log10(float64(rank + 1))
+		if hasUpper(runes[start:end]) {
+			guesses += math.Log10(2)
+		}
+		if substituted {
+			guesses += math.Log10(2)
+		}
+		matches = append(matches, match{end: end, guesses: guesses})
+	}
+	return matches
+}
+
+// sequenceMatches finds runs with a constant step of one, such as "abcd" or "9876".
+func sequenceMatches(lower []rune, start int) []match {
+	matches := []match{}
+	if start+1 >= len(lower) {
+		return matches
+	}
+
+	delta := lower[start+1] - lower[start]
+	if delta != 1 && delta != -1 {
+		return matches
+	}
+
+	end := start + 2
+	for end < len(lower) && lower[end]-lower[end-1] == delta {
+		end++
+	}
+
+	base := 26.0
+	if unicode.IsDigit(lower[start]) {
+		base = 10
+	}
+	if lower[start] == 'a' || lower[start] == '1' || lower[start] == 'z' || lower[start] == '9' {
+		base = 4
+	}
+	if delta < 0 {
+		base *= 2
+	}
+
+	for e := start + minPatternLength; e <= end; e++ {
+		matches = append(matches, match{end: e, guesses: math.Log10(base * float64(e-start))})
+	}
+	return matches
+}
+
+func repeatMatches(lower []rune, start int) []match {
+	matches := []match{}
+	end := start + 1
+	for end < len(lower) && lower[end] == lower[start] {
+		end++
+	}
+
+	for e := start + minPatternLength; e <= end; e++ {
+		matches = append(matches, match{end: e, guesses: math.Log10(bruteforceCardinality * float64(e-start))})
+	}
+	return matches
+}
+
+var keyboardRows = []string{
+	"`1234567890-=",
+	"qwertyuiop[]\\",
+	"asdfghjkl;'",
+	"zxcvbnm,./",
+	"qazwsxedcrfvtgbyhnujmikolp",
+}
+
+func keyboardMatches(lower []rune, start int) []match {
+	matches := []match{}
+	for _, row := range keyboardRows {
+		for _, layout := range []string{row, reverse(row)} {
+			for end := start + minPatternLength; end <= len(lower); end++ {
+				if !strings.Contains(layout, string(lower[start:end])) {
+					break
+				}
+				matches = append(matches, match{end: end, guesses: math.Log10(20 * float64(end-start))})
+			}
+		}
+	}
+	return matches
+}
+
+func yearMatches(lower []rune, start int) []match {
+	if start+4 > len(lower) {
+		return nil
+	}
+	year := string(lower[start : start+4])
+	if (strings.HasPrefix(year, "19") || strings.HasPrefix(year, "20")) && isDigits(year) {
+		return []match{{end: start + 4, guesses: math.Log10(120)}}
+	}
+	return nil
+}
+
+func hasUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// commonWords is ordered roughly by frequency in leaked password corpora;
+// a word's rank is its guess count.
+var commonWords = []string{
+	"password", "123456", "qwerty", "admin", "welcome", "letmein", "monkey",
+	"dragon", "master", "login", "abc123", "iloveyou", "sunshine", "princess",
+	"football", "baseball", "shadow", "superman", "trustno", "secret",
+	"passw", "hello", "freedom", "whatever", "michael", "charlie", "jordan",
+	"hunter", "ranger", "buster", "soccer", "hockey", "killer", "george",
+	"summer", "winter", "spring", "autumn", "love", "pass", "root", "toor",
+	"user", "guest", "test", "default", "changeme", "server", "system",
+	"oracle", "linux", "ubuntu", "windows", "viettel", "vietnam", "hanoi",
+	"saigon", "matkhau", "anhyeuem", "iloveu", "computer", "internet",
+	"company", "office", "manager", "support", "service", "network",
+}
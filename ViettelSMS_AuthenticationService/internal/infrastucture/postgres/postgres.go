@@ -24,6 +24,9 @@ func NewPostgresDB(cfg *config.Config, logger *zap.Logger) (DBEngine, error) {
 
 	gormDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		Logger: gormLogger.Default.LogMode(gormLogger.Info),
+		// Report constraint violations as gorm.ErrDuplicatedKey and friends
+		// rather than driver specific errors.
+		TranslateError: true,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
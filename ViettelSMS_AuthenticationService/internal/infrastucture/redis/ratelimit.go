@@ -0,0 +1,81 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+const rateLimitKeyPrefix = "auth:ratelimit:"
+
+// gcraScript implements the generic cell rate algorithm. The only state is the
+// theoretical arrival time (TAT) of the next request, in milliseconds of the
+// Redis clock, so every replica sees the same limit without clock skew.
+//
+//	ARGV[1] emission interval in ms (period / rate)
+//	ARGV[2] burst
+//
+// Returns {allowed, remaining, retry_after_ms, reset_after_ms}.
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local tat = tonumber(redis.call('GET', KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission
+local allow_at = new_tat - emission * burst
+local diff = now - allow_at
+
+if diff < 0 then
+	return {0, 0, allow_at - now, tat - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', new_tat - now)
+return {1, math.floor(diff / emission), 0, new_tat - now}
+`)
+
+type rateLimiter struct {
+	cache CacheEngine
+}
+
+func NewRateLimiter(cache CacheEngine) srv.RateLimiter {
+	return &rateLimiter{cache: cache}
+}
+
+func (r *rateLimiter) Allow(ctx context.Context, key string, limit dto.RateLimit) (*dto.RateLimitResult, error) {
+	if limit.Rate <= 0 || limit.Period <= 0 {
+		return nil, fmt.Errorf("invalid rate limit %d/%s", limit.Rate, limit.Period)
+	}
+
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Rate
+	}
+	emission := max(limit.Period.Milliseconds()/int64(limit.Rate), 1)
+
+	values, err := gcraScript.Run(ctx, r.cache.GetCache(), []string{rateLimitKeyPrefix + key}, emission, burst).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply rate limit: %w", err)
+	}
+	if len(values) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit reply %v", values)
+	}
+
+	return &dto.RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      burst,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		ResetAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
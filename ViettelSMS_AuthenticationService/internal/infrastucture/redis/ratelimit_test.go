@@ -0,0 +1,96 @@
+package rdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+)
+
+type testCache struct{ client *redis.Client }
+
+func (c testCache) GetCache() *redis.Client { return c.client }
+
+func newTestLimiter(t *testing.T) (*rateLimiter, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return &rateLimiter{cache: testCache{client}}, mr
+}
+
+func TestGCRABurstThenDeny(t *testing.T) {
+	limiter, mr := newTestLimiter(t)
+	mr.SetTime(time.Unix(1_700_000_000, 0))
+	ctx := context.Background()
+	limit := dto.RateLimit{Rate: 6, Period: time.Minute, Burst: 3}
+
+	for i, wantRemaining := range []int{2, 1, 0} {
+		result, err := limiter.Allow(ctx, "login:ip:10.0.0.1", limit)
+		if err != nil {
+			t.Fatalf("Allow #%d: %v", i+1, err)
+		}
+		if !result.Allowed || result.Remaining != wantRemaining || result.Limit != 3 {
+			t.Fatalf("Allow #%d = %+v, want allowed with %d remaining of 3", i+1, result, wantRemaining)
+		}
+	}
+
+	result, err := limiter.Allow(ctx, "login:ip:10.0.0.1", limit)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	// One request is earned back every emission interval, 10s.
+	if result.Allowed || result.RetryAfter != 10*time.Second || result.ResetAfter != 30*time.Second {
+		t.Fatalf("Allow over burst = %+v, want denied, retry after 10s, reset after 30s", result)
+	}
+
+	// Other keys have their own budget.
+	if result, err := limiter.Allow(ctx, "login:ip:10.0.0.2", limit); err != nil || !result.Allowed {
+		t.Fatalf("Allow(other key) = %+v, %v", result, err)
+	}
+
+	mr.SetTime(time.Unix(1_700_000_010, 0))
+	result, err = limiter.Allow(ctx, "login:ip:10.0.0.1", limit)
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("Allow after one interval = %+v, want allowed with 0 remaining", result)
+	}
+}
+
+func TestGCRADefaultsBurstToRate(t *testing.T) {
+	limiter, mr := newTestLimiter(t)
+	mr.SetTime(time.Unix(1_700_000_000, 0))
+
+	result, err := limiter.Allow(context.Background(), "k", dto.RateLimit{Rate: 5, Period: time.Minute})
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if result.Limit != 5 || result.Remaining != 4 {
+		t.Errorf("Allow = %+v, want limit 5 with 4 remaining", result)
+	}
+	if ttl := mr.TTL(rateLimitKeyPrefix + "k"); ttl <= 0 || ttl > 12*time.Second {
+		t.Errorf("key TTL = %s, want one emission interval", ttl)
+	}
+}
+
+func TestGCRARejectsInvalidLimits(t *testing.T) {
+	limiter, _ := newTestLimiter(t)
+	for _, limit := range []dto.RateLimit{{Rate: 0, Period: time.Minute}, {Rate: 1, Period: 0}} {
+		if _, err := limiter.Allow(context.Background(), "k", limit); err == nil {
+			t.Errorf("Allow accepted %+v", limit)
+		}
+	}
+}
+
+func TestGCRAUnavailable(t *testing.T) {
+	limiter, mr := newTestLimiter(t)
+	mr.Close()
+	if _, err := limiter.Allow(context.Background(), "k", dto.RateLimit{Rate: 1, Period: time.Minute}); err == nil {
+		t.Error("Allow succeeded without Redis")
+	}
+}
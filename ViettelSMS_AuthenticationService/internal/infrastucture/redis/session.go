@@ -0,0 +1,77 @@
+package rdb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/pkg/authclient"
+)
+
+const (
+	// Other services check revocation against the same keys.
+	sessionKeyPrefix      = authclient.SessionKeyPrefix
+	userSessionsKeyPrefix = "auth:user_sessions:"
+)
+
+type sessionStore struct {
+	cache CacheEngine
+}
+
+func NewSessionStore(cache CacheEngine) srv.SessionStore {
+	return &sessionStore{cache: cache}
+}
+
+func (s *sessionStore) Create(ctx context.Context, userID uint, ttl time.Duration) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	sessionID := base64.RawURLEncoding.EncodeToString(buf)
+
+	userKey := userSessionsKey(userID)
+	pipe := s.cache.GetCache().TxPipeline()
+	pipe.Set(ctx, sessionKeyPrefix+sessionID, userID, ttl)
+	pipe.SAdd(ctx, userKey, sessionID)
+	pipe.Expire(ctx, userKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return sessionID, nil
+}
+
+func (s *sessionStore) Exists(ctx context.Context, sessionID string) (bool, error) {
+	n, err := s.cache.GetCache().Exists(ctx, sessionKeyPrefix+sessionID).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *sessionStore) RevokeAll(ctx context.Context, userID uint, exceptSessionID string) error {
+	userKey := userSessionsKey(userID)
+	sessionIDs, err := s.cache.GetCache().SMembers(ctx, userKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	pipe := s.cache.GetCache().TxPipeline()
+	for _, sessionID := range sessionIDs {
+		if sessionID == exceptSessionID {
+			continue
+		}
+		pipe.Del(ctx, sessionKeyPrefix+sessionID)
+		pipe.SRem(ctx, userKey, sessionID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return nil
+}
+
+func userSessionsKey(userID uint) string {
+	return fmt.Sprintf("%s%d", userSessionsKeyPrefix, userID)
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func (r *repository) ListGroups(ctx context.Context) ([]*entity.Group, error) {
+	var groups []*entity.Group
+	if err := r.db.GetDB().WithContext(ctx).
+		Preload("Scopes").
+		Preload("Roles").
+		Preload("Parents").
+		Order("name").
+		Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *repository) GetGroupByName(ctx context.Context, name string) (*entity.Group, error) {
+	var group entity.Group
+	if err := r.db.GetDB().WithContext(ctx).
+		Preload("Scopes").
+		Preload("Roles").
+		Preload("Parents").
+		First(&group, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *repository) CreateGroup(ctx context.Context, group *entity.Group) error {
+	if err := r.db.GetDB().WithContext(ctx).Create(group).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateGroup saves the group and replaces its scopes, roles and parents.
+// Every group row is locked and the groups are reread before checkNesting
+// runs, so two updates nesting groups in each other are checked one after
+// the other and cannot form a cycle together.
+func (r *repository) UpdateGroup(ctx context.Context, group *entity.Group, checkNesting func(groups []*entity.Group) error) error {
+	return r.db.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&entity.Group{}).Clauses(clause.Locking{Strength: "UPDATE"}).Order("id").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		var groups []*entity.Group
+		if err := tx.Preload("Parents").Find(&groups).Error; err != nil {
+			return err
+		}
+		if err := checkNesting(groups); err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Scopes", "Roles", "Parents").Save(group).Error; err != nil {
+			return err
+		}
+
+		for _, table := range []interface{}{&entity.GroupScope{}, &entity.GroupRole{}, &entity.GroupParent{}} {
+			if err := tx.Delete(table, "group_id = ?", group.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range group.Scopes {
+			group.Scopes[i].GroupID = group.ID
+		}
+		for i := range group.Roles {
+			group.Roles[i].GroupID = group.ID
+		}
+		for i := range group.Parents {
+			group.Parents[i].GroupID = group.ID
+		}
+		if len(group.Scopes) > 0 {
+			if err := tx.Create(&group.Scopes).Error; err != nil {
+				return err
+			}
+		}
+		if len(group.Roles) > 0 {
+			if err := tx.Create(&group.Roles).Error; err != nil {
+				return err
+			}
+		}
+		if len(group.Parents) > 0 {
+			return tx.Create(&group.Parents).Error
+		}
+		return nil
+	})
+}
+
+func (r *repository) DeleteGroup(ctx context.Context, id uint) error {
+	if err := r.db.GetDB().WithContext(ctx).Delete(&entity.Group{}, "id = ?", id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) GetGroupMembers(ctx context.Context, groupID uint) ([]*entity.AuthUser, error) {
+	var users []*entity.AuthUser
+	if err := r.db.GetDB().WithContext(ctx).
+		Joins("JOIN group_members ON group_members.user_id = auth_users.id").
+		Where("group_members.group_id = ?", groupID).
+		Order("auth_users.username").
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetUserGroupIDs returns the groups the user is a direct member of.
+func (r *repository) GetUserGroupIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.GetDB().WithContext(ctx).
+		Model(&entity.GroupMember{}).
+		Where("user_id = ?", userID).
+		Pluck("group_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *repository) AddGroupMember(ctx context.Context, groupID, userID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).
+		Where(entity.GroupMember{GroupID: groupID, UserID: userID}).
+		FirstOrCreate(&entity.GroupMember{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) RemoveGroupMember(ctx context.Context, groupID, userID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).
+		Delete(&entity.GroupMember{}, "group_id = ? AND user_id = ?", groupID, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteUserGroups(ctx context.Context, userID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).Delete(&entity.GroupMember{}, "user_id = ?", userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+func (r *repository) ListIPRules(ctx context.Context) ([]*entity.IPRule, error) {
+	var rules []*entity.IPRule
+	if err := r.db.GetDB().WithContext(ctx).Order("id").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (r *repository) CreateIPRule(ctx context.Context, rule *entity.IPRule) error {
+	if err := r.db.GetDB().WithContext(ctx).Create(rule).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteIPRule(ctx context.Context, id uint) error {
+	result := r.db.GetDB().WithContext(ctx).Delete(&entity.IPRule{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+)
+
+func (r *repository) GetKnownDevices(ctx context.Context, userID uint) ([]*entity.KnownDevice, error) {
+	var devices []*entity.KnownDevice
+	if err := r.db.GetDB().WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("last_seen_at DESC").
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (r *repository) SaveKnownDevice(ctx context.Context, device *entity.KnownDevice) error {
+	if err := r.db.GetDB().WithContext(ctx).Save(device).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteKnownDevices(ctx context.Context, userID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).Delete(&entity.KnownDevice{}, "user_id = ?", userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
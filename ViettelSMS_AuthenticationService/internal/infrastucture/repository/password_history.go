@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+)
+
+func (r *repository) AddPasswordHistory(ctx context.Context, entry *entity.PasswordHistory) error {
+	if err := r.db.GetDB().WithContext(ctx).Create(entry).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) GetPasswordHistory(ctx context.Context, userID uint, limit int) ([]*entity.PasswordHistory, error) {
+	var entries []*entity.PasswordHistory
+	if err := r.db.GetDB().WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *repository) PrunePasswordHistory(ctx context.Context, userID uint, keep int) error {
+	db := r.db.GetDB().WithContext(ctx)
+	keepIDs := db.Model(&entity.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Limit(keep)
+
+	if err := db.
+		Where("user_id = ? AND id NOT IN (?)", userID, keepIDs).
+		Delete(&entity.PasswordHistory{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeletePasswordHistory(ctx context.Context, userID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).Delete(&entity.PasswordHistory{}, "user_id = ?", userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
 	repo "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/repository"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/postgres"
+	"gorm.io/gorm"
 )
 
 type repository struct {
@@ -36,6 +37,14 @@ func (r *repository) GetUserByUsername(ctx context.Context, username string) (*e
 	return &user, nil
 }
 
+func (r *repository) GetUserByEmail(ctx context.Context, email string) (*entity.AuthUser, error) {
+	var user entity.AuthUser
+	if err := r.db.GetDB().WithContext(ctx).First(&user, "LOWER(email) = LOWER(?)", email).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *repository) CreateUser(ctx context.Context, user *entity.AuthUser) error {
 	if err := r.db.GetDB().WithContext(ctx).Create(user).Error; err != nil {
 		return err
@@ -50,9 +59,72 @@ func (r *repository) UpdateUser(ctx context.Context, user *entity.AuthUser) erro
 	return nil
 }
 
+// UpdateUserColumns writes only the given columns, so a login that loaded the
+// user earlier cannot revert a concurrent change to the rest of the row.
+func (r *repository) UpdateUserColumns(ctx context.Context, id uint, columns map[string]interface{}) error {
+	if err := r.db.GetDB().WithContext(ctx).Model(&entity.AuthUser{}).Where("id = ?", id).Updates(columns).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// AddUserScope appends scope in SQL unless the user already holds it, so
+// concurrent grants neither overwrite each other nor store a duplicate. It
+// reports whether the scope was added.
+func (r *repository) AddUserScope(ctx context.Context, id uint, scope string) (bool, error) {
+	result := r.db.GetDB().WithContext(ctx).Model(&entity.AuthUser{}).
+		Where("id = ? AND NOT (? = ANY(COALESCE(scopes, '{}')))", id, scope).
+		Update("scopes", gorm.Expr("array_append(COALESCE(scopes, '{}'), ?)", scope))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RemoveUserScope drops scope in SQL, so grants stored since the user was
+// read are kept.
+func (r *repository) RemoveUserScope(ctx context.Context, id uint, scope string) error {
+	if err := r.db.GetDB().WithContext(ctx).Model(&entity.AuthUser{}).Where("id = ?", id).
+		Update("scopes", gorm.Expr("array_remove(scopes, ?)", scope)).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 func (r *repository) DeleteUser(ctx context.Context, id uint) error {
 	if err := r.db.GetDB().WithContext(ctx).Delete(&entity.AuthUser{}, "id = ?", id).Error; err != nil {
 		return err
 	}
 	return nil
 }
+
+func (r *repository) GetMachineIdentityByFingerprint(ctx context.Context, fingerprint string) (*entity.MachineIdentity, error) {
+	var identity entity.MachineIdentity
+	if err := r.db.GetDB().WithContext(ctx).First(&identity, "fingerprint = ?", fingerprint).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *repository) GetMachineIdentityBySubject(ctx context.Context, subject string) (*entity.MachineIdentity, error) {
+	var identity entity.MachineIdentity
+	if err := r.db.GetDB().WithContext(ctx).First(&identity, "subject = ? AND fingerprint = ''", subject).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *repository) GetMachineIdentityByName(ctx context.Context, name string) (*entity.MachineIdentity, error) {
+	var identity entity.MachineIdentity
+	if err := r.db.GetDB().WithContext(ctx).First(&identity, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *repository) CreateMachineIdentity(ctx context.Context, identity *entity.MachineIdentity) error {
+	if err := r.db.GetDB().WithContext(ctx).Create(identity).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ListResourcePermissions returns the permissions on resourceType, or on
+// every type when it is empty. subjects maps a subject type to the subjects
+// whose permissions are wanted; a nil map means everyone's.
+func (r *repository) ListResourcePermissions(ctx context.Context, resourceType string, subjects map[string][]string) ([]*entity.ResourcePermission, error) {
+	query := r.db.GetDB().WithContext(ctx).Model(&entity.ResourcePermission{})
+	if resourceType != "" {
+		query = query.Where("resource_type = ?", resourceType)
+	}
+	if subjects != nil {
+		if len(subjects) == 0 {
+			return nil, nil
+		}
+		held := r.db.GetDB()
+		for subjectType, values := range subjects {
+			held = held.Or("subject_type = ? AND subject IN ?", subjectType, values)
+		}
+		query = query.Where(held)
+	}
+
+	var permissions []*entity.ResourcePermission
+	if err := query.Order("id").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (r *repository) CreateResourcePermission(ctx context.Context, permission *entity.ResourcePermission) error {
+	if err := r.db.GetDB().WithContext(ctx).Create(permission).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GrantResourcePermission creates the permission unless an identical one
+// already exists, so replayed events are harmless.
+func (r *repository) GrantResourcePermission(ctx context.Context, permission *entity.ResourcePermission) error {
+	if err := r.db.GetDB().WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(permission).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteResourcePermission(ctx context.Context, id uint) error {
+	result := r.db.GetDB().WithContext(ctx).Delete(&entity.ResourcePermission{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteResourcePermissions drops every permission naming one resource
+// exactly; patterns are left alone.
+func (r *repository) DeleteResourcePermissions(ctx context.Context, resourceType, resource string) error {
+	if err := r.db.GetDB().WithContext(ctx).
+		Delete(&entity.ResourcePermission{}, "resource_type = ? AND resource = ?", resourceType, resource).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteSubjectPermissions(ctx context.Context, subjectType, subject string) error {
+	if err := r.db.GetDB().WithContext(ctx).
+		Delete(&entity.ResourcePermission{}, "subject_type = ? AND subject = ?", subjectType, subject).Error; err != nil {
+		return err
+	}
+	return nil
+}
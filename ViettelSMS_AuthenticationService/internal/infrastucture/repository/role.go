@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"gorm.io/gorm"
+)
+
+func (r *repository) ListRoles(ctx context.Context) ([]*entity.Role, error) {
+	var roles []*entity.Role
+	if err := r.db.GetDB().WithContext(ctx).
+		Preload("Scopes").
+		Order("name").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *repository) GetRoleByName(ctx context.Context, name string) (*entity.Role, error) {
+	var role entity.Role
+	if err := r.db.GetDB().WithContext(ctx).Preload("Scopes").First(&role, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *repository) CreateRole(ctx context.Context, role *entity.Role) error {
+	if err := r.db.GetDB().WithContext(ctx).Create(role).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateRole saves the role and replaces its scopes with role.Scopes.
+func (r *repository) UpdateRole(ctx context.Context, role *entity.Role) error {
+	return r.db.GetDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Scopes").Save(role).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&entity.RoleScope{}, "role_id = ?", role.ID).Error; err != nil {
+			return err
+		}
+		for i := range role.Scopes {
+			role.Scopes[i].RoleID = role.ID
+		}
+		if len(role.Scopes) == 0 {
+			return nil
+		}
+		return tx.Create(&role.Scopes).Error
+	})
+}
+
+func (r *repository) DeleteRole(ctx context.Context, id uint) error {
+	if err := r.db.GetDB().WithContext(ctx).Delete(&entity.Role{}, "id = ?", id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) GetUserRoles(ctx context.Context, userID uint) ([]*entity.Role, error) {
+	var roles []*entity.Role
+	if err := r.db.GetDB().WithContext(ctx).
+		Preload("Scopes").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.name").
+		Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (r *repository) AssignRole(ctx context.Context, userID, roleID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).
+		Where(entity.UserRole{UserID: userID, RoleID: roleID}).
+		FirstOrCreate(&entity.UserRole{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) UnassignRole(ctx context.Context, userID, roleID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).
+		Delete(&entity.UserRole{}, "user_id = ? AND role_id = ?", userID, roleID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *repository) DeleteUserRoles(ctx context.Context, userID uint) error {
+	if err := r.db.GetDB().WithContext(ctx).Delete(&entity.UserRole{}, "user_id = ?", userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+)
+
+func (r *repository) ListScopes(ctx context.Context) ([]*entity.Scope, error) {
+	var scopes []*entity.Scope
+	if err := r.db.GetDB().WithContext(ctx).Order("service, name").Find(&scopes).Error; err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
@@ -0,0 +1,219 @@
+package scope
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+const wildcard = "*"
+
+var partPattern = regexp.MustCompile(`^([a-z0-9_-]+|\*)$`)
+
+// Grammar is the content of the scopes file.
+type Grammar struct {
+	// Resources lists the actions of every known resource.
+	Resources map[string][]string `mapstructure:"resources"`
+	// Actions maps an action to the actions it implies on the same resource.
+	Actions map[string][]string `mapstructure:"actions"`
+	// Scopes maps a scope to other scopes it implies.
+	Scopes map[string][]string `mapstructure:"scopes"`
+}
+
+type scope struct {
+	resource string
+	action   string
+}
+
+func (s scope) String() string {
+	return s.resource + ":" + s.action
+}
+
+func (s scope) concrete() bool {
+	return s.resource != wildcard && s.action != wildcard
+}
+
+type matcher struct {
+	known   []scope
+	actions map[string][]string
+	scopes  map[scope][]scope
+}
+
+// LoadMatcher reads the scope grammar from a YAML file. Without a file only
+// exact and wildcard matches apply.
+func LoadMatcher(file string) (srv.ScopeMatcher, error) {
+	if file == "" {
+		return NewMatcher(Grammar{})
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read scopes file: %w", err)
+	}
+
+	var grammar Grammar
+	if err := v.Unmarshal(&grammar); err != nil {
+		return nil, fmt.Errorf("parse scopes file %s: %w", file, err)
+	}
+	return NewMatcher(grammar)
+}
+
+func NewMatcher(grammar Grammar) (srv.ScopeMatcher, error) {
+	m := &matcher{
+		actions: map[string][]string{},
+		scopes:  map[scope][]scope{},
+	}
+
+	for resource, actions := range grammar.Resources {
+		for _, action := range actions {
+			s, ok := parse(resource + ":" + action)
+			if !ok || !s.concrete() {
+				return nil, fmt.Errorf("resource %q: invalid action %q", resource, action)
+			}
+			m.known = append(m.known, s)
+		}
+	}
+	slices.SortFunc(m.known, func(a, b scope) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	for action, implied := range grammar.Actions {
+		for _, a := range append([]string{action}, implied...) {
+			if a == wildcard || !partPattern.MatchString(a) {
+				return nil, fmt.Errorf("actions: invalid action %q", a)
+			}
+		}
+		m.actions[action] = implied
+	}
+
+	for from, implied := range grammar.Scopes {
+		s, ok := parse(from)
+		if !ok || !s.concrete() {
+			return nil, fmt.Errorf("scopes: invalid scope %q", from)
+		}
+		for _, to := range implied {
+			t, ok := parse(to)
+			if !ok || !t.concrete() {
+				return nil, fmt.Errorf("scopes: %q implies invalid scope %q", from, to)
+			}
+			m.scopes[s] = append(m.scopes[s], t)
+		}
+	}
+
+	return m, nil
+}
+
+func (m *matcher) Valid(s string) bool {
+	_, ok := parse(s)
+	return ok
+}
+
+func (m *matcher) Grants(granted []string, required string) bool {
+	req, ok := parse(required)
+	if !ok {
+		return false
+	}
+
+	for _, g := range granted {
+		grant, ok := parse(g)
+		if !ok {
+			continue
+		}
+		if m.covers(grant, req) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *matcher) Expand(granted []string) []string {
+	result := make([]string, 0, len(granted))
+	var concrete []scope
+
+	for _, g := range granted {
+		grant, ok := parse(g)
+		if !ok {
+			continue
+		}
+		result = append(result, grant.String())
+		if grant.concrete() {
+			concrete = append(concrete, grant)
+		}
+	}
+
+	for _, grant := range concrete {
+		for _, implied := range m.closure(grant) {
+			result = append(result, implied.String())
+		}
+	}
+	for _, known := range m.known {
+		if m.Grants(granted, known.String()) {
+			result = append(result, known.String())
+		}
+	}
+
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+// covers reports whether one grant satisfies req. A wildcard in req only
+// matches a grant at least as broad, so "server:*" is not satisfied by
+// "server:read".
+func (m *matcher) covers(grant, req scope) bool {
+	if grant.resource != wildcard && grant.resource != req.resource {
+		return false
+	}
+	if grant.action == wildcard {
+		return true
+	}
+	if !req.concrete() {
+		return grant.resource == req.resource && grant.action == req.action
+	}
+
+	// "*:read" grants what "server:read" grants when asked about servers.
+	grant.resource = req.resource
+	return slices.Contains(m.closure(grant), req)
+}
+
+// closure returns s and every scope it implies, following action and scope
+// implications transitively. Cycles in the grammar are harmless.
+func (m *matcher) closure(s scope) []scope {
+	seen := map[scope]bool{s: true}
+	result := []scope{s}
+
+	for i := 0; i < len(result); i++ {
+		current := result[i]
+
+		next := m.scopes[current]
+		for _, action := range m.actions[current.action] {
+			next = append(next, scope{resource: current.resource, action: action})
+		}
+
+		for _, n := range next {
+			if !seen[n] {
+				seen[n] = true
+				result = append(result, n)
+			}
+		}
+	}
+	return result
+}
+
+// parse splits RESOURCE:ACTION. A bare "*" is shorthand for "*:*".
+func parse(s string) (scope, bool) {
+	s = strings.TrimSpace(s)
+	if s == wildcard {
+		return scope{resource: wildcard, action: wildcard}, true
+	}
+
+	resource, action, ok := strings.Cut(s, ":")
+	if !ok || !partPattern.MatchString(resource) || !partPattern.MatchString(action) {
+		return scope{}, false
+	}
+	return scope{resource: resource, action: action}, true
+}
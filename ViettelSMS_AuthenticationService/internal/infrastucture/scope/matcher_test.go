@@ -0,0 +1,244 @@
+package scope
+
+import (
+	"slices"
+	"testing"
+
+	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+)
+
+// testGrammar mirrors config/scopes.yaml.
+var testGrammar = Grammar{
+	Resources: map[string][]string{
+		"user":   {"create", "read", "update", "delete", "view", "scope"},
+		"server": {"read", "update", "delete", "view", "import", "export"},
+		"auth":   {"authorize"},
+	},
+	Actions: map[string][]string{
+		"create": {"read"},
+		"update": {"read"},
+		"delete": {"read"},
+		"import": {"update"},
+		"export": {"read"},
+		"scope":  {"read"},
+		"read":   {"view"},
+	},
+	Scopes: map[string][]string{
+		"user:scope": {"user:update"},
+	},
+}
+
+func newTestMatcher(t *testing.T) srv.ScopeMatcher {
+	t.Helper()
+	m, err := NewMatcher(testGrammar)
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+	return m
+}
+
+func TestValid(t *testing.T) {
+	m := newTestMatcher(t)
+
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{"server:read", true},
+		{"server:*", true},
+		{"*:read", true},
+		{"*:*", true},
+		{"*", true},
+		{" server:read ", true},
+		{"billing:refund", true},
+		{"server_v2:bulk-import", true},
+
+		{"", false},
+		{"server", false},
+		{"server:", false},
+		{":read", false},
+		{"server:read:extra", false},
+		{"Server:read", false},
+		{"server:re ad", false},
+		{"server:**", false},
+		{"ser*:read", false},
+		{"*server:read", false},
+		{"server::read", false},
+	}
+	for _, tt := range tests {
+		if got := m.Valid(tt.scope); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestGrants(t *testing.T) {
+	m := newTestMatcher(t)
+
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact", []string{"server:read"}, "server:read", true},
+		{"exact other action", []string{"server:read"}, "server:delete", false},
+		{"exact other resource", []string{"server:read"}, "user:read", false},
+		{"no grants", nil, "server:read", false},
+
+		{"resource wildcard", []string{"server:*"}, "server:delete", true},
+		{"resource wildcard other resource", []string{"server:*"}, "user:read", false},
+		{"resource wildcard unknown action", []string{"server:*"}, "server:reboot", true},
+		{"action wildcard", []string{"*:read"}, "user:read", true},
+		{"action wildcard other action", []string{"*:read"}, "user:delete", false},
+		{"action wildcard implied", []string{"*:read"}, "server:view", true},
+		{"action wildcard unknown resource", []string{"*:read"}, "billing:read", true},
+		{"everything", []string{"*:*"}, "auth:authorize", true},
+		{"bare star", []string{"*"}, "server:delete", true},
+
+		{"implied action", []string{"server:update"}, "server:read", true},
+		{"implied action transitive", []string{"server:import"}, "server:view", true},
+		{"implied action not reversed", []string{"server:read"}, "server:update", false},
+		{"implied action same resource only", []string{"server:update"}, "user:read", false},
+		{"implied scope", []string{"user:scope"}, "user:update", true},
+		{"implied scope then action", []string{"user:scope"}, "user:view", true},
+		{"implied scope not reversed", []string{"user:update"}, "user:scope", false},
+
+		{"wildcard required needs wildcard grant", []string{"server:read"}, "server:*", false},
+		{"wildcard required by same wildcard", []string{"server:*"}, "server:*", true},
+		{"wildcard required by broader grant", []string{"*:*"}, "server:*", true},
+		{"action wildcard required by resource wildcard", []string{"server:*"}, "*:read", false},
+		{"everything required", []string{"server:*", "user:*"}, "*:*", false},
+
+		{"unknown resource exact", []string{"billing:refund"}, "billing:refund", true},
+		{"unknown resource implied action", []string{"billing:read"}, "billing:view", true},
+		{"unknown action not implied", []string{"billing:refund"}, "billing:read", false},
+
+		{"malformed required", []string{"*:*"}, "server", false},
+		{"empty required", []string{"*:*"}, "", false},
+		{"malformed grant skipped", []string{"server", "server:read"}, "server:read", true},
+		{"only malformed grants", []string{"server:", ":read"}, "server:read", false},
+		{"any grant is enough", []string{"user:read", "server:export"}, "server:view", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Grants(tt.granted, tt.required); got != tt.want {
+				t.Errorf("Grants(%q, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	m := newTestMatcher(t)
+
+	allServer := []string{"server:*", "server:delete", "server:export", "server:import", "server:read", "server:update", "server:view"}
+
+	tests := []struct {
+		name    string
+		granted []string
+		want    []string
+	}{
+		{"empty", nil, []string{}},
+		{"exact without implications", []string{"auth:authorize"}, []string{"auth:authorize"}},
+		{"exact with implications", []string{"server:update"}, []string{"server:read", "server:update", "server:view"}},
+		{"transitive implications", []string{"server:import"}, []string{"server:import", "server:read", "server:update", "server:view"}},
+		{"scope implication", []string{"user:scope"}, []string{"user:read", "user:scope", "user:update", "user:view"}},
+		{"resource wildcard", []string{"server:*"}, allServer},
+		{"action wildcard", []string{"*:read"}, []string{"*:read", "server:read", "server:view", "user:read", "user:view"}},
+		{"everything", []string{"*:*"}, []string{
+			"*:*", "auth:authorize",
+			"server:delete", "server:export", "server:import", "server:read", "server:update", "server:view",
+			"user:create", "user:delete", "user:read", "user:scope", "user:update", "user:view",
+		}},
+		{"bare star normalised", []string{"*"}, []string{
+			"*:*", "auth:authorize",
+			"server:delete", "server:export", "server:import", "server:read", "server:update", "server:view",
+			"user:create", "user:delete", "user:read", "user:scope", "user:update", "user:view",
+		}},
+		{"duplicates removed", []string{"server:read", "server:read", "server:update"}, []string{"server:read", "server:update", "server:view"}},
+		{"whitespace trimmed", []string{" server:view "}, []string{"server:view"}},
+		{"unknown resource kept", []string{"billing:refund"}, []string{"billing:refund"}},
+		{"unknown resource implied action", []string{"billing:read"}, []string{"billing:read", "billing:view"}},
+		{"malformed dropped", []string{"server", ":read", "server:view"}, []string{"server:view"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.Expand(tt.granted)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expand(%q) = %q, want %q", tt.granted, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherWithoutGrammar(t *testing.T) {
+	m, err := NewMatcher(Grammar{})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+
+	if !m.Grants([]string{"server:*"}, "server:read") {
+		t.Error("wildcard grant should match without a grammar")
+	}
+	if m.Grants([]string{"server:update"}, "server:read") {
+		t.Error("no implications without a grammar")
+	}
+	if got, want := m.Expand([]string{"*:*", "server:update"}), []string{"*:*", "server:update"}; !slices.Equal(got, want) {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestNewMatcherRejectsInvalidGrammar(t *testing.T) {
+	tests := []struct {
+		name    string
+		grammar Grammar
+	}{
+		{"wildcard resource action", Grammar{Resources: map[string][]string{"server": {"*"}}}},
+		{"wildcard resource", Grammar{Resources: map[string][]string{"*": {"read"}}}},
+		{"malformed resource action", Grammar{Resources: map[string][]string{"server": {"Read"}}}},
+		{"wildcard implied action", Grammar{Actions: map[string][]string{"update": {"*"}}}},
+		{"wildcard implying action", Grammar{Actions: map[string][]string{"*": {"read"}}}},
+		{"malformed implied action", Grammar{Actions: map[string][]string{"update": {"re ad"}}}},
+		{"wildcard implying scope", Grammar{Scopes: map[string][]string{"user:*": {"user:read"}}}},
+		{"wildcard implied scope", Grammar{Scopes: map[string][]string{"user:scope": {"*:read"}}}},
+		{"malformed implied scope", Grammar{Scopes: map[string][]string{"user:scope": {"user"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMatcher(tt.grammar); err == nil {
+				t.Error("NewMatcher accepted an invalid grammar")
+			}
+		})
+	}
+}
+
+func TestImplicationCycle(t *testing.T) {
+	m, err := NewMatcher(Grammar{
+		Actions: map[string][]string{"read": {"view"}, "view": {"read"}},
+	})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+
+	if !m.Grants([]string{"server:view"}, "server:read") {
+		t.Error("cyclic implication should still grant")
+	}
+	if got, want := m.Expand([]string{"server:read"}), []string{"server:read", "server:view"}; !slices.Equal(got, want) {
+		t.Errorf("Expand = %q, want %q", got, want)
+	}
+}
+
+func TestLoadMatcher(t *testing.T) {
+	m, err := LoadMatcher("../../../config/scopes.yaml")
+	if err != nil {
+		t.Fatalf("LoadMatcher: %v", err)
+	}
+	if !m.Grants([]string{"server:import"}, "server:view") {
+		t.Error("config/scopes.yaml should make server:import imply server:view")
+	}
+
+	if _, err := LoadMatcher("testdata/missing.yaml"); err == nil {
+		t.Error("LoadMatcher accepted a missing file")
+	}
+}
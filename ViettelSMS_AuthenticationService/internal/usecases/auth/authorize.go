@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"go.uber.org/zap"
+)
+
+const authzDecisionKeyPrefix = "auth:authz:"
+
+// Authorize evaluates the ABAC policies for a request from another service.
+// The subject is always resolved first, so decisions cached for
+// AUTHZ_CACHE_TTL are keyed on its current scopes, blocked state and
+// resource permission as well as the request and the policy version; a
+// block, scope or permission change applies to the next request.
+func (u *usecase) Authorize(ctx context.Context, req dto.AuthorizeRequest) (*dto.AuthorizeResponse, error) {
+	attributes := requestAttributes(req)
+	if err := u.resolveSubject(ctx, req, attributes); err != nil {
+		return nil, err
+	}
+
+	key, err := u.decisionCacheKey(attributes)
+	if err != nil {
+		u.logger.Error("Failed to build decision cache key", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	if decision, ok := u.cachedDecision(ctx, key); ok {
+		return decision, nil
+	}
+
+	decision := u.policies.Decide(attributes)
+	u.logger.Info("Authorization decided",
+		zap.String("subject_type", req.Subject.Type),
+		zap.String("subject", req.Subject.ID),
+		zap.String("action", req.Action),
+		zap.String("resource_type", req.Resource.Type),
+		zap.String("resource", req.Resource.ID),
+		zap.String("decision", decision.Decision),
+		zap.String("rule", decision.Rule))
+
+	if ttl := u.config.Authorization.CacheTTL; ttl > 0 {
+		if raw, err := json.Marshal(decision); err == nil {
+			if err := u.cache.GetCache().Set(ctx, key, raw, ttl).Err(); err != nil {
+				u.logger.Warn("Failed to cache authorization decision", zap.Error(err))
+			}
+		}
+	}
+	return &decision, nil
+}
+
+// requestAttributes flattens what the caller sent. The fixed attributes are
+// set last so free-form ones cannot shadow them.
+func requestAttributes(req dto.AuthorizeRequest) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for name, value := range req.Subject.Attributes {
+		attributes["subject."+name] = value
+	}
+	for name, value := range req.Resource.Attributes {
+		attributes["resource."+name] = value
+	}
+	for name, value := range req.Context.Attributes {
+		attributes["context."+name] = value
+	}
+
+	attributes["action"] = req.Action
+	attributes["subject.type"] = req.Subject.Type
+	attributes["subject.id"] = req.Subject.ID
+	attributes["resource.type"] = req.Resource.Type
+	if req.Resource.ID != "" {
+		attributes["resource.id"] = req.Resource.ID
+	}
+	if req.Context.IP != "" {
+		attributes["context.ip"] = req.Context.IP
+	}
+
+	// Only the hour and weekday are exposed so decisions stay cacheable.
+	now := time.Now()
+	if req.Context.Time != nil {
+		now = *req.Context.Time
+	}
+	attributes["context.hour"] = now.Hour()
+	attributes["context.weekday"] = strings.ToLower(now.Weekday().String())
+
+	return attributes
+}
+
+// resolveSubject adds what this service knows about the subject, overriding
+// anything the caller claimed under the same names. resource.permitted tells
+// whether a resource permission grants the action on that very resource.
+func (u *usecase) resolveSubject(ctx context.Context, req dto.AuthorizeRequest, attributes map[string]interface{}) error {
+	var holder *permissionHolder
+	switch req.Subject.Type {
+	case entity.PermissionSubjectUser:
+		user, err := u.getUserByUserName(ctx, req.Subject.ID)
+		if err != nil {
+			return err
+		}
+		if holder, err = u.userHolder(ctx, user); err != nil {
+			return err
+		}
+		attributes["subject.user_id"] = strconv.FormatUint(uint64(user.ID), 10)
+		attributes["subject.email"] = user.Email
+		attributes["subject.blocked"] = user.Blocked
+
+	case entity.PermissionSubjectMachine:
+		identity, err := u.getMachineIdentityByName(ctx, req.Subject.ID)
+		if err != nil {
+			return err
+		}
+		holder = u.machineHolder(identity)
+		attributes["subject.blocked"] = identity.Blocked
+	}
+	attributes["subject.scopes"] = holder.scopes
+
+	attributes["resource.permitted"] = false
+	if req.Resource.ID != "" {
+		permission, err := u.permissionFor(ctx, holder, req.Resource.Type, req.Resource.ID, req.Action)
+		if err != nil {
+			return err
+		}
+		attributes["resource.permitted"] = permission != nil
+	}
+	return nil
+}
+
+func (u *usecase) decisionCacheKey(attributes map[string]interface{}) (string, error) {
+	// encoding/json sorts map keys, so equal requests hash the same.
+	raw, err := json.Marshal(attributes)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(u.policies.Version()+"\n"), raw...))
+	return authzDecisionKeyPrefix + hex.EncodeToString(sum[:]), nil
+}
+
+// cachedDecision returns a cached decision. Cache failures only cost a fresh
+// evaluation.
+func (u *usecase) cachedDecision(ctx context.Context, key string) (*dto.AuthorizeResponse, bool) {
+	if u.config.Authorization.CacheTTL <= 0 {
+		return nil, false
+	}
+
+	raw, err := u.cache.GetCache().Get(ctx, key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			u.logger.Warn("Failed to read cached authorization decision", zap.Error(err))
+		}
+		return nil, false
+	}
+
+	var decision dto.AuthorizeResponse
+	if err := json.Unmarshal(raw, &decision); err != nil {
+		u.logger.Warn("Discarding malformed cached authorization decision", zap.Error(err))
+		return nil, false
+	}
+	return &decision, true
+}
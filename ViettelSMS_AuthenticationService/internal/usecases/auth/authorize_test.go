@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/abac"
+)
+
+type testCache struct{ client *redis.Client }
+
+func (c testCache) GetCache() *redis.Client { return c.client }
+
+func newAuthorizeUsecase(t *testing.T) *usecase {
+	t.Helper()
+	u := newPermissionUsecase(t)
+
+	policies, err := abac.LoadEngine("../../../config/policies.yaml", u.scopes)
+	if err != nil {
+		t.Fatalf("LoadEngine: %v", err)
+	}
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	u.policies = policies
+	u.cache = testCache{client}
+	u.config = &config.Config{}
+	u.config.Authorization.CacheTTL = time.Minute
+	return u
+}
+
+func authorizeRequest(subject, action, resource string) dto.AuthorizeRequest {
+	var req dto.AuthorizeRequest
+	req.Subject.Type = entity.PermissionSubjectUser
+	req.Subject.ID = subject
+	req.Action = action
+	req.Resource.Type = serverResourceType
+	req.Resource.ID = resource
+	return req
+}
+
+// A cached decision must not outlive a change to what the service resolves
+// about the subject.
+func TestAuthorizeCacheFollowsSubject(t *testing.T) {
+	u := newAuthorizeUsecase(t)
+	ctx := context.Background()
+	alice := u.repo.(*permissionRepo).users[0]
+
+	tests := []struct {
+		name    string
+		change  func()
+		req     dto.AuthorizeRequest
+		allowed bool
+	}{
+		{"allowed by scope", func() {}, authorizeRequest("alice", "read", ""), true},
+		{"cached allow", func() {}, authorizeRequest("alice", "read", ""), true},
+		{"blocked", func() { alice.Blocked = true }, authorizeRequest("alice", "read", ""), false},
+		{"unblocked", func() { alice.Blocked = false }, authorizeRequest("alice", "read", ""), true},
+		{"scope removed", func() { alice.Scopes = nil }, authorizeRequest("alice", "read", ""), false},
+		{"allowed by permission", func() {}, authorizeRequest("alice", "update", "srv-1"), true},
+		{"permission removed", func() { u.repo.(*permissionRepo).permissions = nil }, authorizeRequest("alice", "update", "srv-1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			got, err := u.Authorize(ctx, tt.req)
+			if err != nil {
+				t.Fatalf("Authorize: %v", err)
+			}
+			if got.Allowed != tt.allowed {
+				t.Errorf("Authorize = %+v, want allowed %v", got, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestAuthorizeIgnoresClaimedSubjectAttributes(t *testing.T) {
+	u := newAuthorizeUsecase(t)
+
+	req := authorizeRequest("bob", "read", "")
+	req.Subject.Attributes = map[string]interface{}{
+		"blocked": false,
+		"scopes":  []string{"server:read"},
+	}
+	got, err := u.Authorize(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	if got.Allowed {
+		t.Errorf("Authorize = %+v, want claimed scopes ignored", got)
+	}
+}
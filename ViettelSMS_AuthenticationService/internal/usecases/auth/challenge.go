@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	challengeFailuresKeyPrefix = "auth:challenge:failures:"
+	powChallengeKeyPrefix      = "auth:challenge:pow:"
+
+	challengeModeOff     = "off"
+	challengeModePoW     = "pow"
+	challengeModeCaptcha = "captcha"
+
+	powAlgorithm = "sha256"
+)
+
+// IssueChallenge hands out a single-use proof-of-work challenge, or tells the
+// client to show the configured CAPTCHA.
+func (u *usecase) IssueChallenge(ctx context.Context) (*dto.ChallengeResponse, error) {
+	cfg := u.config.Challenge
+
+	switch cfg.Mode {
+	case challengeModeCaptcha:
+		return &dto.ChallengeResponse{
+			Type:    challengeModeCaptcha,
+			SiteKey: cfg.CaptchaSiteKey,
+		}, nil
+	case challengeModePoW:
+		challenge, err := randomToken(16)
+		if err != nil {
+			u.logger.Error("Failed to generate challenge", zap.Error(err))
+			return nil, domain.ErrInternalServer
+		}
+
+		if err := u.cache.GetCache().Set(ctx, powChallengeKeyPrefix+challenge, cfg.PowDifficulty, cfg.PowTTL).Err(); err != nil {
+			u.logger.Error("Failed to store challenge", zap.Error(err))
+			return nil, domain.ErrInternalServer
+		}
+
+		return &dto.ChallengeResponse{
+			Type:       challengeModePoW,
+			Challenge:  challenge,
+			Algorithm:  powAlgorithm,
+			Difficulty: cfg.PowDifficulty,
+			ExpiresIn:  int(cfg.PowTTL.Seconds()),
+		}, nil
+	default:
+		return &dto.ChallengeResponse{Type: challengeModeOff}, nil
+	}
+}
+
+// checkLoginChallenge gates a login attempt once the username or the client
+// IP has collected AfterFailures failures. Attempts stopped here never reach
+// the password check, so they do not count towards the account lockout
+// either: an attacker has to pay for every guess, and cannot lock a victim
+// out by spraying wrong passwords for free.
+func (u *usecase) checkLoginChallenge(ctx context.Context, req dto.LoginRequest, client dto.ClientInfo) error {
+	if !u.challengeRequired(ctx, req.UserName, client.IP) {
+		return nil
+	}
+
+	switch u.config.Challenge.Mode {
+	case challengeModePoW:
+		if req.Challenge == "" {
+			return domain.ErrChallengeRequired
+		}
+		return u.verifyProofOfWork(ctx, req.Challenge, req.Nonce)
+	case challengeModeCaptcha:
+		if req.CaptchaToken == "" {
+			return domain.ErrChallengeRequired
+		}
+		ok, err := u.captcha.Verify(ctx, req.CaptchaToken, client.IP)
+		if err != nil {
+			u.logger.Error("Failed to verify captcha", zap.Error(err))
+			return domain.ErrInternalServer
+		}
+		if !ok {
+			return domain.ErrInvalidChallenge
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (u *usecase) challengeRequired(ctx context.Context, username, ip string) bool {
+	cfg := u.config.Challenge
+	if cfg.Mode == challengeModeOff || cfg.AfterFailures <= 0 {
+		return false
+	}
+
+	counts, err := u.cache.GetCache().MGet(ctx, challengeFailureKeys(username, ip)...).Result()
+	if err != nil {
+		u.logger.Error("Failed to read login failures", zap.Error(err))
+		return false
+	}
+
+	for _, count := range counts {
+		value, ok := count.(string)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(value); err == nil && n >= cfg.AfterFailures {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyProofOfWork consumes the challenge and checks that
+// SHA-256(challenge + ":" + nonce) starts with the required zero bits.
+func (u *usecase) verifyProofOfWork(ctx context.Context, challenge, nonce string) error {
+	stored, err := u.cache.GetCache().GetDel(ctx, powChallengeKeyPrefix+challenge).Int()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return domain.ErrInvalidChallenge
+		}
+		u.logger.Error("Failed to retrieve challenge", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	sum := sha256.Sum256([]byte(challenge + ":" + nonce))
+	if leadingZeroBits(sum[:]) < stored {
+		return domain.ErrInvalidChallenge
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login against both the username and
+// the client IP. The username need not exist, so counting does not reveal
+// which accounts do. Each failure extends the window, so the counters only
+// reset after Window without failures.
+func (u *usecase) recordLoginFailure(ctx context.Context, username, ip string) {
+	cfg := u.config.Challenge
+	if cfg.Mode == challengeModeOff || cfg.AfterFailures <= 0 {
+		return
+	}
+
+	pipe := u.cache.GetCache().TxPipeline()
+	for _, key := range challengeFailureKeys(username, ip) {
+		pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, cfg.Window)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		u.logger.Error("Failed to record login failure", zap.Error(err))
+	}
+}
+
+// clearLoginFailures forgets the failures of a username after it logged in.
+// The IP counter is kept: a stuffing run will hit some valid accounts.
+func (u *usecase) clearLoginFailures(ctx context.Context, username string) {
+	u.cache.GetCache().Del(ctx, challengeFailuresKeyPrefix+"user:"+strings.ToLower(username))
+}
+
+func challengeFailureKeys(username, ip string) []string {
+	return []string{
+		challengeFailuresKeyPrefix + "user:" + strings.ToLower(username),
+		challengeFailuresKeyPrefix + "ip:" + ip,
+	}
+}
+
+func leadingZeroBits(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
@@ -0,0 +1,337 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	deviceCodeKeyPrefix = "auth:device:code:"
+	userCodeKeyPrefix   = "auth:device:user_code:"
+
+	// userCodeAlphabet omits vowels and look-alike characters, as suggested
+	// by RFC 8628 section 6.1.
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+
+	slowDownIncrement = 5 * time.Second
+
+	deviceUpdateRetries = 3
+
+	deviceStatusPending  = "pending"
+	deviceStatusApproved = "approved"
+	deviceStatusDenied   = "denied"
+)
+
+// deviceAuthorization is the pending device grant kept in Redis until it is
+// exchanged for tokens or expires.
+type deviceAuthorization struct {
+	ClientID     string `json:"client_id"`
+	UserCode     string `json:"user_code"`
+	Status       string `json:"status"`
+	UserID       uint   `json:"user_id"`
+	Interval     int64  `json:"interval"`
+	LastPolledAt int64  `json:"last_polled_at"`
+}
+
+func (u *usecase) RequestDeviceCode(ctx context.Context, clientID string) (*dto.DeviceCodeResponse, error) {
+	// The exchange is bound to this client ID, so it has to name a client
+	// this service knows.
+	if !slices.Contains(u.config.Device.ClientIDs, clientID) {
+		u.logger.Warn("Device code requested by unknown client", zap.String("client_id", clientID))
+		return nil, domain.ErrUnknownClient
+	}
+
+	deviceCode, err := randomToken(32)
+	if err != nil {
+		u.logger.Error("Failed to generate device code", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	userCode, err := randomUserCode()
+	if err != nil {
+		u.logger.Error("Failed to generate user code", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	ttl := u.config.Device.CodeTTL
+	interval := u.config.Device.PollInterval
+
+	authorization := &deviceAuthorization{
+		ClientID: clientID,
+		UserCode: userCode,
+		Status:   deviceStatusPending,
+		Interval: int64(interval.Seconds()),
+	}
+
+	if err := u.saveDeviceAuthorization(ctx, deviceCode, authorization, ttl); err != nil {
+		u.logger.Error("Failed to store device authorization", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	if err := u.cache.GetCache().Set(ctx, userCodeKeyPrefix+userCode, deviceCode, ttl).Err(); err != nil {
+		u.logger.Error("Failed to store user code", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	u.logger.Info("Device authorization requested", zap.String("client_id", clientID))
+
+	return &dto.DeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                formatUserCode(userCode),
+		VerificationURI:         u.config.Device.VerificationURI,
+		VerificationURIComplete: u.config.Device.VerificationURI + "?user_code=" + url.QueryEscape(formatUserCode(userCode)),
+		ExpiresIn:               int(ttl.Seconds()),
+		Interval:                int(interval.Seconds()),
+	}, nil
+}
+
+func (u *usecase) ApproveDeviceCode(ctx context.Context, userID uint, userCode string, approve bool) error {
+	userCode = normalizeUserCode(userCode)
+
+	deviceCode, err := u.cache.GetCache().Get(ctx, userCodeKeyPrefix+userCode).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			u.logger.Warn("Unknown or expired user code", zap.Uint("userID", userID))
+			return domain.ErrInvalidUserCode
+		}
+		u.logger.Error("Failed to retrieve user code", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	authorization, err := u.updateDeviceAuthorization(ctx, deviceCode, func(authorization *deviceAuthorization) error {
+		if authorization.Status != deviceStatusPending {
+			return domain.ErrInvalidUserCode
+		}
+		authorization.UserID = userID
+		authorization.Status = deviceStatusDenied
+		if approve {
+			authorization.Status = deviceStatusApproved
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrExpiredToken) {
+			return domain.ErrInvalidUserCode
+		}
+		return err
+	}
+
+	// A user code is single use; the device code lives on until it is polled.
+	u.cache.GetCache().Del(ctx, userCodeKeyPrefix+userCode)
+
+	u.logger.Info("Device authorization decided",
+		zap.Uint("userID", userID),
+		zap.String("client_id", authorization.ClientID),
+		zap.String("status", authorization.Status))
+
+	return nil
+}
+
+// ExchangeDeviceCode redeems an approved device code for the client it was
+// issued to. The user goes through the same gatekeeping as a password login:
+// blocked or locked accounts and disallowed addresses get no tokens.
+func (u *usecase) ExchangeDeviceCode(ctx context.Context, deviceCode, clientID string, client dto.ClientInfo) (*dto.TokenResponse, error) {
+	var pollErr error
+	authorization, err := u.updateDeviceAuthorization(ctx, deviceCode, func(authorization *deviceAuthorization) error {
+		// RFC 8628 section 3.4: the code is bound to the client that requested it.
+		if authorization.ClientID != clientID {
+			return domain.ErrInvalidClient
+		}
+
+		pollErr = nil
+		now := time.Now().Unix()
+		if authorization.LastPolledAt != 0 && now-authorization.LastPolledAt < authorization.Interval {
+			authorization.Interval += int64(slowDownIncrement.Seconds())
+			pollErr = domain.ErrSlowDown
+		}
+		authorization.LastPolledAt = now
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidClient) {
+			u.logger.Warn("Device code presented by another client", zap.String("client_id", clientID))
+		}
+		return nil, err
+	}
+	if pollErr != nil {
+		return nil, pollErr
+	}
+
+	switch authorization.Status {
+	case deviceStatusPending:
+		return nil, domain.ErrAuthorizationPending
+	case deviceStatusDenied:
+		u.cache.GetCache().Del(ctx, deviceCodeKeyPrefix+deviceCode)
+		return nil, domain.ErrAccessDenied
+	}
+
+	// Delete before minting so a device code can only be redeemed once.
+	deleted, err := u.cache.GetCache().Del(ctx, deviceCodeKeyPrefix+deviceCode).Result()
+	if err != nil {
+		u.logger.Error("Failed to delete device authorization", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	if deleted == 0 {
+		return nil, domain.ErrExpiredToken
+	}
+
+	user, err := u.repo.GetUserByID(ctx, authorization.UserID)
+	if err != nil {
+		u.logger.Error("Failed to retrieve user", zap.Uint("userID", authorization.UserID), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	// A device has no way to show the change-password form.
+	if u.passwordChangeRequired(user) {
+		u.logger.Warn("Device code refused until password is changed", zap.Uint("userID", user.ID))
+		return nil, domain.ErrAccessDenied
+	}
+
+	if user.Blocked || isLocked(user) {
+		u.logger.Warn("Device code refused for blocked or locked account", zap.Uint("userID", user.ID))
+		return nil, domain.ErrAccessDenied
+	}
+
+	if err := u.checkLoginIP(ctx, user, client); err != nil {
+		return nil, err
+	}
+
+	tokens, err := u.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("Device code exchanged", zap.Uint("userID", user.ID), zap.String("client_id", authorization.ClientID))
+	return &dto.TokenResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (u *usecase) getDeviceAuthorization(ctx context.Context, deviceCode string) (*deviceAuthorization, error) {
+	raw, err := u.cache.GetCache().Get(ctx, deviceCodeKeyPrefix+deviceCode).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, domain.ErrExpiredToken
+		}
+		u.logger.Error("Failed to retrieve device authorization", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	var authorization deviceAuthorization
+	if err := json.Unmarshal(raw, &authorization); err != nil {
+		u.logger.Error("Failed to decode device authorization", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	return &authorization, nil
+}
+
+// updateDeviceAuthorization applies update to the stored authorization under
+// WATCH, so concurrent approvals and polls cannot overwrite each other. An
+// error from update aborts without writing and is returned as is.
+func (u *usecase) updateDeviceAuthorization(ctx context.Context, deviceCode string, update func(*deviceAuthorization) error) (*deviceAuthorization, error) {
+	cache := u.cache.GetCache()
+	key := deviceCodeKeyPrefix + deviceCode
+
+	var authorization *deviceAuthorization
+	txf := func(tx *redis.Tx) error {
+		var err error
+		authorization, err = u.getDeviceAuthorization(ctx, deviceCode)
+		if err != nil {
+			return err
+		}
+		if err := update(authorization); err != nil {
+			return err
+		}
+
+		raw, err := json.Marshal(authorization)
+		if err == nil {
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, raw, redis.KeepTTL)
+				return nil
+			})
+		}
+		if err != nil && !errors.Is(err, redis.TxFailedErr) {
+			u.logger.Error("Failed to update device authorization", zap.Error(err))
+			return domain.ErrInternalServer
+		}
+		return err
+	}
+
+	for i := 0; i < deviceUpdateRetries; i++ {
+		err := cache.Watch(ctx, txf, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return authorization, nil
+	}
+
+	u.logger.Warn("Device authorization kept changing, giving up", zap.Int("retries", deviceUpdateRetries))
+	return nil, domain.ErrInternalServer
+}
+
+func (u *usecase) saveDeviceAuthorization(ctx context.Context, deviceCode string, authorization *deviceAuthorization, ttl time.Duration) error {
+	raw, err := json.Marshal(authorization)
+	if err != nil {
+		return err
+	}
+	return u.cache.GetCache().Set(ctx, deviceCodeKeyPrefix+deviceCode, raw, ttl).Err()
+}
+
+// randomToken returns n random bytes encoded as unpadded base64url.
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func randomUserCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(userCodeAlphabet)))
+	for i := 0; i < userCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(userCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func formatUserCode(code string) string {
+	return fmt.Sprintf("%s-%s", code[:userCodeLength/2], code[userCodeLength/2:])
+}
+
+// normalizeUserCode accepts the code as a user types it: any case, with or
+// without the separator.
+func normalizeUserCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
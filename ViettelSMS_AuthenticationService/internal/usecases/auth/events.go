@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+)
+
+const (
+	eventSource = "auth-service"
+
+	ruleHashFormat = "hash_format"
+	redacted       = "[REDACTED]"
+)
+
+// modularCryptFormat matches the "$id$" prefix shared by bcrypt, argon2 and
+// the other crypt(3) style hashes. A password that starts this way but is not
+// a hash we support is refused rather than stored as plaintext or as a hash
+// nothing can verify.
+var modularCryptFormat = regexp.MustCompile(`^\$[a-z0-9-]{1,32}\$`)
+
+// sensitivePayloadKeys are replaced before an event payload is logged.
+var sensitivePayloadKeys = []string{"password", "new_password", "current_password"}
+
+// publishEvent sends an event on AUTH_TOPIC using the same envelope as the
+// user events this service consumes, keyed so events for one user stay ordered.
+func (u *usecase) publishEvent(event, key string, payload map[string]interface{}) error {
+	body, err := json.Marshal(dto.UserEvent{
+		Event:   event,
+		Payload: payload,
+	})
+	if err != nil {
+		u.logger.Error("Failed to marshal event", zap.String("event", event), zap.Error(err))
+		return err
+	}
+
+	if err := u.broker.Send(mq.Message{
+		Key:   key,
+		Topic: mq.AUTH_TOPIC,
+		Body:  body,
+		Headers: map[string]string{
+			"event":  event,
+			"source": eventSource,
+		},
+	}); err != nil {
+		u.logger.Error("Failed to publish event", zap.String("event", event), zap.String("key", key), zap.Error(err))
+		return err
+	}
+
+	u.logger.Info("Event published", zap.String("event", event), zap.String("key", key))
+	return nil
+}
+
+// ingestEventPassword turns the password carried by a user event into the
+// hash to store. A valid hash in a supported format is kept as is, plaintext is
+// checked against the policy and history and then hashed, and anything that
+// looks like an unsupported hash is refused. Refusals are reported back on
+// AUTH_TOPIC and returned as a *domain.PasswordPolicyError.
+func (u *usecase) ingestEventPassword(ctx context.Context, user *entity.AuthUser, event, password string) (string, error) {
+	if u.password.Supports(password) {
+		// The hash is stored and later verified as is, so it must decode and
+		// keep its cost within limits.
+		if err := u.password.Validate(password); err != nil {
+			u.logger.Warn("Password hash from user event rejected",
+				zap.String("user_name", user.Username),
+				zap.String("event", event),
+				zap.Error(err))
+			violations := []dto.PolicyViolation{{
+				Rule:    ruleHashFormat,
+				Message: "is not a valid hash or its cost parameters exceed the accepted limits",
+			}}
+			u.publishRejection(user.Username, event, violations)
+			return "", domain.NewPasswordPolicyError(violations)
+		}
+		if err := u.checkPasswordHistory(ctx, user, password, true); err != nil {
+			return "", u.eventPasswordRejected(user.Username, event, err)
+		}
+		return password, nil
+	}
+
+	if modularCryptFormat.MatchString(password) {
+		u.logger.Warn("Password from user event has an unsupported hash format",
+			zap.String("user_name", user.Username),
+			zap.String("event", event))
+		violations := []dto.PolicyViolation{{
+			Rule:    ruleHashFormat,
+			Message: "is hashed with an unsupported algorithm",
+		}}
+		u.publishRejection(user.Username, event, violations)
+		return "", domain.NewPasswordPolicyError(violations)
+	}
+
+	if err := u.checkEventPassword(user.Username, event, password); err != nil {
+		return "", err
+	}
+
+	if err := u.checkPasswordHistory(ctx, user, password, false); err != nil {
+		return "", u.eventPasswordRejected(user.Username, event, err)
+	}
+
+	hashedPassword, err := u.password.Hash(password)
+	if err != nil {
+		u.logger.Error("Failed to hash password", zap.String("user_name", user.Username), zap.Error(err))
+		return "", domain.ErrInternalServer
+	}
+	return hashedPassword, nil
+}
+
+// eventPasswordRejected reports a history rejection back to UserService.
+// Other errors are passed through for the consumer to retry.
+func (u *usecase) eventPasswordRejected(username, event string, err error) error {
+	var policyErr *domain.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return err
+	}
+	u.logger.Warn("Password from user event rejected by history",
+		zap.String("user_name", username),
+		zap.String("event", event))
+	u.publishRejection(username, event, policyErr.Violations)
+	return err
+}
+
+// checkEventPassword applies the password policy to a plaintext password
+// arriving in a user event. A rejected password is reported back on AUTH_TOPIC instead of
+// being retried, since the same payload would fail again.
+func (u *usecase) checkEventPassword(username, event, password string) error {
+	violations := u.passwordViolations(username, password)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	u.logger.Warn("Password from user event rejected by policy",
+		zap.String("user_name", username),
+		zap.String("event", event),
+		zap.Int("violations", len(violations)))
+
+	u.publishRejection(username, event, violations)
+	return domain.NewPasswordPolicyError(violations)
+}
+
+// publishRejection tells UserService that the password it sent with event was
+// not accepted. Publish failures are already logged by publishEvent.
+func (u *usecase) publishRejection(username, event string, violations []dto.PolicyViolation) {
+	_ = u.publishEvent(mq.EventUserPasswordRejected, username, map[string]interface{}{
+		"user_name":  username,
+		"event":      event,
+		"violations": violations,
+	})
+}
+
+// redactPayload returns a copy of an event payload that is safe to log.
+func redactPayload(payload map[string]interface{}) map[string]interface{} {
+	safe := make(map[string]interface{}, len(payload))
+	for key, value := range payload {
+		safe[key] = value
+	}
+	for _, key := range sensitivePayloadKeys {
+		if _, ok := safe[key]; ok {
+			safe[key] = redacted
+		}
+	}
+	return safe
+}
@@ -0,0 +1,530 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var groupNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+const (
+	scopeSourceUser  = "user"
+	scopeSourceRole  = "role"
+	scopeSourceGroup = "group"
+)
+
+// scopeGrant is a scope as granted, before expansion through the scope
+// grammar, and where it came from.
+type scopeGrant struct {
+	scope  string
+	source dto.ScopeSource
+}
+
+// userGrants is what a user holds: its own scopes, its roles and everything
+// inherited through groups.
+type userGrants struct {
+	roles  []*entity.Role
+	grants []scopeGrant
+}
+
+func (g *userGrants) scopes() []string {
+	scopes := make([]string, len(g.grants))
+	for i, grant := range g.grants {
+		scopes[i] = grant.scope
+	}
+	return scopes
+}
+
+func (g *userGrants) roleNames() []string {
+	names := make([]string, len(g.roles))
+	for i, role := range g.roles {
+		names[i] = role.Name
+	}
+	return names
+}
+
+func (g *userGrants) addRole(role *entity.Role, source dto.ScopeSource) {
+	if !slices.ContainsFunc(g.roles, func(held *entity.Role) bool { return held.ID == role.ID }) {
+		g.roles = append(g.roles, role)
+	}
+	source.Type, source.Role = scopeSourceRole, role.Name
+	for _, scope := range role.ScopeNames() {
+		g.grants = append(g.grants, scopeGrant{scope: scope, source: source})
+	}
+}
+
+// resolveGrants collects the grants of a user, following group nesting.
+func (u *usecase) resolveGrants(ctx context.Context, user *entity.AuthUser) (*userGrants, error) {
+	result := &userGrants{}
+	for _, scope := range user.Scopes {
+		result.grants = append(result.grants, scopeGrant{scope: scope, source: dto.ScopeSource{Type: scopeSourceUser}})
+	}
+
+	roles, err := u.repo.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		result.addRole(role, dto.ScopeSource{})
+	}
+
+	groupIDs, err := u.repo.GetUserGroupIDs(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+
+	groups, err := u.repo.ListGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	allRoles, err := u.repo.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rolesByID := make(map[uint]*entity.Role, len(allRoles))
+	for _, role := range allRoles {
+		rolesByID[role.ID] = role
+	}
+
+	for _, membership := range groupMemberships(groupsByID(groups), groupIDs) {
+		group := membership.group
+		source := dto.ScopeSource{Group: group.Name, Path: membership.path}
+		for _, scope := range group.ScopeNames() {
+			groupSource := source
+			groupSource.Type = scopeSourceGroup
+			result.grants = append(result.grants, scopeGrant{scope: scope, source: groupSource})
+		}
+		for _, groupRole := range group.Roles {
+			if role, ok := rolesByID[groupRole.RoleID]; ok {
+				result.addRole(role, source)
+			}
+		}
+	}
+	return result, nil
+}
+
+type groupMembership struct {
+	group *entity.Group
+	// path runs from the group the user belongs to up to group.
+	path []string
+}
+
+// groupMemberships walks from the user's groups up through their parents,
+// breadth first so each group is reached by its shortest path. Visiting a
+// group once also keeps a cycle that slipped into the table from looping.
+func groupMemberships(byID map[uint]*entity.Group, direct []uint) []groupMembership {
+	var queue []groupMembership
+	for _, id := range direct {
+		if group, ok := byID[id]; ok {
+			queue = append(queue, groupMembership{group: group, path: []string{group.Name}})
+		}
+	}
+	slices.SortFunc(queue, func(a, b groupMembership) int { return strings.Compare(a.group.Name, b.group.Name) })
+
+	visited := map[uint]bool{}
+	var result []groupMembership
+	for len(queue) > 0 {
+		membership := queue[0]
+		queue = queue[1:]
+		if visited[membership.group.ID] {
+			continue
+		}
+		visited[membership.group.ID] = true
+		result = append(result, membership)
+
+		for _, parent := range membership.group.Parents {
+			if group, ok := byID[parent.ParentID]; ok && !visited[group.ID] {
+				path := append(slices.Clone(membership.path), group.Name)
+				queue = append(queue, groupMembership{group: group, path: path})
+			}
+		}
+	}
+	return result
+}
+
+// GetEffectiveScopes lists the scopes the user's next token will carry and
+// where each comes from.
+func (u *usecase) GetEffectiveScopes(ctx context.Context, userName string) (*dto.EffectiveScopesResponse, error) {
+	user, err := u.getUserByUserName(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := u.resolveGrants(ctx, user)
+	if err != nil {
+		u.logger.Error("Failed to resolve user scopes", zap.String("user_name", userName), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	byScope := map[string]*dto.EffectiveScope{}
+	for _, grant := range grants.grants {
+		for _, scope := range u.scopes.Expand([]string{grant.scope}) {
+			source := grant.source
+			if scope != grant.scope {
+				source.Grant = grant.scope
+			}
+			effective, ok := byScope[scope]
+			if !ok {
+				effective = &dto.EffectiveScope{Scope: scope}
+				byScope[scope] = effective
+			}
+			effective.Sources = append(effective.Sources, source)
+		}
+	}
+
+	result := &dto.EffectiveScopesResponse{UserName: user.Username, Scopes: []*dto.EffectiveScope{}}
+	for _, effective := range byScope {
+		result.Scopes = append(result.Scopes, effective)
+	}
+	slices.SortFunc(result.Scopes, func(a, b *dto.EffectiveScope) int { return strings.Compare(a.Scope, b.Scope) })
+	return result, nil
+}
+
+func (u *usecase) ListGroups(ctx context.Context) ([]*dto.GroupResponse, error) {
+	groups, err := u.repo.ListGroups(ctx)
+	if err != nil {
+		u.logger.Error("Failed to list groups", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	return u.toGroupResponses(ctx, groups)
+}
+
+func (u *usecase) CreateGroup(ctx context.Context, req dto.GroupRequest, adminID uint) (*dto.GroupResponse, error) {
+	if !groupNamePattern.MatchString(req.Name) {
+		return nil, domain.ErrInvalidGroup
+	}
+
+	group := &entity.Group{Name: req.Name}
+	if err := u.defineGroup(ctx, group, req.Description, req.Scopes, req.Roles, req.Parents); err != nil {
+		return nil, err
+	}
+	if err := u.repo.CreateGroup(ctx, group); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, domain.ErrGroupConflict
+		}
+		u.logger.Error("Failed to create group", zap.String("group", req.Name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	u.logger.Info("Group created", zap.String("group", group.Name), zap.Uint("by", adminID))
+	return u.publishGroup(ctx, mq.EventGroupCreated, group)
+}
+
+func (u *usecase) UpdateGroup(ctx context.Context, name string, req dto.GroupUpdateRequest, adminID uint) (*dto.GroupResponse, error) {
+	group, err := u.getGroupByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.defineGroup(ctx, group, req.Description, req.Scopes, req.Roles, req.Parents); err != nil {
+		return nil, err
+	}
+	err = u.repo.UpdateGroup(ctx, group, func(groups []*entity.Group) error {
+		return u.checkNesting(group, groups)
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrGroupCycle) || errors.Is(err, domain.ErrGroupNotFound) {
+			return nil, err
+		}
+		u.logger.Error("Failed to update group", zap.String("group", name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	u.logger.Info("Group updated", zap.String("group", group.Name), zap.Uint("by", adminID))
+	return u.publishGroup(ctx, mq.EventGroupUpdated, group)
+}
+
+// DeleteGroup removes a group. Its members and nested groups lose what it
+// granted, from their next token.
+func (u *usecase) DeleteGroup(ctx context.Context, name string, adminID uint) error {
+	group, err := u.getGroupByName(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.DeleteGroup(ctx, group.ID); err != nil {
+		u.logger.Error("Failed to delete group", zap.String("group", name), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	u.logger.Info("Group deleted", zap.String("group", name), zap.Uint("by", adminID))
+	_ = u.publishEvent(mq.EventGroupDeleted, group.Name, map[string]interface{}{
+		"name": group.Name,
+	})
+	return nil
+}
+
+func (u *usecase) GetGroupMembers(ctx context.Context, name string) ([]string, error) {
+	group, err := u.getGroupByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := u.repo.GetGroupMembers(ctx, group.ID)
+	if err != nil {
+		u.logger.Error("Failed to retrieve group members", zap.String("group", name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	result := make([]string, len(users))
+	for i, user := range users {
+		result[i] = user.Username
+	}
+	return result, nil
+}
+
+func (u *usecase) AddGroupMember(ctx context.Context, groupName, userName string, adminID uint) error {
+	group, user, err := u.groupMember(ctx, groupName, userName)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.AddGroupMember(ctx, group.ID, user.ID); err != nil {
+		u.logger.Error("Failed to add group member", zap.String("group", groupName), zap.String("user_name", userName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	u.logger.Info("Group member added", zap.String("group", groupName), zap.String("user_name", userName), zap.Uint("by", adminID))
+	_ = u.publishEvent(mq.EventGroupMemberAdded, userName, map[string]interface{}{
+		"user_name": userName,
+		"group":     groupName,
+	})
+	return nil
+}
+
+func (u *usecase) RemoveGroupMember(ctx context.Context, groupName, userName string, adminID uint) error {
+	group, user, err := u.groupMember(ctx, groupName, userName)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.RemoveGroupMember(ctx, group.ID, user.ID); err != nil {
+		u.logger.Error("Failed to remove group member", zap.String("group", groupName), zap.String("user_name", userName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	u.logger.Info("Group member removed", zap.String("group", groupName), zap.String("user_name", userName), zap.Uint("by", adminID))
+	_ = u.publishEvent(mq.EventGroupMemberRemoved, userName, map[string]interface{}{
+		"user_name": userName,
+		"group":     groupName,
+	})
+	return nil
+}
+
+func (u *usecase) groupMember(ctx context.Context, groupName, userName string) (*entity.Group, *entity.AuthUser, error) {
+	group, err := u.getGroupByName(ctx, groupName)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := u.getUserByUserName(ctx, userName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return group, user, nil
+}
+
+// defineGroup sets what group grants and where it is nested, after checking
+// every scope, role and parent exists. Whether the nesting forms a cycle is
+// left to checkNesting, which runs as the group is saved.
+func (u *usecase) defineGroup(ctx context.Context, group *entity.Group, description string, scopes, roleNames, parentNames []string) error {
+	valid, err := u.validScopes(ctx, scopes)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return domain.ErrInvalidGroup
+	}
+
+	var roles []entity.GroupRole
+	for _, name := range uniqueNames(roleNames) {
+		role, err := u.getRoleByName(ctx, name)
+		if err != nil {
+			return err
+		}
+		roles = append(roles, entity.GroupRole{GroupID: group.ID, RoleID: role.ID})
+	}
+
+	var parents []entity.GroupParent
+	if len(parentNames) > 0 {
+		groups, err := u.repo.ListGroups(ctx)
+		if err != nil {
+			u.logger.Error("Failed to list groups", zap.Error(err))
+			return domain.ErrInternalServer
+		}
+		byName := map[string]*entity.Group{}
+		for _, g := range groups {
+			byName[g.Name] = g
+		}
+
+		for _, name := range uniqueNames(parentNames) {
+			parent, ok := byName[name]
+			if !ok {
+				u.logger.Warn("Group not found", zap.String("group", name))
+				return domain.ErrGroupNotFound
+			}
+			parents = append(parents, entity.GroupParent{GroupID: group.ID, ParentID: parent.ID})
+		}
+	}
+
+	group.Description = description
+	group.Scopes = groupScopes(scopes)
+	group.Roles = roles
+	group.Parents = parents
+	return nil
+}
+
+// checkNesting refuses parents of group that are the group itself or nested
+// in it, directly or not, given every group as currently stored. A new group
+// has nothing nested in it yet, so only updates are checked.
+func (u *usecase) checkNesting(group *entity.Group, groups []*entity.Group) error {
+	byID := groupsByID(groups)
+	for _, parent := range group.Parents {
+		stored, ok := byID[parent.ParentID]
+		if !ok {
+			u.logger.Warn("Parent group deleted", zap.String("group", group.Name), zap.Uint("parent_id", parent.ParentID))
+			return domain.ErrGroupNotFound
+		}
+		if nestedIn(byID, parent.ParentID, group.ID) {
+			u.logger.Warn("Refusing cyclic group nesting", zap.String("group", group.Name), zap.String("parent", stored.Name))
+			return domain.ErrGroupCycle
+		}
+	}
+	return nil
+}
+
+// nestedIn reports whether group id is ancestor or sits below it, following
+// parents.
+func nestedIn(byID map[uint]*entity.Group, id, ancestor uint) bool {
+	visited := map[uint]bool{}
+	queue := []uint{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == ancestor {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		if group, ok := byID[current]; ok {
+			for _, parent := range group.Parents {
+				queue = append(queue, parent.ParentID)
+			}
+		}
+	}
+	return false
+}
+
+func (u *usecase) getGroupByName(ctx context.Context, name string) (*entity.Group, error) {
+	group, err := u.repo.GetGroupByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Warn("Group not found", zap.String("group", name))
+			return nil, domain.ErrGroupNotFound
+		}
+		u.logger.Error("Failed to retrieve group", zap.String("group", name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	return group, nil
+}
+
+func (u *usecase) publishGroup(ctx context.Context, event string, group *entity.Group) (*dto.GroupResponse, error) {
+	responses, err := u.toGroupResponses(ctx, []*entity.Group{group})
+	if err != nil {
+		return nil, err
+	}
+
+	response := responses[0]
+	_ = u.publishEvent(event, group.Name, map[string]interface{}{
+		"name":        response.Name,
+		"description": response.Description,
+		"scopes":      response.Scopes,
+		"roles":       response.Roles,
+		"parents":     response.Parents,
+	})
+	return response, nil
+}
+
+// toGroupResponses names the roles and parents of groups, which are stored
+// by ID.
+func (u *usecase) toGroupResponses(ctx context.Context, groups []*entity.Group) ([]*dto.GroupResponse, error) {
+	roles, err := u.repo.ListRoles(ctx)
+	if err != nil {
+		u.logger.Error("Failed to list roles", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	allGroups, err := u.repo.ListGroups(ctx)
+	if err != nil {
+		u.logger.Error("Failed to list groups", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	roleNames := make(map[uint]string, len(roles))
+	for _, role := range roles {
+		roleNames[role.ID] = role.Name
+	}
+	groupNames := make(map[uint]string, len(allGroups))
+	for _, group := range allGroups {
+		groupNames[group.ID] = group.Name
+	}
+
+	result := make([]*dto.GroupResponse, len(groups))
+	for i, group := range groups {
+		response := &dto.GroupResponse{
+			Name:        group.Name,
+			Description: group.Description,
+			Scopes:      group.ScopeNames(),
+			Roles:       []string{},
+			Parents:     []string{},
+		}
+		for _, role := range group.Roles {
+			response.Roles = append(response.Roles, roleNames[role.RoleID])
+		}
+		for _, parent := range group.Parents {
+			response.Parents = append(response.Parents, groupNames[parent.ParentID])
+		}
+		slices.Sort(response.Roles)
+		slices.Sort(response.Parents)
+		result[i] = response
+	}
+	return result, nil
+}
+
+func groupsByID(groups []*entity.Group) map[uint]*entity.Group {
+	byID := make(map[uint]*entity.Group, len(groups))
+	for _, group := range groups {
+		byID[group.ID] = group
+	}
+	return byID
+}
+
+func groupScopes(scopes []string) []entity.GroupScope {
+	unique := uniqueNames(scopes)
+	result := make([]entity.GroupScope, len(unique))
+	for i, scope := range unique {
+		result[i] = entity.GroupScope{Scope: scope}
+	}
+	return result
+}
+
+// uniqueNames sorts names and drops duplicates and blanks.
+func uniqueNames(names []string) []string {
+	unique := slices.Clone(names)
+	slices.Sort(unique)
+	unique = slices.Compact(unique)
+	return slices.DeleteFunc(unique, func(name string) bool { return name == "" })
+}
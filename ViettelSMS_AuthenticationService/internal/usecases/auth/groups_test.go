@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	repo "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/repository"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/scope"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// testGroups builds groups from "name:parent,parent" specs, numbering them
+// from 1 in order.
+func testGroups(specs ...string) []*entity.Group {
+	ids := map[string]uint{}
+	for i, spec := range specs {
+		name, _, _ := strings.Cut(spec, ":")
+		ids[name] = uint(i + 1)
+	}
+
+	groups := make([]*entity.Group, len(specs))
+	for i, spec := range specs {
+		name, parents, _ := strings.Cut(spec, ":")
+		group := &entity.Group{ID: ids[name], Name: name}
+		for _, parent := range strings.Split(parents, ",") {
+			if parent != "" {
+				group.Parents = append(group.Parents, entity.GroupParent{GroupID: group.ID, ParentID: ids[parent]})
+			}
+		}
+		groups[i] = group
+	}
+	return groups
+}
+
+func TestNestedIn(t *testing.T) {
+	// dev and ops are nested in eng, eng in all; loop-a and loop-b form a
+	// cycle that slipped into the table.
+	groups := testGroups("all", "eng:all", "dev:eng", "ops:eng,all", "sales", "loop-a:loop-b", "loop-b:loop-a")
+	byID := groupsByID(groups)
+	id := func(name string) uint {
+		for _, group := range groups {
+			if group.Name == name {
+				return group.ID
+			}
+		}
+		return 99
+	}
+
+	tests := []struct {
+		group    string
+		ancestor string
+		want     bool
+	}{
+		{"dev", "dev", true},
+		{"dev", "eng", true},
+		{"dev", "all", true},
+		{"ops", "all", true},
+		{"all", "dev", false},
+		{"eng", "dev", false},
+		{"dev", "ops", false},
+		{"sales", "all", false},
+		{"loop-a", "loop-b", true},
+		{"loop-a", "all", false},
+		{"unknown", "all", false},
+	}
+	for _, tt := range tests {
+		if got := nestedIn(byID, id(tt.group), id(tt.ancestor)); got != tt.want {
+			t.Errorf("nestedIn(%s, %s) = %v, want %v", tt.group, tt.ancestor, got, tt.want)
+		}
+	}
+}
+
+func TestGroupMemberships(t *testing.T) {
+	groups := testGroups("all", "eng:all", "dev:eng", "ops:eng,all", "sales", "loop-a:loop-b", "loop-b:loop-a")
+	byID := groupsByID(groups)
+	idsOf := func(names ...string) []uint {
+		var ids []uint
+		for _, group := range groups {
+			if slices.Contains(names, group.Name) {
+				ids = append(ids, group.ID)
+			}
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		direct []uint
+		want   []string
+	}{
+		{"none", nil, nil},
+		{"top group", idsOf("all"), []string{"all"}},
+		{"nested", idsOf("dev"), []string{"dev", "dev>eng", "dev>eng>all"}},
+		{"shortest path wins", idsOf("ops"), []string{"ops", "ops>eng", "ops>all"}},
+		{"direct beats inherited", idsOf("dev", "all"), []string{"all", "dev", "dev>eng"}},
+		{"siblings share ancestors once", idsOf("dev", "ops"), []string{"dev", "ops", "dev>eng", "ops>all"}},
+		{"cycle visited once", idsOf("loop-a"), []string{"loop-a", "loop-a>loop-b"}},
+		{"unknown group ignored", []uint{99}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, membership := range groupMemberships(byID, tt.direct) {
+				if membership.path[len(membership.path)-1] != membership.group.Name {
+					t.Errorf("path %q does not end at %s", membership.path, membership.group.Name)
+				}
+				got = append(got, strings.Join(membership.path, ">"))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("groupMemberships = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// groupRepo stores groups in memory. concurrent, when set, runs inside
+// UpdateGroup before the nesting check, as an update committed between the
+// read in defineGroup and the lock would.
+type groupRepo struct {
+	repo.Repository
+	groups     []*entity.Group
+	concurrent func(groups []*entity.Group)
+	saved      *entity.Group
+}
+
+func (r *groupRepo) ListGroups(context.Context) ([]*entity.Group, error) {
+	return r.groups, nil
+}
+
+func (r *groupRepo) GetGroupByName(_ context.Context, name string) (*entity.Group, error) {
+	for _, group := range r.groups {
+		if group.Name == name {
+			copied := *group
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *groupRepo) UpdateGroup(_ context.Context, group *entity.Group, checkNesting func([]*entity.Group) error) error {
+	if r.concurrent != nil {
+		r.concurrent(r.groups)
+	}
+	if err := checkNesting(r.groups); err != nil {
+		return err
+	}
+	r.saved = group
+	return nil
+}
+
+func (r *groupRepo) ListScopes(context.Context) ([]*entity.Scope, error) {
+	return nil, nil
+}
+
+func (r *groupRepo) ListRoles(context.Context) ([]*entity.Role, error) {
+	return nil, nil
+}
+
+func TestUpdateGroupNesting(t *testing.T) {
+	matcher, err := scope.NewMatcher(scope.Grammar{})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		group      string
+		parents    []string
+		concurrent func(groups []*entity.Group)
+		wantErr    error
+	}{
+		{"new parent", "dev", []string{"ops"}, nil, nil},
+		{"unchanged", "dev", []string{"eng"}, nil, nil},
+		{"no parents", "eng", nil, nil, nil},
+		{"itself", "eng", []string{"eng"}, nil, domain.ErrGroupCycle},
+		{"direct child", "eng", []string{"dev"}, nil, domain.ErrGroupCycle},
+		{"indirect child", "all", []string{"dev"}, nil, domain.ErrGroupCycle},
+		{"unknown parent", "dev", []string{"nobody"}, nil, domain.ErrGroupNotFound},
+		{"cycle from a concurrent update", "ops", []string{"dev"}, func(groups []*entity.Group) {
+			// dev is nested in ops after defineGroup read the groups.
+			groups[2].Parents = append(groups[2].Parents, entity.GroupParent{GroupID: 3, ParentID: 4})
+		}, domain.ErrGroupCycle},
+		{"parent deleted concurrently", "dev", []string{"ops"}, func(groups []*entity.Group) {
+			groups[3] = &entity.Group{ID: 5, Name: "ops-new"}
+		}, domain.ErrGroupNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &groupRepo{groups: testGroups("all", "eng:all", "dev:eng", "ops"), concurrent: tt.concurrent}
+			u := &usecase{repo: r, broker: &recordingBroker{}, scopes: matcher, logger: zap.NewNop()}
+
+			_, err := u.UpdateGroup(context.Background(), tt.group, dto.GroupUpdateRequest{Parents: tt.parents}, 1)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("UpdateGroup error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && r.saved != nil {
+				t.Error("UpdateGroup saved a refused group")
+			}
+			if tt.wantErr == nil && (r.saved == nil || len(r.saved.Parents) != len(tt.parents)) {
+				t.Errorf("UpdateGroup saved %+v, want %d parents", r.saved, len(tt.parents))
+			}
+		})
+	}
+}
@@ -2,13 +2,59 @@ package auth
 
 import (
 	"context"
+	"crypto/x509"
 
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
 )
 
 type UseCase interface {
-	Login(ctx context.Context, userName, password string) (*dto.LoginResponse, error)
+	Login(ctx context.Context, req dto.LoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	VerifyLogin(ctx context.Context, req dto.LoginVerifyRequest, client dto.ClientInfo) (*dto.LoginResponse, error)
+	IssueChallenge(ctx context.Context) (*dto.ChallengeResponse, error)
 	RefreshToken(ctx context.Context, userID uint) (*string, error)
+	IssueMachineToken(ctx context.Context, cert *x509.Certificate) (*dto.MachineTokenResponse, error)
+
+	RequestDeviceCode(ctx context.Context, clientID string) (*dto.DeviceCodeResponse, error)
+	ApproveDeviceCode(ctx context.Context, userID uint, userCode string, approve bool) error
+	ExchangeDeviceCode(ctx context.Context, deviceCode, clientID string, client dto.ClientInfo) (*dto.TokenResponse, error)
+
+	ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+	ChangePassword(ctx context.Context, userID uint, sessionID, currentPassword, newPassword string) error
+
+	UnlockUser(ctx context.Context, userName string, adminID uint) error
+
+	ListRoles(ctx context.Context) ([]*dto.RoleResponse, error)
+	CreateRole(ctx context.Context, req dto.RoleRequest, adminID uint) (*dto.RoleResponse, error)
+	UpdateRole(ctx context.Context, name string, req dto.RoleUpdateRequest, adminID uint) (*dto.RoleResponse, error)
+	DeleteRole(ctx context.Context, name string, adminID uint) error
+	GetUserRoles(ctx context.Context, userName string) ([]*dto.RoleResponse, error)
+	AssignRole(ctx context.Context, userName, roleName string, adminID uint) error
+	UnassignRole(ctx context.Context, userName, roleName string, adminID uint) error
+
+	ListGroups(ctx context.Context) ([]*dto.GroupResponse, error)
+	CreateGroup(ctx context.Context, req dto.GroupRequest, adminID uint) (*dto.GroupResponse, error)
+	UpdateGroup(ctx context.Context, name string, req dto.GroupUpdateRequest, adminID uint) (*dto.GroupResponse, error)
+	DeleteGroup(ctx context.Context, name string, adminID uint) error
+	GetGroupMembers(ctx context.Context, name string) ([]string, error)
+	AddGroupMember(ctx context.Context, groupName, userName string, adminID uint) error
+	RemoveGroupMember(ctx context.Context, groupName, userName string, adminID uint) error
+	GetEffectiveScopes(ctx context.Context, userName string) (*dto.EffectiveScopesResponse, error)
+
+	ListScopes(ctx context.Context) ([]*dto.ScopeResponse, error)
+
+	ListIPRules(ctx context.Context) ([]*dto.IPRuleResponse, error)
+	CreateIPRule(ctx context.Context, req dto.IPRuleRequest, adminID uint) (*dto.IPRuleResponse, error)
+	DeleteIPRule(ctx context.Context, id uint, adminID uint) error
+
+	Authorize(ctx context.Context, req dto.AuthorizeRequest) (*dto.AuthorizeResponse, error)
+	Introspect(ctx context.Context, req dto.IntrospectRequest) (*dto.IntrospectResponse, error)
+
+	ListResourcePermissions(ctx context.Context, resourceType string) ([]*dto.ResourcePermissionResponse, error)
+	CreateResourcePermission(ctx context.Context, req dto.ResourcePermissionRequest, adminID uint) (*dto.ResourcePermissionResponse, error)
+	DeleteResourcePermission(ctx context.Context, id uint, adminID uint) error
+	CheckPermission(ctx context.Context, req dto.PermissionCheckRequest) (*dto.PermissionCheckResponse, error)
+	PermittedResources(ctx context.Context, req dto.PermittedResourcesRequest) (*dto.PermittedResourcesResponse, error)
 
 	CreateAuthUser(ctx context.Context, payload map[string]interface{}) error
 	UpdateAuthUser(ctx context.Context, payload map[string]interface{}) error
@@ -16,4 +62,10 @@ type UseCase interface {
 	UpdateAuthPassword(ctx context.Context, payload map[string]interface{}) error
 	AddAuthScope(ctx context.Context, payload map[string]interface{}) error
 	DeleteAuthScope(ctx context.Context, payload map[string]interface{}) error
+	SaveAuthRole(ctx context.Context, payload map[string]interface{}) error
+	DeleteAuthRole(ctx context.Context, payload map[string]interface{}) error
+	AssignAuthRole(ctx context.Context, payload map[string]interface{}) error
+	UnassignAuthRole(ctx context.Context, payload map[string]interface{}) error
+	RegisterAuthServer(ctx context.Context, payload map[string]interface{}) error
+	DeleteAuthServer(ctx context.Context, payload map[string]interface{}) error
 }
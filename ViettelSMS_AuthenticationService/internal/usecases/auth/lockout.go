@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	loginFailuresKeyPrefix = "auth:login_failures:"
+	lockoutCountKeyPrefix  = "auth:lockouts:"
+
+	unlockReasonAdmin   = "admin"
+	unlockReasonExpired = "expired"
+)
+
+// isLocked reports whether the account is inside a lockout period.
+func isLocked(user *entity.AuthUser) bool {
+	return user.LockedUntil != nil && time.Now().Before(*user.LockedUntil)
+}
+
+// registerFailedLogin counts a wrong password within the lockout window and
+// locks the account once MaxAttempts is reached. The counter lives in Redis
+// and is bumped with INCR, so concurrent failures are never lost, and the
+// lock itself only writes locked_until. Each lockout within Decay of
+// the previous one doubles the duration, up to MaxDuration.
+func (u *usecase) registerFailedLogin(ctx context.Context, user *entity.AuthUser) {
+	cfg := u.config.Lockout
+	if cfg.MaxAttempts <= 0 {
+		return
+	}
+
+	cache := u.cache.GetCache()
+	key := loginFailuresKeyPrefix + strconv.FormatUint(uint64(user.ID), 10)
+
+	failures, err := cache.Incr(ctx, key).Result()
+	if err != nil {
+		u.logger.Error("Failed to count failed login", zap.String("user_name", user.Username), zap.Error(err))
+		return
+	}
+	if failures == 1 {
+		cache.Expire(ctx, key, cfg.Window)
+	}
+	if failures < int64(cfg.MaxAttempts) {
+		return
+	}
+
+	countKey := lockoutCountKeyPrefix + strconv.FormatUint(uint64(user.ID), 10)
+	lockouts, err := cache.Incr(ctx, countKey).Result()
+	if err != nil {
+		u.logger.Error("Failed to count lockouts", zap.String("user_name", user.Username), zap.Error(err))
+		lockouts = 1
+	}
+	cache.Expire(ctx, countKey, cfg.Decay)
+
+	duration := lockoutDuration(cfg.BaseDuration, cfg.MaxDuration, lockouts)
+	lockedUntil := time.Now().Add(duration)
+	user.LockedUntil = &lockedUntil
+
+	if err := u.repo.UpdateUserColumns(ctx, user.ID, map[string]interface{}{"locked_until": lockedUntil}); err != nil {
+		u.logger.Error("Failed to lock account", zap.String("user_name", user.Username), zap.Error(err))
+		return
+	}
+	cache.Del(ctx, key)
+
+	u.logger.Warn("Account locked after failed logins",
+		zap.String("user_name", user.Username),
+		zap.Int64("failures", failures),
+		zap.Duration("duration", duration))
+
+	_ = u.publishEvent(mq.EventAccountLocked, user.Username, map[string]interface{}{
+		"user_name":       user.Username,
+		"locked_until":    lockedUntil.UTC().Format(time.RFC3339),
+		"failed_attempts": failures,
+		"reason":          "failed_logins",
+	})
+}
+
+// clearFailedLogins resets the failure counter after a successful login and
+// lifts a lockout that has run out.
+func (u *usecase) clearFailedLogins(ctx context.Context, user *entity.AuthUser) {
+	u.cache.GetCache().Del(ctx, loginFailuresKeyPrefix+strconv.FormatUint(uint64(user.ID), 10))
+
+	if user.LockedUntil == nil {
+		return
+	}
+
+	user.LockedUntil = nil
+	if err := u.repo.UpdateUserColumns(ctx, user.ID, map[string]interface{}{"locked_until": nil}); err != nil {
+		u.logger.Error("Failed to clear expired lockout", zap.String("user_name", user.Username), zap.Error(err))
+		return
+	}
+	u.publishUnlocked(user.Username, unlockReasonExpired, 0)
+}
+
+func (u *usecase) UnlockUser(ctx context.Context, userName string, adminID uint) error {
+	user, err := u.repo.GetUserByUsername(ctx, userName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Warn("User not found", zap.String("user_name", userName))
+			return domain.ErrUserNotFound
+		}
+		u.logger.Error("Failed to retrieve user", zap.String("user_name", userName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	id := strconv.FormatUint(uint64(user.ID), 10)
+	if err := u.cache.GetCache().Del(ctx, loginFailuresKeyPrefix+id, lockoutCountKeyPrefix+id).Err(); err != nil {
+		u.logger.Error("Failed to reset failed logins", zap.String("user_name", userName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	wasLocked := isLocked(user)
+	if user.LockedUntil != nil {
+		user.LockedUntil = nil
+		if err := u.repo.UpdateUserColumns(ctx, user.ID, map[string]interface{}{"locked_until": nil}); err != nil {
+			u.logger.Error("Failed to unlock account", zap.String("user_name", userName), zap.Error(err))
+			return domain.ErrInternalServer
+		}
+	}
+
+	if wasLocked {
+		u.publishUnlocked(user.Username, unlockReasonAdmin, adminID)
+	}
+
+	u.logger.Info("Account unlocked", zap.String("user_name", userName), zap.Uint("by", adminID))
+	return nil
+}
+
+func (u *usecase) publishUnlocked(username, reason string, adminID uint) {
+	payload := map[string]interface{}{
+		"user_name": username,
+		"reason":    reason,
+	}
+	if adminID != 0 {
+		payload["unlocked_by"] = adminID
+	}
+	_ = u.publishEvent(mq.EventAccountUnlocked, username, payload)
+}
+
+func lockoutDuration(base, limit time.Duration, lockouts int64) time.Duration {
+	duration := base
+	for i := int64(1); i < lockouts && duration < limit; i++ {
+		duration *= 2
+	}
+	if limit > 0 && duration > limit {
+		return limit
+	}
+	return duration
+}
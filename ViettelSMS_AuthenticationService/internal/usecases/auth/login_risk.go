@@ -0,0 +1,420 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	loginMFAKeyPrefix = "auth:login_mfa:"
+	mfaMethodEmailOTP = "email_otp"
+	otpDigits         = 6
+
+	riskNewDevice        = "new_device"
+	riskNewCountry       = "new_country"
+	riskImpossibleTravel = "impossible_travel"
+
+	riskActionStepUp = "step_up"
+	riskActionBlock  = "block"
+
+	// minTravelKm ignores jumps small enough to be GeoIP inaccuracy, such as
+	// a mobile carrier moving a phone between gateways in one country.
+	minTravelKm   = 200
+	earthRadiusKm = 6371
+)
+
+// completeLogin runs the login heuristics for a user whose password checked
+// out. A risky login is blocked or held until the user confirms a one-time
+// code sent by email; anything else is finished right away.
+func (u *usecase) completeLogin(ctx context.Context, user *entity.AuthUser, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	devices, err := u.repo.GetKnownDevices(ctx, user.ID)
+	if err != nil {
+		// Without history the login is scored as if nothing were known,
+		// rather than refusing every login while the table is unavailable.
+		u.logger.Error("Failed to retrieve known devices", zap.String("username", user.Username), zap.Error(err))
+	}
+
+	location, _ := u.geoip.Lookup(client.IP)
+	risk := u.assessLoginRisk(devices, client, location)
+
+	cfg := u.config.Risk
+	switch {
+	case cfg.BlockScore > 0 && risk.Score >= cfg.BlockScore:
+		u.logger.Warn("Login blocked as risky", zap.String("username", user.Username), zap.Int("score", risk.Score), zap.Strings("reasons", risk.Reasons))
+		u.publishRiskyLogin(user, client, location, risk, riskActionBlock)
+		return nil, domain.ErrLoginBlocked
+	case cfg.StepUpScore > 0 && risk.Score >= cfg.StepUpScore:
+		if user.Email == "" {
+			u.logger.Warn("Risky login blocked, no email for verification", zap.String("username", user.Username), zap.Int("score", risk.Score))
+			u.publishRiskyLogin(user, client, location, risk, riskActionBlock)
+			return nil, domain.ErrLoginBlocked
+		}
+		u.logger.Info("Login requires verification", zap.String("username", user.Username), zap.Int("score", risk.Score), zap.Strings("reasons", risk.Reasons))
+		u.publishRiskyLogin(user, client, location, risk, riskActionStepUp)
+		return u.startLoginMFA(ctx, user, client)
+	}
+
+	return u.finishLogin(ctx, user, client, devices, location)
+}
+
+// finishLogin remembers the device and hands out tokens.
+func (u *usecase) finishLogin(ctx context.Context, user *entity.AuthUser, client dto.ClientInfo, devices []*entity.KnownDevice, location *dto.GeoLocation) (*dto.LoginResponse, error) {
+	u.recordDevice(ctx, user, client, devices, location)
+
+	if u.passwordChangeRequired(user) {
+		u.logger.Info("Login restricted until password is changed", zap.String("username", user.Username))
+		return u.issueRestrictedToken(ctx, user)
+	}
+
+	tokens, err := u.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	tokens.PasswordExpiresInDays = u.passwordExpiresInDays(user)
+
+	u.logger.Info("Login successful", zap.String("username", user.Username))
+	return tokens, nil
+}
+
+// assessLoginRisk scores a login against the user's known devices. A user
+// with no known device is logging in for the first time and scores zero:
+// there is nothing to compare against.
+func (u *usecase) assessLoginRisk(devices []*entity.KnownDevice, client dto.ClientInfo, location *dto.GeoLocation) *dto.LoginRisk {
+	cfg := u.config.Risk
+	risk := &dto.LoginRisk{Reasons: []string{}}
+	if len(devices) == 0 {
+		risk.NewDevice = true
+		return risk
+	}
+
+	if findDevice(devices, deviceFingerprint(client)) == nil {
+		risk.NewDevice = true
+		risk.Score += cfg.NewDeviceScore
+		risk.Reasons = append(risk.Reasons, riskNewDevice)
+	}
+
+	if location == nil {
+		return risk
+	}
+
+	knownCountries := map[string]bool{}
+	for _, device := range devices {
+		if device.Country != "" {
+			knownCountries[device.Country] = true
+		}
+	}
+	if len(knownCountries) > 0 && !knownCountries[location.Country] {
+		risk.Score += cfg.NewCountryScore
+		risk.Reasons = append(risk.Reasons, riskNewCountry)
+	}
+
+	// devices is ordered by last_seen_at, so the first located device is
+	// where the previous login came from.
+	for _, last := range devices {
+		if last.Latitude == nil || last.Longitude == nil {
+			continue
+		}
+		if impossibleTravel(last, location, cfg.MaxTravelSpeedKmh) {
+			risk.Score += cfg.TravelScore
+			risk.Reasons = append(risk.Reasons, riskImpossibleTravel)
+		}
+		break
+	}
+
+	return risk
+}
+
+// recordDevice stores the device of a successful login, and tells the user
+// when it is one we have not seen before.
+func (u *usecase) recordDevice(ctx context.Context, user *entity.AuthUser, client dto.ClientInfo, devices []*entity.KnownDevice, location *dto.GeoLocation) {
+	fingerprint := deviceFingerprint(client)
+	now := time.Now()
+
+	device := findDevice(devices, fingerprint)
+	isNew := device == nil
+	if isNew {
+		device = &entity.KnownDevice{
+			UserID:      user.ID,
+			Fingerprint: fingerprint,
+			FirstSeenAt: now,
+		}
+	}
+	device.UserAgent = client.UserAgent
+	device.IP = client.IP
+	device.LastSeenAt = now
+	if location != nil {
+		device.Country = location.Country
+		device.Latitude = &location.Latitude
+		device.Longitude = &location.Longitude
+	}
+
+	if err := u.repo.SaveKnownDevice(ctx, device); err != nil {
+		u.logger.Error("Failed to record login device", zap.String("username", user.Username), zap.Error(err))
+	}
+
+	// The first device of an account is not news to its owner.
+	if !isNew || len(devices) == 0 {
+		return
+	}
+	u.notifyNewDevice(user, device)
+}
+
+func (u *usecase) notifyNewDevice(user *entity.AuthUser, device *entity.KnownDevice) {
+	u.logger.Info("Login from new device", zap.String("username", user.Username), zap.String("ip", device.IP))
+
+	_ = u.publishEvent(mq.EventLoginNewDevice, user.Username, map[string]interface{}{
+		"user_name":   user.Username,
+		"fingerprint": device.Fingerprint,
+		"ip":          device.IP,
+		"user_agent":  device.UserAgent,
+		"country":     device.Country,
+		"seen_at":     device.LastSeenAt.UTC().Format(time.RFC3339),
+	})
+
+	if !u.config.Risk.NotifyNewDevice || user.Email == "" {
+		return
+	}
+
+	go func(email, username string, device entity.KnownDevice) {
+		where := device.IP
+		if device.Country != "" {
+			where = fmt.Sprintf("%s (%s)", device.IP, device.Country)
+		}
+		body := fmt.Sprintf(
+			"Hello %s,\n\nYour ViettelSMS account was just signed in to from a new device.\n\n"+
+				"Time: %s\nFrom: %s\nBrowser: %s\n\n"+
+				"If this was you, you can ignore this email. If not, change your password "+
+				"right away and contact your administrator.\n",
+			username, device.LastSeenAt.UTC().Format(time.RFC1123), where, device.UserAgent,
+		)
+		if err := u.mailer.Send(email, "New sign-in to your ViettelSMS account", body); err != nil {
+			u.logger.Error("Failed to send new device email", zap.String("user_name", username), zap.Error(err))
+			return
+		}
+		u.logger.Info("New device email sent", zap.String("user_name", username))
+	}(user.Email, user.Username, *device)
+}
+
+func (u *usecase) publishRiskyLogin(user *entity.AuthUser, client dto.ClientInfo, location *dto.GeoLocation, risk *dto.LoginRisk, action string) {
+	payload := map[string]interface{}{
+		"user_name":  user.Username,
+		"ip":         client.IP,
+		"user_agent": client.UserAgent,
+		"score":      risk.Score,
+		"reasons":    risk.Reasons,
+		"action":     action,
+	}
+	if location != nil {
+		payload["country"] = location.Country
+	}
+	_ = u.publishEvent(mq.EventLoginRisky, user.Username, payload)
+}
+
+// startLoginMFA emails a one-time code and parks the login under a random
+// token until the code comes back through VerifyLogin.
+func (u *usecase) startLoginMFA(ctx context.Context, user *entity.AuthUser, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		u.logger.Error("Failed to generate mfa token", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	code, err := randomOTP()
+	if err != nil {
+		u.logger.Error("Failed to generate verification code", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	ttl := u.config.Risk.OTPTTL
+	key := loginMFAKeyPrefix + token
+	pipe := u.cache.GetCache().TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"user_id":     user.ID,
+		"code":        otpHash(token, code),
+		"fingerprint": deviceFingerprint(client),
+		"attempts":    0,
+	})
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		u.logger.Error("Failed to store pending login", zap.String("username", user.Username), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	go func(email, username string) {
+		body := fmt.Sprintf(
+			"Hello %s,\n\nWe noticed a sign-in to your ViettelSMS account that does not look like you.\n"+
+				"Enter this code within %s to finish signing in:\n\n%s\n\n"+
+				"If this was not you, change your password right away.\n",
+			username, ttl, code,
+		)
+		if err := u.mailer.Send(email, "Your ViettelSMS verification code", body); err != nil {
+			u.logger.Error("Failed to send verification code", zap.String("user_name", username), zap.Error(err))
+			return
+		}
+		u.logger.Info("Verification code sent", zap.String("user_name", username))
+	}(user.Email, user.Username)
+
+	return &dto.LoginResponse{
+		MFARequired: true,
+		MFAToken:    token,
+		MFAMethod:   mfaMethodEmailOTP,
+	}, nil
+}
+
+// VerifyLogin finishes a login held by startLoginMFA. The code must come from
+// the same device that started the login, and a pending login is dropped
+// after OTPMaxAttempts wrong codes.
+func (u *usecase) VerifyLogin(ctx context.Context, req dto.LoginVerifyRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	cache := u.cache.GetCache()
+	key := loginMFAKeyPrefix + req.MFAToken
+
+	pending, err := cache.HGetAll(ctx, key).Result()
+	if err != nil {
+		u.logger.Error("Failed to retrieve pending login", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	if len(pending) == 0 || pending["fingerprint"] != deviceFingerprint(client) {
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(pending["code"]), []byte(otpHash(req.MFAToken, req.Code))) != 1 {
+		attempts, err := cache.HIncrBy(ctx, key, "attempts", 1).Result()
+		if err != nil || attempts >= int64(u.config.Risk.OTPMaxAttempts) {
+			cache.Del(ctx, key)
+		}
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	// Only the request that deletes the key may finish the login.
+	deleted, err := cache.Del(ctx, key).Result()
+	if err != nil {
+		u.logger.Error("Failed to consume pending login", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	if deleted == 0 {
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	userID, err := strconv.ParseUint(pending["user_id"], 10, 64)
+	if err != nil {
+		return nil, domain.ErrInvalidMFACode
+	}
+	user, err := u.repo.GetUserByID(ctx, uint(userID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrInvalidMFACode
+		}
+		u.logger.Error("Failed to retrieve user", zap.Uint64("userID", userID), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	if isLocked(user) {
+		return nil, domain.ErrInvalidMFACode
+	}
+	if err := u.checkLoginIP(ctx, user, client); err != nil {
+		return nil, err
+	}
+
+	devices, err := u.repo.GetKnownDevices(ctx, user.ID)
+	if err != nil {
+		u.logger.Error("Failed to retrieve known devices", zap.String("username", user.Username), zap.Error(err))
+	}
+	location, _ := u.geoip.Lookup(client.IP)
+
+	u.logger.Info("Login verified", zap.String("username", user.Username))
+	return u.finishLogin(ctx, user, client, devices, location)
+}
+
+// deviceFingerprint identifies a device by its user agent and the network it
+// connects from. The address is cut to its /24 (IPv4) or /48 (IPv6) so a
+// DHCP lease change does not make a laptop look new.
+func deviceFingerprint(client dto.ClientInfo) string {
+	sum := sha256.Sum256([]byte(client.UserAgent + "|" + ipSubnet(client.IP)))
+	return hex.EncodeToString(sum[:])
+}
+
+func ipSubnet(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return ip
+	}
+	return prefix.String()
+}
+
+func findDevice(devices []*entity.KnownDevice, fingerprint string) *entity.KnownDevice {
+	for _, device := range devices {
+		if device.Fingerprint == fingerprint {
+			return device
+		}
+	}
+	return nil
+}
+
+// impossibleTravel reports whether getting from the last login location to
+// here since then needs a speed above maxSpeedKmh.
+func impossibleTravel(last *entity.KnownDevice, here *dto.GeoLocation, maxSpeedKmh float64) bool {
+	if maxSpeedKmh <= 0 {
+		return false
+	}
+
+	distance := haversineKm(*last.Latitude, *last.Longitude, here.Latitude, here.Longitude)
+	if distance < minTravelKm {
+		return false
+	}
+
+	hours := time.Since(last.LastSeenAt).Hours()
+	if hours <= 0 {
+		return true
+	}
+	return distance/hours > maxSpeedKmh
+}
+
+func haversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func randomOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(math.Pow10(otpDigits))))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
+
+// otpHash keeps the code out of Redis in the clear; the token salts it.
+func otpHash(token, code string) string {
+	sum := sha256.Sum256([]byte(token + ":" + strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/utils"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const machineTokenTTL = 15 * time.Minute
+
+func (u *usecase) IssueMachineToken(ctx context.Context, cert *x509.Certificate) (*dto.MachineTokenResponse, error) {
+	if cert == nil {
+		return nil, domain.ErrClientCertRequired
+	}
+
+	thumbprint := utils.CertThumbprint(cert)
+	subject := cert.Subject.String()
+
+	u.logger.Info("Machine token request", zap.String("subject", subject), zap.String("fingerprint", thumbprint))
+
+	identity, err := u.getMachineIdentity(ctx, subject, thumbprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Blocked {
+		u.logger.Warn("Machine identity is blocked", zap.String("machine", identity.Name))
+		return nil, domain.ErrMachineBlocked
+	}
+
+	accessToken, err := u.generateMachineToken(identity, thumbprint)
+	if err != nil {
+		u.logger.Error("Failed to generate machine token", zap.String("machine", identity.Name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	u.logger.Info("Machine token issued", zap.String("machine", identity.Name))
+	return &dto.MachineTokenResponse{
+		AccessToken: *accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(machineTokenTTL.Seconds()),
+		Machine:     identity.Name,
+		Scopes:      u.scopes.Expand(identity.Scopes),
+	}, nil
+}
+
+// getMachineIdentity resolves a certificate to an identity, preferring an
+// identity pinned to the exact fingerprint over one bound by subject only.
+func (u *usecase) getMachineIdentity(ctx context.Context, subject, thumbprint string) (*entity.MachineIdentity, error) {
+	identity, err := u.repo.GetMachineIdentityByFingerprint(ctx, thumbprint)
+	if err == nil {
+		return identity, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		u.logger.Error("Failed to retrieve machine identity", zap.String("fingerprint", thumbprint), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	identity, err = u.repo.GetMachineIdentityBySubject(ctx, subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Warn("Machine identity not found", zap.String("subject", subject))
+			return nil, domain.ErrMachineNotFound
+		}
+		u.logger.Error("Failed to retrieve machine identity", zap.String("subject", subject), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	return identity, nil
+}
+
+func (u *usecase) generateMachineToken(identity *entity.MachineIdentity, thumbprint string) (*string, error) {
+	scopes := u.scopes.Expand(identity.Scopes)
+
+	claims := jwt.MapClaims{
+		"sub":     identity.ID,
+		"machine": identity.Name,
+		"scopes":  scopes,
+		"cnf": map[string]string{
+			"x5t#S256": thumbprint,
+		},
+		"exp": time.Now().Add(machineTokenTTL).Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signedToken, err := token.SignedString([]byte(u.jwtSecret))
+	if err != nil {
+		return nil, err
+	}
+	return &signedToken, nil
+}
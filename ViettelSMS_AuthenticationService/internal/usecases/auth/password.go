@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func (u *usecase) ChangePassword(ctx context.Context, userID uint, sessionID, currentPassword, newPassword string) error {
+	user, err := u.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Warn("User not found", zap.Uint("userID", userID))
+			return domain.ErrUserNotFound
+		}
+		u.logger.Error("Failed to retrieve user", zap.Uint("userID", userID), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	ok, err := u.password.Verify(user.Password, currentPassword)
+	if err != nil || !ok {
+		u.logger.Warn("Invalid current password", zap.String("user_name", user.Username))
+		return domain.ErrInvalidCredentials
+	}
+
+	// A forced change must go through even right after an admin reset.
+	if !user.MustChangePassword {
+		if err := u.checkPasswordAge(ctx, user); err != nil {
+			u.logger.Warn("Password changed too recently", zap.String("user_name", user.Username))
+			return err
+		}
+	}
+
+	if newPassword == currentPassword {
+		u.logger.Warn("New password equals current password", zap.String("user_name", user.Username))
+		return domain.NewPasswordPolicyError([]dto.PolicyViolation{{
+			Rule:    "unchanged",
+			Message: "must differ from the current password",
+		}})
+	}
+
+	hashedPassword, err := u.setPassword(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := u.sessions.RevokeAll(ctx, user.ID, sessionID); err != nil {
+		u.logger.Error("Failed to revoke other sessions", zap.String("user_name", user.Username), zap.Error(err))
+	}
+
+	if err := u.publishEvent(mq.EventUserPasswordChanged, user.Username, map[string]interface{}{
+		"user_name": user.Username,
+		"password":  hashedPassword,
+	}); err != nil {
+		u.logger.Error("Failed to publish password change", zap.String("user_name", user.Username), zap.Error(err))
+	}
+
+	u.logger.Info("Password changed successfully", zap.String("user_name", user.Username))
+	return nil
+}
+
+// setPassword validates a new plaintext password, hashes it and persists it
+// on the user. It is the single write path for user-chosen passwords and
+// returns the stored hash for propagation to UserService.
+func (u *usecase) setPassword(ctx context.Context, user *entity.AuthUser, newPassword string) (string, error) {
+	if err := u.checkNewPassword(ctx, user, newPassword); err != nil {
+		return "", err
+	}
+	return u.storePassword(ctx, user, newPassword)
+}
+
+// checkNewPassword runs the policy and history checks of setPassword on their
+// own, for callers that must validate before spending something single use.
+func (u *usecase) checkNewPassword(ctx context.Context, user *entity.AuthUser, newPassword string) error {
+	if err := u.validatePassword(user, newPassword); err != nil {
+		u.logger.Warn("Password rejected by policy", zap.String("user_name", user.Username), zap.Error(err))
+		return err
+	}
+
+	if err := u.checkPasswordHistory(ctx, user, newPassword, false); err != nil {
+		u.logger.Warn("Password rejected by history", zap.String("user_name", user.Username), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// storePassword hashes and persists a password that already passed
+// checkNewPassword.
+func (u *usecase) storePassword(ctx context.Context, user *entity.AuthUser, newPassword string) (string, error) {
+	hashedPassword, err := u.password.Hash(newPassword)
+	if err != nil {
+		u.logger.Error("Failed to hash password", zap.String("user_name", user.Username), zap.Error(err))
+		return "", domain.ErrInternalServer
+	}
+
+	user.Password = hashedPassword
+	user.PasswordChangedAt = time.Now()
+	user.MustChangePassword = false
+	if err := u.repo.UpdateUserColumns(ctx, user.ID, map[string]interface{}{
+		"password":             user.Password,
+		"password_changed_at":  user.PasswordChangedAt,
+		"must_change_password": user.MustChangePassword,
+	}); err != nil {
+		u.logger.Error("Failed to update user", zap.String("user_name", user.Username), zap.Error(err))
+		return "", domain.ErrInternalServer
+	}
+
+	u.recordPasswordHistory(ctx, user)
+
+	return hashedPassword, nil
+}
+
+func (u *usecase) validatePassword(user *entity.AuthUser, password string) error {
+	if violations := u.passwordViolations(user.Username, password); len(violations) > 0 {
+		return domain.NewPasswordPolicyError(violations)
+	}
+	return nil
+}
+
+// verifyDummy runs a password check against a throwaway hash made with the
+// current algorithm and parameters, for requests that have no user to check.
+func (u *usecase) verifyDummy(password string) {
+	u.dummyHashOnce.Do(func() {
+		secret, err := randomToken(32)
+		if err == nil {
+			u.dummyHash, err = u.password.Hash(secret)
+		}
+		if err != nil {
+			u.logger.Error("Failed to create dummy password hash", zap.Error(err))
+		}
+	})
+	if u.dummyHash != "" {
+		_, _ = u.password.Verify(u.dummyHash, password)
+	}
+}
+
+// rehashIfNeeded upgrades a hash made with an outdated algorithm or cost
+// while the plaintext is at hand. Failure is logged and never fails the login.
+func (u *usecase) rehashIfNeeded(ctx context.Context, user *entity.AuthUser, password string) {
+	if !u.password.NeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := u.password.Hash(password)
+	if err != nil {
+		u.logger.Error("Failed to rehash password", zap.String("user_name", user.Username), zap.Error(err))
+		return
+	}
+
+	user.Password = hashedPassword
+	if err := u.repo.UpdateUserColumns(ctx, user.ID, map[string]interface{}{"password": user.Password}); err != nil {
+		u.logger.Error("Failed to save rehashed password", zap.String("user_name", user.Username), zap.Error(err))
+		return
+	}
+
+	u.logger.Info("Password rehashed", zap.String("user_name", user.Username))
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"go.uber.org/zap"
+)
+
+const ruleBreached = "breached"
+
+// passwordViolations runs the configured policy and the breached password
+// lookup over a plaintext password.
+func (u *usecase) passwordViolations(username, password string) []dto.PolicyViolation {
+	violations := u.policy.Validate(username, password)
+	if u.isBreached(username, password) {
+		violations = append(violations, dto.PolicyViolation{
+			Rule:    ruleBreached,
+			Message: "appears in a list of passwords exposed in data breaches",
+		})
+	}
+	return violations
+}
+
+// isBreached fails open: a corpus read error is logged and the password is
+// judged by the other rules alone rather than blocking every password write.
+func (u *usecase) isBreached(username, password string) bool {
+	breached, err := u.breached.IsBreached(password)
+	if err != nil {
+		u.logger.Error("Failed to check breached passwords", zap.String("user_name", username), zap.Error(err))
+		return false
+	}
+	return breached
+}
+
+// flagBreachedOnLogin marks an account for a forced change when the password
+// it just logged in with shows up in the corpus, e.g. after the corpus was
+// refreshed. The login then continues with a restricted token.
+func (u *usecase) flagBreachedOnLogin(ctx context.Context, user *entity.AuthUser, password string) {
+	if !u.config.BreachedPassword.CheckOnLogin || user.MustChangePassword {
+		return
+	}
+	if !u.isBreached(user.Username, password) {
+		return
+	}
+
+	user.MustChangePassword = true
+	if err := u.repo.UpdateUserColumns(ctx, user.ID, map[string]interface{}{"must_change_password": true}); err != nil {
+		u.logger.Error("Failed to flag breached password", zap.String("user_name", user.Username), zap.Error(err))
+		return
+	}
+
+	u.logger.Warn("Breached password detected at login, password change required", zap.String("user_name", user.Username))
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"go.uber.org/zap"
+)
+
+// passwordChangeRequired reports whether the user has to pick a new password
+// before getting a normal token: an administrator flagged the account, or the
+// password is older than MaxAge.
+func (u *usecase) passwordChangeRequired(user *entity.AuthUser) bool {
+	if user.MustChangePassword {
+		return true
+	}
+	maxAge := u.config.PasswordPolicy.MaxAge
+	return maxAge > 0 && time.Since(user.PasswordChangedAt) >= maxAge
+}
+
+// passwordExpiresInDays returns the whole days left before the password
+// expires, or nil when passwords never expire.
+func (u *usecase) passwordExpiresInDays(user *entity.AuthUser) *int {
+	maxAge := u.config.PasswordPolicy.MaxAge
+	if maxAge <= 0 {
+		return nil
+	}
+
+	remaining := time.Until(user.PasswordChangedAt.Add(maxAge))
+	days := max(0, int(math.Floor(remaining.Hours()/24)))
+	return &days
+}
+
+// issueRestrictedToken opens a short session for a user whose password must
+// be changed. The token carries no scopes and no refresh token is issued, so
+// it is only good for the change-password endpoint.
+func (u *usecase) issueRestrictedToken(ctx context.Context, user *entity.AuthUser) (*dto.LoginResponse, error) {
+	sessionID, err := u.sessions.Create(ctx, user.ID, accessTokenTTL)
+	if err != nil {
+		u.logger.Error("Failed to create session", zap.String("username", user.Username), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	claims := jwt.MapClaims{
+		"sub":        user.ID,
+		"sid":        sessionID,
+		"blocked":    user.Blocked,
+		"scopes":     []string{},
+		"restricted": true,
+		"exp":        time.Now().Add(accessTokenTTL).Unix(),
+		"iat":        time.Now().Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signedToken, err := token.SignedString([]byte(u.jwtSecret))
+	if err != nil {
+		u.logger.Error("Failed to generate access token", zap.String("username", user.Username), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	return &dto.LoginResponse{
+		AccessToken:            signedToken,
+		PasswordChangeRequired: true,
+		PasswordExpiresInDays:  u.passwordExpiresInDays(user),
+	}, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	ruleHistory = "history"
+	ruleMinAge  = "min_age"
+)
+
+// checkPasswordHistory refuses a password matching any of the last
+// HistorySize passwords. candidate is plaintext unless isHash is set, in
+// which case only an identical stored hash can be detected.
+func (u *usecase) checkPasswordHistory(ctx context.Context, user *entity.AuthUser, candidate string, isHash bool) error {
+	size := u.config.PasswordPolicy.HistorySize
+	if size <= 0 || user.ID == 0 {
+		return nil
+	}
+
+	entries, err := u.repo.GetPasswordHistory(ctx, user.ID, size)
+	if err != nil {
+		u.logger.Error("Failed to retrieve password history", zap.String("user_name", user.Username), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	for _, entry := range entries {
+		var reused bool
+		if isHash {
+			reused = entry.PasswordHash == candidate
+		} else {
+			// Unknown formats cannot match; that is not a reason to refuse.
+			reused, _ = u.password.Verify(entry.PasswordHash, candidate)
+		}
+
+		if reused {
+			return domain.NewPasswordPolicyError([]dto.PolicyViolation{{
+				Rule:    ruleHistory,
+				Message: fmt.Sprintf("must not match any of the last %d passwords", size),
+			}})
+		}
+	}
+	return nil
+}
+
+// checkPasswordAge refuses a user-initiated change within MinAge of the last
+// one, so the history cannot be flushed by changing the password N times in a row.
+func (u *usecase) checkPasswordAge(ctx context.Context, user *entity.AuthUser) error {
+	minAge := u.config.PasswordPolicy.MinAge
+	if minAge <= 0 {
+		return nil
+	}
+
+	entries, err := u.repo.GetPasswordHistory(ctx, user.ID, 1)
+	if err != nil {
+		u.logger.Error("Failed to retrieve password history", zap.String("user_name", user.Username), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if time.Since(entries[0].CreatedAt) < minAge {
+		return domain.NewPasswordPolicyError([]dto.PolicyViolation{{
+			Rule:    ruleMinAge,
+			Message: fmt.Sprintf("can only be changed once every %s", minAge),
+		}})
+	}
+	return nil
+}
+
+// recordPasswordHistory appends the user's current hash and trims the
+// history to HistorySize entries. Failures are logged; the password itself
+// has already been stored.
+func (u *usecase) recordPasswordHistory(ctx context.Context, user *entity.AuthUser) {
+	size := u.config.PasswordPolicy.HistorySize
+	if size <= 0 {
+		return
+	}
+
+	if err := u.repo.AddPasswordHistory(ctx, &entity.PasswordHistory{
+		UserID:       user.ID,
+		PasswordHash: user.Password,
+	}); err != nil {
+		u.logger.Error("Failed to record password history", zap.String("user_name", user.Username), zap.Error(err))
+		return
+	}
+
+	if err := u.repo.PrunePasswordHistory(ctx, user.ID, size); err != nil {
+		u.logger.Error("Failed to prune password history", zap.String("user_name", user.Username), zap.Error(err))
+	}
+}
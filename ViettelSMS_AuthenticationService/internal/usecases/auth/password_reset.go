@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	resetTokenPurpose   = "password_reset"
+	resetTokenKeyPrefix = "auth:password_reset:"
+)
+
+func (u *usecase) ForgotPassword(ctx context.Context, req dto.ForgotPasswordRequest) error {
+	u.logger.Info("Password reset requested", zap.String("user_name", req.UserName))
+
+	var (
+		user *entity.AuthUser
+		err  error
+	)
+	switch {
+	case req.UserName != "":
+		user, err = u.repo.GetUserByUsername(ctx, req.UserName)
+	case req.Email != "":
+		user, err = u.repo.GetUserByEmail(ctx, req.Email)
+	default:
+		return nil
+	}
+
+	// Unknown, blocked and mail-less accounts get the same silent success as
+	// real ones so the endpoint cannot be used to enumerate users.
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Error("Failed to retrieve user for password reset", zap.Error(err))
+		}
+		return nil
+	}
+	if user.Blocked || user.Email == "" {
+		u.logger.Warn("Password reset not deliverable", zap.String("user_name", user.Username))
+		return nil
+	}
+
+	token, err := u.generateResetToken(ctx, user)
+	if err != nil {
+		u.logger.Error("Failed to generate password reset token", zap.String("user_name", user.Username), zap.Error(err))
+		return nil
+	}
+
+	// Mail is sent in the background so response time does not reveal
+	// whether an account exists.
+	go func(email, username string) {
+		link := fmt.Sprintf("%s?token=%s", u.config.PasswordReset.URL, url.QueryEscape(token))
+		body := fmt.Sprintf(
+			"Hello %s,\n\nA password reset was requested for your ViettelSMS account.\n"+
+				"Use the link below within %s to choose a new password:\n\n%s\n\n"+
+				"If you did not request this, you can ignore this email.\n",
+			username, u.config.PasswordReset.TokenTTL, link,
+		)
+		if err := u.mailer.Send(email, "Reset your ViettelSMS password", body); err != nil {
+			u.logger.Error("Failed to send password reset email", zap.String("user_name", username), zap.Error(err))
+			return
+		}
+		u.logger.Info("Password reset email sent", zap.String("user_name", username))
+	}(user.Email, user.Username)
+
+	return nil
+}
+
+func (u *usecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	claims, err := u.parseResetToken(token)
+	if err != nil {
+		u.logger.Warn("Invalid password reset token", zap.Error(err))
+		return domain.ErrInvalidResetToken
+	}
+
+	key := resetTokenKeyPrefix + claims.ID
+	if err := u.cache.GetCache().Get(ctx, key).Err(); err != nil {
+		if errors.Is(err, redis.Nil) {
+			u.logger.Warn("Password reset token already used or expired", zap.Uint("userID", claims.Sub))
+			return domain.ErrInvalidResetToken
+		}
+		u.logger.Error("Failed to retrieve password reset token", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	user, err := u.repo.GetUserByID(ctx, claims.Sub)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrInvalidResetToken
+		}
+		u.logger.Error("Failed to retrieve user", zap.Uint("userID", claims.Sub), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	if claims.PasswordHash != passwordFingerprint(user.Password) || user.Blocked {
+		u.logger.Warn("Password reset token no longer valid for user", zap.String("user_name", user.Username))
+		return domain.ErrInvalidResetToken
+	}
+
+	// A rejected password leaves the token usable for another try.
+	if err := u.checkNewPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	// DEL decides the race between concurrent resets: only the request that
+	// removes the key may set the password, even across replicas.
+	deleted, err := u.cache.GetCache().Del(ctx, key).Result()
+	if err != nil {
+		u.logger.Error("Failed to consume password reset token", zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	if deleted == 0 {
+		u.logger.Warn("Password reset token already used or expired", zap.Uint("userID", claims.Sub))
+		return domain.ErrInvalidResetToken
+	}
+
+	hashedPassword, err := u.storePassword(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := u.sessions.RevokeAll(ctx, user.ID, ""); err != nil {
+		u.logger.Error("Failed to revoke sessions", zap.String("user_name", user.Username), zap.Error(err))
+	}
+
+	if err := u.publishEvent(mq.EventUserUpdatedPassword, user.Username, map[string]interface{}{
+		"user_name": user.Username,
+		"password":  hashedPassword,
+	}); err != nil {
+		u.logger.Error("Failed to publish password update", zap.String("user_name", user.Username), zap.Error(err))
+	}
+
+	u.logger.Info("Password reset successfully", zap.String("user_name", user.Username))
+	return nil
+}
+
+func (u *usecase) generateResetToken(ctx context.Context, user *entity.AuthUser) (string, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	ttl := u.config.PasswordReset.TokenTTL
+	now := time.Now()
+	claims := dto.ResetClaims{
+		Sub:          user.ID,
+		Purpose:      resetTokenPurpose,
+		PasswordHash: passwordFingerprint(user.Password),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(u.resetSigningKey())
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.cache.GetCache().Set(ctx, resetTokenKeyPrefix+jti, user.ID, ttl).Err(); err != nil {
+		return "", err
+	}
+	return signed, nil
+}
+
+func (u *usecase) parseResetToken(token string) (*dto.ResetClaims, error) {
+	parsed, err := jwt.ParseWithClaims(token, &dto.ResetClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected token signing method: %v", token.Header["alg"])
+		}
+		return u.resetSigningKey(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := parsed.Claims.(*dto.ResetClaims)
+	if !ok || !parsed.Valid || claims.Purpose != resetTokenPurpose || claims.ID == "" {
+		return nil, domain.ErrInvalidResetToken
+	}
+	return claims, nil
+}
+
+// resetSigningKey derives a key separate from the access token key so a
+// reset token can never be replayed as a bearer token.
+func (u *usecase) resetSigningKey() []byte {
+	mac := hmac.New(sha256.New, []byte(u.jwtSecret))
+	mac.Write([]byte(resetTokenPurpose))
+	return mac.Sum(nil)
+}
+
+// passwordFingerprint is a short digest of the stored hash, so the hash
+// itself never travels inside a token.
+func passwordFingerprint(hashedPassword string) string {
+	sum := sha256.Sum256([]byte(hashedPassword))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	serverResourceType = "server"
+
+	permissionViaScope      = "scope"
+	permissionViaPermission = "permission"
+)
+
+// permissionHolder is who a permission check is about: the subjects whose
+// resource permissions apply, keyed by subject type, and the scopes held.
+// A blocked holder has neither, so no check passes for it whatever it was
+// granted.
+type permissionHolder struct {
+	subjects map[string][]string
+	scopes   []string
+	blocked  bool
+}
+
+func blockedHolder() *permissionHolder {
+	return &permissionHolder{subjects: map[string][]string{}, blocked: true}
+}
+
+// userHolder counts roles inherited through groups, so role permissions
+// reach group members too.
+func (u *usecase) userHolder(ctx context.Context, user *entity.AuthUser) (*permissionHolder, error) {
+	if user.Blocked {
+		return blockedHolder(), nil
+	}
+
+	grants, err := u.resolveGrants(ctx, user)
+	if err != nil {
+		u.logger.Error("Failed to resolve user grants", zap.String("user_name", user.Username), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	return &permissionHolder{
+		subjects: map[string][]string{
+			entity.PermissionSubjectUser: {strconv.FormatUint(uint64(user.ID), 10)},
+			entity.PermissionSubjectRole: grants.roleNames(),
+		},
+		scopes: u.scopes.Expand(grants.scopes()),
+	}, nil
+}
+
+func (u *usecase) machineHolder(identity *entity.MachineIdentity) *permissionHolder {
+	if identity.Blocked {
+		return blockedHolder()
+	}
+	return &permissionHolder{
+		subjects: map[string][]string{entity.PermissionSubjectMachine: {identity.Name}},
+		scopes:   u.scopes.Expand(identity.Scopes),
+	}
+}
+
+// holder resolves a user name or machine identity name.
+func (u *usecase) holder(ctx context.Context, subjectType, subject string) (*permissionHolder, error) {
+	if subjectType == entity.PermissionSubjectMachine {
+		identity, err := u.getMachineIdentityByName(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		return u.machineHolder(identity), nil
+	}
+
+	user, err := u.getUserByUserName(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	return u.userHolder(ctx, user)
+}
+
+// permissionFor returns the resource permission letting holder perform
+// action on one resource, or nil.
+func (u *usecase) permissionFor(ctx context.Context, holder *permissionHolder, resourceType, resourceID, action string) (*entity.ResourcePermission, error) {
+	if holder.blocked {
+		return nil, nil
+	}
+
+	permissions, err := u.repo.ListResourcePermissions(ctx, resourceType, holder.subjects)
+	if err != nil {
+		u.logger.Error("Failed to list resource permissions", zap.String("resource_type", resourceType), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	for _, permission := range permissions {
+		if permitsAction(permission, action) && matchResource(permission.Resource, resourceID) {
+			return permission, nil
+		}
+	}
+	return nil, nil
+}
+
+func (u *usecase) CheckPermission(ctx context.Context, req dto.PermissionCheckRequest) (*dto.PermissionCheckResponse, error) {
+	holder, err := u.holder(ctx, req.SubjectType, req.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if holder.blocked {
+		return &dto.PermissionCheckResponse{Allowed: false}, nil
+	}
+
+	if u.scopes.Grants(holder.scopes, req.ResourceType+":"+req.Action) {
+		return &dto.PermissionCheckResponse{Allowed: true, Via: permissionViaScope}, nil
+	}
+
+	permission, err := u.permissionFor(ctx, holder, req.ResourceType, req.ResourceID, req.Action)
+	if err != nil {
+		return nil, err
+	}
+	if permission == nil {
+		return &dto.PermissionCheckResponse{Allowed: false}, nil
+	}
+	return &dto.PermissionCheckResponse{
+		Allowed:    true,
+		Via:        permissionViaPermission,
+		Permission: toResourcePermissionResponse(permission),
+	}, nil
+}
+
+// PermittedResources lists what a subject may perform action on, so a
+// service can filter its own queries instead of checking resources one by one.
+func (u *usecase) PermittedResources(ctx context.Context, req dto.PermittedResourcesRequest) (*dto.PermittedResourcesResponse, error) {
+	holder, err := u.holder(ctx, req.SubjectType, req.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.PermittedResourcesResponse{
+		ResourceType: req.ResourceType,
+		Action:       req.Action,
+		Resources:    []string{},
+	}
+	if holder.blocked {
+		return result, nil
+	}
+	if u.scopes.Grants(holder.scopes, req.ResourceType+":"+req.Action) {
+		result.All = true
+		return result, nil
+	}
+
+	permissions, err := u.repo.ListResourcePermissions(ctx, req.ResourceType, holder.subjects)
+	if err != nil {
+		u.logger.Error("Failed to list resource permissions", zap.String("resource_type", req.ResourceType), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	for _, permission := range permissions {
+		if permitsAction(permission, req.Action) && !slices.Contains(result.Resources, permission.Resource) {
+			result.Resources = append(result.Resources, permission.Resource)
+		}
+	}
+	return result, nil
+}
+
+func (u *usecase) ListResourcePermissions(ctx context.Context, resourceType string) ([]*dto.ResourcePermissionResponse, error) {
+	permissions, err := u.repo.ListResourcePermissions(ctx, resourceType, nil)
+	if err != nil {
+		u.logger.Error("Failed to list resource permissions", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	result := make([]*dto.ResourcePermissionResponse, len(permissions))
+	for i, permission := range permissions {
+		result[i] = toResourcePermissionResponse(permission)
+	}
+	return result, nil
+}
+
+func (u *usecase) CreateResourcePermission(ctx context.Context, req dto.ResourcePermissionRequest, adminID uint) (*dto.ResourcePermissionResponse, error) {
+	// Resource types and actions follow the scope grammar, so the check is
+	// the same as for the RESOURCE:ACTION scope it narrows.
+	if !u.scopes.Valid(req.ResourceType+":"+req.Action) || strings.ContainsAny(req.Resource, " \t\n") {
+		return nil, domain.ErrInvalidPermission
+	}
+
+	subject := req.Subject
+	switch req.SubjectType {
+	case entity.PermissionSubjectUser:
+		// User permissions are keyed by ID, which is what access tokens carry.
+		user, err := u.getUserByUserName(ctx, req.Subject)
+		if err != nil {
+			return nil, err
+		}
+		subject = strconv.FormatUint(uint64(user.ID), 10)
+	case entity.PermissionSubjectRole:
+		if _, err := u.getRoleByName(ctx, req.Subject); err != nil {
+			return nil, err
+		}
+	case entity.PermissionSubjectMachine:
+		if _, err := u.getMachineIdentityByName(ctx, req.Subject); err != nil {
+			return nil, err
+		}
+	}
+
+	permission := &entity.ResourcePermission{
+		SubjectType:  req.SubjectType,
+		Subject:      subject,
+		ResourceType: req.ResourceType,
+		Resource:     req.Resource,
+		Action:       req.Action,
+		CreatedBy:    &adminID,
+	}
+	if err := u.repo.CreateResourcePermission(ctx, permission); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, domain.ErrPermissionConflict
+		}
+		u.logger.Error("Failed to create resource permission", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	u.logger.Info("Resource permission created",
+		zap.Uint("id", permission.ID),
+		zap.String("subject_type", permission.SubjectType),
+		zap.String("subject", permission.Subject),
+		zap.String("resource_type", permission.ResourceType),
+		zap.String("resource", permission.Resource),
+		zap.String("action", permission.Action),
+		zap.Uint("by", adminID))
+	return toResourcePermissionResponse(permission), nil
+}
+
+func (u *usecase) DeleteResourcePermission(ctx context.Context, id uint, adminID uint) error {
+	if err := u.repo.DeleteResourcePermission(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrPermissionNotFound
+		}
+		u.logger.Error("Failed to delete resource permission", zap.Uint("id", id), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	u.logger.Info("Resource permission deleted", zap.Uint("id", id), zap.Uint("by", adminID))
+	return nil
+}
+
+// RegisterAuthServer handles server.registered from ServerService by making
+// the owner a full operator of the new server. Unlike user events, an unknown
+// owner is an error: the server exists, and its user.created is most likely
+// still in flight on USER_TOPIC, so the consumer retries the event instead of
+// leaving the server without an owner.
+func (u *usecase) RegisterAuthServer(ctx context.Context, payload map[string]interface{}) error {
+	u.logger.Info("Registering server", zap.Any("payload", redactPayload(payload)))
+
+	var req dto.ServerRegistered
+	if err := u.decodePayload(payload, &req); err != nil {
+		return err
+	}
+	if req.ServerID == "" || req.Owner == "" {
+		u.logger.Warn("Ignoring server without id or owner", zap.String("server_id", req.ServerID))
+		return nil
+	}
+
+	user, err := u.getUserByUserName(ctx, req.Owner)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			u.logger.Warn("Server owner not found, leaving the event for retry",
+				zap.String("server_id", req.ServerID),
+				zap.String("user_name", req.Owner))
+		}
+		return err
+	}
+
+	permission := &entity.ResourcePermission{
+		SubjectType:  entity.PermissionSubjectUser,
+		Subject:      strconv.FormatUint(uint64(user.ID), 10),
+		ResourceType: serverResourceType,
+		Resource:     req.ServerID,
+		Action:       entity.PermissionWildcard,
+	}
+	if err := u.repo.GrantResourcePermission(ctx, permission); err != nil {
+		u.logger.Error("Failed to grant server owner permission", zap.String("server_id", req.ServerID), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	u.logger.Info("Server owner permission granted", zap.String("server_id", req.ServerID), zap.String("user_name", req.Owner))
+	return nil
+}
+
+// DeleteAuthServer handles server.deleted by dropping every permission on
+// that server, so a later server reusing the ID starts clean.
+func (u *usecase) DeleteAuthServer(ctx context.Context, payload map[string]interface{}) error {
+	u.logger.Info("Deleting server permissions", zap.Any("payload", redactPayload(payload)))
+
+	var req dto.ServerDeleted
+	if err := u.decodePayload(payload, &req); err != nil {
+		return err
+	}
+	if req.ServerID == "" {
+		return nil
+	}
+
+	if err := u.repo.DeleteResourcePermissions(ctx, serverResourceType, req.ServerID); err != nil {
+		u.logger.Error("Failed to delete server permissions", zap.String("server_id", req.ServerID), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	u.logger.Info("Server permissions deleted", zap.String("server_id", req.ServerID))
+	return nil
+}
+
+func (u *usecase) getMachineIdentityByName(ctx context.Context, name string) (*entity.MachineIdentity, error) {
+	identity, err := u.repo.GetMachineIdentityByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Warn("Machine identity not found", zap.String("machine", name))
+			return nil, domain.ErrMachineNotFound
+		}
+		u.logger.Error("Failed to retrieve machine identity", zap.String("machine", name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	return identity, nil
+}
+
+func permitsAction(permission *entity.ResourcePermission, action string) bool {
+	return permission.Action == entity.PermissionWildcard || permission.Action == action
+}
+
+// matchResource matches a resource ID against an ID or a pattern where "*"
+// stands for any run of characters.
+func matchResource(pattern, id string) bool {
+	parts := strings.Split(pattern, entity.PermissionWildcard)
+	if len(parts) == 1 {
+		return pattern == id
+	}
+
+	first, last := parts[0], parts[len(parts)-1]
+	if len(id) < len(first)+len(last) || !strings.HasPrefix(id, first) || !strings.HasSuffix(id, last) {
+		return false
+	}
+	id = id[len(first) : len(id)-len(last)]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(id, part)
+		if i < 0 {
+			return false
+		}
+		id = id[i+len(part):]
+	}
+	return true
+}
+
+func toResourcePermissionResponse(permission *entity.ResourcePermission) *dto.ResourcePermissionResponse {
+	return &dto.ResourcePermissionResponse{
+		ID:           permission.ID,
+		SubjectType:  permission.SubjectType,
+		Subject:      permission.Subject,
+		ResourceType: permission.ResourceType,
+		Resource:     permission.Resource,
+		Action:       permission.Action,
+		CreatedAt:    permission.CreatedAt,
+	}
+}
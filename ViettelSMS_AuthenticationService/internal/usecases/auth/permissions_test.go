@@ -0,0 +1,392 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	repo "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/repository"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/scope"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// permissionRepo serves the users, machine identities and resource
+// permissions a permission check reads. Anything else panics through the
+// nil embedded Repository.
+type permissionRepo struct {
+	repo.Repository
+	users       []*entity.AuthUser
+	machines    []*entity.MachineIdentity
+	permissions []*entity.ResourcePermission
+}
+
+func (r *permissionRepo) GetUserByUsername(_ context.Context, username string) (*entity.AuthUser, error) {
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *permissionRepo) GetMachineIdentityByName(_ context.Context, name string) (*entity.MachineIdentity, error) {
+	for _, identity := range r.machines {
+		if identity.Name == name {
+			return identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *permissionRepo) GetUserRoles(context.Context, uint) ([]*entity.Role, error) {
+	return nil, nil
+}
+
+func (r *permissionRepo) GetUserGroupIDs(context.Context, uint) ([]uint, error) {
+	return nil, nil
+}
+
+// ListResourcePermissions filters like the real repository: nil subjects
+// means everyone's permissions, an empty map nobody's.
+func (r *permissionRepo) ListResourcePermissions(_ context.Context, resourceType string, subjects map[string][]string) ([]*entity.ResourcePermission, error) {
+	var result []*entity.ResourcePermission
+	for _, permission := range r.permissions {
+		if permission.ResourceType != resourceType {
+			continue
+		}
+		if subjects != nil && !slices.Contains(subjects[permission.SubjectType], permission.Subject) {
+			continue
+		}
+		result = append(result, permission)
+	}
+	return result, nil
+}
+
+// GrantResourcePermission ignores a grant already stored, like the ON
+// CONFLICT DO NOTHING of the real repository.
+func (r *permissionRepo) GrantResourcePermission(_ context.Context, permission *entity.ResourcePermission) error {
+	for _, stored := range r.permissions {
+		if stored.SubjectType == permission.SubjectType && stored.Subject == permission.Subject &&
+			stored.ResourceType == permission.ResourceType && stored.Resource == permission.Resource &&
+			stored.Action == permission.Action {
+			return nil
+		}
+	}
+	r.permissions = append(r.permissions, permission)
+	return nil
+}
+
+func (r *permissionRepo) DeleteResourcePermissions(_ context.Context, resourceType, resource string) error {
+	r.permissions = slices.DeleteFunc(r.permissions, func(permission *entity.ResourcePermission) bool {
+		return permission.ResourceType == resourceType && permission.Resource == resource
+	})
+	return nil
+}
+
+func newPermissionUsecase(t *testing.T) *usecase {
+	t.Helper()
+	matcher, err := scope.NewMatcher(scope.Grammar{})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+
+	users := []*entity.AuthUser{
+		{ID: 1, Username: "alice", Scopes: []string{"server:read"}},
+		{ID: 2, Username: "bob"},
+		{ID: 3, Username: "mallory", Blocked: true, Scopes: []string{"server:read"}},
+		{ID: 4, Username: "eve", Blocked: true},
+	}
+	machines := []*entity.MachineIdentity{
+		{Name: "scanner", Scopes: []string{"server:read"}},
+		{Name: "backup"},
+		{Name: "old-scanner", Blocked: true, Scopes: []string{"server:read"}},
+		{Name: "old-backup", Blocked: true},
+	}
+
+	var permissions []*entity.ResourcePermission
+	for _, user := range users {
+		permissions = append(permissions, &entity.ResourcePermission{
+			SubjectType:  entity.PermissionSubjectUser,
+			Subject:      strconv.FormatUint(uint64(user.ID), 10),
+			ResourceType: serverResourceType,
+			Resource:     "srv-*",
+			Action:       "update",
+		})
+	}
+	for _, identity := range machines {
+		permissions = append(permissions, &entity.ResourcePermission{
+			SubjectType:  entity.PermissionSubjectMachine,
+			Subject:      identity.Name,
+			ResourceType: serverResourceType,
+			Resource:     "srv-*",
+			Action:       "update",
+		})
+	}
+
+	return &usecase{
+		repo:   &permissionRepo{users: users, machines: machines, permissions: permissions},
+		scopes: matcher,
+		logger: zap.NewNop(),
+	}
+}
+
+func TestCheckPermission(t *testing.T) {
+	u := newPermissionUsecase(t)
+
+	tests := []struct {
+		name        string
+		subjectType string
+		subject     string
+		action      string
+		allowed     bool
+		via         string
+	}{
+		{"user via scope", entity.PermissionSubjectUser, "alice", "read", true, permissionViaScope},
+		{"user via permission", entity.PermissionSubjectUser, "bob", "update", true, permissionViaPermission},
+		{"user without grant", entity.PermissionSubjectUser, "bob", "delete", false, ""},
+		{"blocked user with scope", entity.PermissionSubjectUser, "mallory", "read", false, ""},
+		{"blocked user with permission", entity.PermissionSubjectUser, "eve", "update", false, ""},
+
+		{"machine via scope", entity.PermissionSubjectMachine, "scanner", "read", true, permissionViaScope},
+		{"machine via permission", entity.PermissionSubjectMachine, "backup", "update", true, permissionViaPermission},
+		{"machine without grant", entity.PermissionSubjectMachine, "backup", "delete", false, ""},
+		{"blocked machine with scope", entity.PermissionSubjectMachine, "old-scanner", "read", false, ""},
+		{"blocked machine with permission", entity.PermissionSubjectMachine, "old-backup", "update", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.CheckPermission(context.Background(), dto.PermissionCheckRequest{
+				SubjectType:  tt.subjectType,
+				Subject:      tt.subject,
+				ResourceType: serverResourceType,
+				ResourceID:   "srv-1",
+				Action:       tt.action,
+			})
+			if err != nil {
+				t.Fatalf("CheckPermission: %v", err)
+			}
+			if got.Allowed != tt.allowed || got.Via != tt.via {
+				t.Errorf("CheckPermission = allowed %v via %q, want allowed %v via %q", got.Allowed, got.Via, tt.allowed, tt.via)
+			}
+			if !got.Allowed && got.Permission != nil {
+				t.Errorf("denied check reported permission %+v", got.Permission)
+			}
+		})
+	}
+}
+
+func TestPermittedResources(t *testing.T) {
+	u := newPermissionUsecase(t)
+
+	tests := []struct {
+		name        string
+		subjectType string
+		subject     string
+		action      string
+		all         bool
+		resources   []string
+	}{
+		{"user via scope", entity.PermissionSubjectUser, "alice", "read", true, []string{}},
+		{"user via permission", entity.PermissionSubjectUser, "bob", "update", false, []string{"srv-*"}},
+		{"user without grant", entity.PermissionSubjectUser, "bob", "delete", false, []string{}},
+		{"blocked user with scope", entity.PermissionSubjectUser, "mallory", "read", false, []string{}},
+		{"blocked user with permission", entity.PermissionSubjectUser, "eve", "update", false, []string{}},
+
+		{"machine via scope", entity.PermissionSubjectMachine, "scanner", "read", true, []string{}},
+		{"machine via permission", entity.PermissionSubjectMachine, "backup", "update", false, []string{"srv-*"}},
+		{"blocked machine with scope", entity.PermissionSubjectMachine, "old-scanner", "read", false, []string{}},
+		{"blocked machine with permission", entity.PermissionSubjectMachine, "old-backup", "update", false, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := u.PermittedResources(context.Background(), dto.PermittedResourcesRequest{
+				SubjectType:  tt.subjectType,
+				Subject:      tt.subject,
+				ResourceType: serverResourceType,
+				Action:       tt.action,
+			})
+			if err != nil {
+				t.Fatalf("PermittedResources: %v", err)
+			}
+			if got.All != tt.all || !slices.Equal(got.Resources, tt.resources) {
+				t.Errorf("PermittedResources = all %v resources %q, want all %v resources %q", got.All, got.Resources, tt.all, tt.resources)
+			}
+		})
+	}
+}
+
+func TestBlockedHolderGetsNoPermission(t *testing.T) {
+	u := newPermissionUsecase(t)
+
+	holder, err := u.holder(context.Background(), entity.PermissionSubjectUser, "eve")
+	if err != nil {
+		t.Fatalf("holder: %v", err)
+	}
+	if !holder.blocked || len(holder.scopes) != 0 || len(holder.subjects) != 0 {
+		t.Fatalf("blocked user holder = %+v, want blocked with no scopes or subjects", holder)
+	}
+
+	permission, err := u.permissionFor(context.Background(), holder, serverResourceType, "srv-1", "update")
+	if err != nil {
+		t.Fatalf("permissionFor: %v", err)
+	}
+	if permission != nil {
+		t.Errorf("permissionFor granted %+v to a blocked user", permission)
+	}
+}
+
+func TestMatchResource(t *testing.T) {
+	tests := []struct {
+		pattern string
+		id      string
+		want    bool
+	}{
+		{"srv-1", "srv-1", true},
+		{"srv-1", "srv-10", false},
+		{"", "", true},
+		{"", "srv-1", false},
+		{"*", "", true},
+		{"*", "srv-1", true},
+		{"srv-*", "srv-", true},
+		{"srv-*", "srv-1", true},
+		{"srv-*", "db-1", false},
+		{"*-prod", "srv-prod", true},
+		{"*-prod", "srv-prod-2", false},
+		{"srv-*-prod", "srv-1-prod", true},
+		{"srv-*-prod", "srv--prod", true},
+		{"srv-*-prod", "srv-prod", false},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+		{"a*a", "aba", true},
+		{"ab*ba", "aba", false},
+		{"*a*b*", "xaxbx", true},
+		{"*a*b*", "xbxax", false},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "acbc", true},
+		{"a*b*c", "ac", false},
+		{"**", "srv-1", true},
+		{"srv-**", "srv-", true},
+		{"a*bc*bc", "abcbc", true},
+		{"a*bc*bc", "abc", false},
+	}
+	for _, tt := range tests {
+		if got := matchResource(tt.pattern, tt.id); got != tt.want {
+			t.Errorf("matchResource(%q, %q) = %v, want %v", tt.pattern, tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterAuthServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		wantErr error
+		granted bool
+	}{
+		{"owner granted", map[string]interface{}{"server_id": "db-1", "owner": "bob"}, nil, true},
+		{"missing server id", map[string]interface{}{"owner": "bob"}, nil, false},
+		{"missing owner", map[string]interface{}{"server_id": "db-1"}, nil, false},
+		{"unknown owner is retried", map[string]interface{}{"server_id": "db-1", "owner": "nobody"}, domain.ErrUserNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := newPermissionUsecase(t)
+			r := u.repo.(*permissionRepo)
+			before := len(r.permissions)
+
+			err := u.RegisterAuthServer(context.Background(), tt.payload)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RegisterAuthServer error = %v, want %v", err, tt.wantErr)
+			}
+
+			var granted []*entity.ResourcePermission
+			for _, permission := range r.permissions {
+				if permission.Resource == "db-1" {
+					granted = append(granted, permission)
+				}
+			}
+			if !tt.granted {
+				if len(r.permissions) != before {
+					t.Errorf("RegisterAuthServer stored %d permissions, want none", len(r.permissions)-before)
+				}
+				return
+			}
+			if len(granted) != 1 {
+				t.Fatalf("RegisterAuthServer stored %d permissions on db-1, want 1", len(granted))
+			}
+			want := entity.ResourcePermission{
+				SubjectType:  entity.PermissionSubjectUser,
+				Subject:      "2",
+				ResourceType: serverResourceType,
+				Resource:     "db-1",
+				Action:       entity.PermissionWildcard,
+			}
+			if *granted[0] != want {
+				t.Errorf("granted %+v, want %+v", *granted[0], want)
+			}
+
+			// A redelivered event grants nothing new.
+			if err := u.RegisterAuthServer(context.Background(), tt.payload); err != nil {
+				t.Fatalf("RegisterAuthServer again: %v", err)
+			}
+			if len(r.permissions) != before+1 {
+				t.Errorf("redelivery stored %d permissions, want 1", len(r.permissions)-before)
+			}
+
+			check, err := u.CheckPermission(context.Background(), dto.PermissionCheckRequest{
+				SubjectType:  entity.PermissionSubjectUser,
+				Subject:      "bob",
+				ResourceType: serverResourceType,
+				ResourceID:   "db-1",
+				Action:       "delete",
+			})
+			if err != nil || !check.Allowed {
+				t.Errorf("owner CheckPermission = %+v, %v, want allowed", check, err)
+			}
+		})
+	}
+}
+
+func TestDeleteAuthServer(t *testing.T) {
+	u := newPermissionUsecase(t)
+	r := u.repo.(*permissionRepo)
+	for _, server := range []string{"db-1", "db-2"} {
+		if err := u.RegisterAuthServer(context.Background(), map[string]interface{}{"server_id": server, "owner": "bob"}); err != nil {
+			t.Fatalf("RegisterAuthServer(%s): %v", server, err)
+		}
+	}
+	before := len(r.permissions)
+
+	if err := u.DeleteAuthServer(context.Background(), map[string]interface{}{}); err != nil {
+		t.Fatalf("DeleteAuthServer without id: %v", err)
+	}
+	if len(r.permissions) != before {
+		t.Fatal("DeleteAuthServer without id deleted permissions")
+	}
+
+	if err := u.DeleteAuthServer(context.Background(), map[string]interface{}{"server_id": "db-1"}); err != nil {
+		t.Fatalf("DeleteAuthServer: %v", err)
+	}
+	if len(r.permissions) != before-1 {
+		t.Fatalf("DeleteAuthServer left %d permissions, want %d", len(r.permissions), before-1)
+	}
+	for _, permission := range r.permissions {
+		if permission.Resource == "db-1" {
+			t.Errorf("permission %+v on the deleted server kept", permission)
+		}
+	}
+
+	// Patterns covering the ID belong to other servers too and are kept.
+	if err := u.DeleteAuthServer(context.Background(), map[string]interface{}{"server_id": "srv-1"}); err != nil {
+		t.Fatalf("DeleteAuthServer: %v", err)
+	}
+	if len(r.permissions) != before-1 {
+		t.Errorf("DeleteAuthServer removed pattern permissions")
+	}
+}
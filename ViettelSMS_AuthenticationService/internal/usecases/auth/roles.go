@@ -0,0 +1,412 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.-]{0,63}$`)
+
+// effectiveScopes is the user's own scopes and the scopes of its roles and
+// groups, expanded through the scope grammar. Role and group changes reach a
+// user at the next token, so an access token may carry revoked scopes until
+// it expires.
+func (u *usecase) effectiveScopes(ctx context.Context, user *entity.AuthUser) ([]string, error) {
+	grants, err := u.resolveGrants(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return u.scopes.Expand(grants.scopes()), nil
+}
+
+func (u *usecase) ListRoles(ctx context.Context) ([]*dto.RoleResponse, error) {
+	roles, err := u.repo.ListRoles(ctx)
+	if err != nil {
+		u.logger.Error("Failed to list roles", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	result := make([]*dto.RoleResponse, len(roles))
+	for i, role := range roles {
+		result[i] = toRoleResponse(role)
+	}
+	return result, nil
+}
+
+func (u *usecase) CreateRole(ctx context.Context, req dto.RoleRequest, adminID uint) (*dto.RoleResponse, error) {
+	valid, err := u.validScopes(ctx, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if !roleNamePattern.MatchString(req.Name) || !valid {
+		return nil, domain.ErrInvalidRole
+	}
+
+	role := &entity.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Scopes:      roleScopes(req.Scopes),
+	}
+	if err := u.repo.CreateRole(ctx, role); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, domain.ErrRoleConflict
+		}
+		u.logger.Error("Failed to create role", zap.String("role", req.Name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	u.logger.Info("Role created", zap.String("role", role.Name), zap.Uint("by", adminID))
+	u.publishRole(mq.EventRoleCreated, role)
+	return toRoleResponse(role), nil
+}
+
+func (u *usecase) UpdateRole(ctx context.Context, name string, req dto.RoleUpdateRequest, adminID uint) (*dto.RoleResponse, error) {
+	valid, err := u.validScopes(ctx, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, domain.ErrInvalidRole
+	}
+
+	role, err := u.getRoleByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	// Built-in roles are what the service relies on for its own admins;
+	// rewriting admin could lock everyone out.
+	if role.BuiltIn {
+		return nil, domain.ErrRoleBuiltIn
+	}
+
+	if err := u.replaceRole(ctx, role, req.Description, req.Scopes); err != nil {
+		return nil, err
+	}
+
+	u.logger.Info("Role updated", zap.String("role", role.Name), zap.Uint("by", adminID))
+	u.publishRole(mq.EventRoleUpdated, role)
+	return toRoleResponse(role), nil
+}
+
+func (u *usecase) DeleteRole(ctx context.Context, name string, adminID uint) error {
+	role, err := u.getRoleByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return domain.ErrRoleBuiltIn
+	}
+
+	if err := u.deleteRole(ctx, role); err != nil {
+		return err
+	}
+
+	u.logger.Info("Role deleted", zap.String("role", name), zap.Uint("by", adminID))
+	_ = u.publishEvent(mq.EventRoleDeleted, role.Name, map[string]interface{}{
+		"name": role.Name,
+	})
+	return nil
+}
+
+func (u *usecase) GetUserRoles(ctx context.Context, userName string) ([]*dto.RoleResponse, error) {
+	user, err := u.getUserByUserName(ctx, userName)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := u.repo.GetUserRoles(ctx, user.ID)
+	if err != nil {
+		u.logger.Error("Failed to retrieve user roles", zap.String("user_name", userName), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	result := make([]*dto.RoleResponse, len(roles))
+	for i, role := range roles {
+		result[i] = toRoleResponse(role)
+	}
+	return result, nil
+}
+
+func (u *usecase) AssignRole(ctx context.Context, userName, roleName string, adminID uint) error {
+	if err := u.assignRole(ctx, userName, roleName); err != nil {
+		return err
+	}
+
+	u.logger.Info("Role assigned", zap.String("user_name", userName), zap.String("role", roleName), zap.Uint("by", adminID))
+	_ = u.publishEvent(mq.EventUserRoleAssigned, userName, map[string]interface{}{
+		"user_name": userName,
+		"role":      roleName,
+	})
+	return nil
+}
+
+func (u *usecase) UnassignRole(ctx context.Context, userName, roleName string, adminID uint) error {
+	if err := u.unassignRole(ctx, userName, roleName); err != nil {
+		return err
+	}
+
+	u.logger.Info("Role unassigned", zap.String("user_name", userName), zap.String("role", roleName), zap.Uint("by", adminID))
+	_ = u.publishEvent(mq.EventUserRoleUnassigned, userName, map[string]interface{}{
+		"user_name": userName,
+		"role":      roleName,
+	})
+	return nil
+}
+
+// SaveAuthRole handles role.created and role.updated from UserService. Both
+// create the role if it is missing and replace it otherwise, so a replayed
+// or reordered event converges on the latest definition.
+func (u *usecase) SaveAuthRole(ctx context.Context, payload map[string]interface{}) error {
+	u.logger.Info("Saving role", zap.Any("payload", redactPayload(payload)))
+
+	var req dto.RoleRequest
+	if err := u.decodePayload(payload, &req); err != nil {
+		return err
+	}
+
+	valid, err := u.validScopes(ctx, req.Scopes)
+	if err != nil {
+		return err
+	}
+	if !roleNamePattern.MatchString(req.Name) || !valid {
+		u.logger.Warn("Ignoring invalid role", zap.String("role", req.Name), zap.Strings("scopes", req.Scopes))
+		return nil
+	}
+
+	role, err := u.repo.GetRoleByName(ctx, req.Name)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Error("Failed to retrieve role", zap.String("role", req.Name), zap.Error(err))
+			return domain.ErrInternalServer
+		}
+		role = &entity.Role{Name: req.Name}
+	}
+	if role.BuiltIn {
+		u.logger.Warn("Ignoring update of built-in role", zap.String("role", req.Name))
+		return nil
+	}
+
+	if err := u.replaceRole(ctx, role, req.Description, req.Scopes); err != nil {
+		return err
+	}
+
+	u.logger.Info("Role saved successfully", zap.String("role", req.Name))
+	return nil
+}
+
+func (u *usecase) DeleteAuthRole(ctx context.Context, payload map[string]interface{}) error {
+	u.logger.Info("Deleting role", zap.Any("payload", redactPayload(payload)))
+
+	var req dto.RoleDelete
+	if err := u.decodePayload(payload, &req); err != nil {
+		return err
+	}
+
+	role, err := u.getRoleByName(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, domain.ErrRoleNotFound) {
+			return nil
+		}
+		return err
+	}
+	if role.BuiltIn {
+		u.logger.Warn("Ignoring delete of built-in role", zap.String("role", req.Name))
+		return nil
+	}
+
+	if err := u.deleteRole(ctx, role); err != nil {
+		return err
+	}
+
+	u.logger.Info("Role deleted successfully", zap.String("role", req.Name))
+	return nil
+}
+
+func (u *usecase) AssignAuthRole(ctx context.Context, payload map[string]interface{}) error {
+	u.logger.Info("Assigning user role", zap.Any("payload", redactPayload(payload)))
+
+	var req dto.UserRole
+	if err := u.decodePayload(payload, &req); err != nil {
+		return err
+	}
+
+	if err := u.assignRole(ctx, req.UserName, req.Role); err != nil {
+		return ignoreMissing(err)
+	}
+
+	u.logger.Info("User role assigned successfully", zap.String("user_name", req.UserName), zap.String("role", req.Role))
+	return nil
+}
+
+func (u *usecase) UnassignAuthRole(ctx context.Context, payload map[string]interface{}) error {
+	u.logger.Info("Unassigning user role", zap.Any("payload", redactPayload(payload)))
+
+	var req dto.UserRole
+	if err := u.decodePayload(payload, &req); err != nil {
+		return err
+	}
+
+	if err := u.unassignRole(ctx, req.UserName, req.Role); err != nil {
+		return ignoreMissing(err)
+	}
+
+	u.logger.Info("User role unassigned successfully", zap.String("user_name", req.UserName), zap.String("role", req.Role))
+	return nil
+}
+
+// assignRoles gives a newly created user the roles named in its event.
+// Unknown roles are skipped.
+func (u *usecase) assignRoles(ctx context.Context, user *entity.AuthUser, roleNames []string) {
+	for _, name := range roleNames {
+		role, err := u.getRoleByName(ctx, name)
+		if err != nil {
+			continue
+		}
+		if err := u.repo.AssignRole(ctx, user.ID, role.ID); err != nil {
+			u.logger.Error("Failed to assign role", zap.String("user_name", user.Username), zap.String("role", name), zap.Error(err))
+		}
+	}
+}
+
+func (u *usecase) assignRole(ctx context.Context, userName, roleName string) error {
+	user, err := u.getUserByUserName(ctx, userName)
+	if err != nil {
+		return err
+	}
+	role, err := u.getRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.AssignRole(ctx, user.ID, role.ID); err != nil {
+		u.logger.Error("Failed to assign role", zap.String("user_name", userName), zap.String("role", roleName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	return nil
+}
+
+func (u *usecase) unassignRole(ctx context.Context, userName, roleName string) error {
+	user, err := u.getUserByUserName(ctx, userName)
+	if err != nil {
+		return err
+	}
+	role, err := u.getRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.UnassignRole(ctx, user.ID, role.ID); err != nil {
+		u.logger.Error("Failed to unassign role", zap.String("user_name", userName), zap.String("role", roleName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	return nil
+}
+
+// replaceRole sets the description and scopes of role, creating it when it
+// has no ID yet.
+func (u *usecase) replaceRole(ctx context.Context, role *entity.Role, description string, scopes []string) error {
+	role.Description = description
+	role.Scopes = roleScopes(scopes)
+
+	save := u.repo.UpdateRole
+	if role.ID == 0 {
+		save = u.repo.CreateRole
+	}
+	if err := save(ctx, role); err != nil {
+		u.logger.Error("Failed to save role", zap.String("role", role.Name), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	return nil
+}
+
+func (u *usecase) getRoleByName(ctx context.Context, name string) (*entity.Role, error) {
+	role, err := u.repo.GetRoleByName(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			u.logger.Warn("Role not found", zap.String("role", name))
+			return nil, domain.ErrRoleNotFound
+		}
+		u.logger.Error("Failed to retrieve role", zap.String("role", name), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+	return role, nil
+}
+
+func (u *usecase) publishRole(event string, role *entity.Role) {
+	_ = u.publishEvent(event, role.Name, map[string]interface{}{
+		"name":        role.Name,
+		"description": role.Description,
+		"scopes":      role.ScopeNames(),
+	})
+}
+
+// deleteRole removes a role together with its resource permissions, which
+// would otherwise pass to any later role of the same name.
+func (u *usecase) deleteRole(ctx context.Context, role *entity.Role) error {
+	if err := u.repo.DeleteSubjectPermissions(ctx, entity.PermissionSubjectRole, role.Name); err != nil {
+		u.logger.Error("Failed to delete role permissions", zap.String("role", role.Name), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	if err := u.repo.DeleteRole(ctx, role.ID); err != nil {
+		u.logger.Error("Failed to delete role", zap.String("role", role.Name), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+	return nil
+}
+
+// validScopes reports whether every scope can be granted: it is in the
+// catalogue and not deprecated, or a wildcard covering such a scope.
+func (u *usecase) validScopes(ctx context.Context, scopes []string) (bool, error) {
+	catalogue, err := u.scopeCatalogue(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, scope := range scopes {
+		if reason := u.scopeRejection(catalogue, scope); reason != "" {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func roleScopes(scopes []string) []entity.RoleScope {
+	unique := slices.Clone(scopes)
+	slices.Sort(unique)
+	unique = slices.Compact(unique)
+
+	result := make([]entity.RoleScope, 0, len(unique))
+	for _, scope := range unique {
+		if scope == "" {
+			continue
+		}
+		result = append(result, entity.RoleScope{Scope: scope})
+	}
+	return result
+}
+
+func toRoleResponse(role *entity.Role) *dto.RoleResponse {
+	return &dto.RoleResponse{
+		Name:        role.Name,
+		Description: role.Description,
+		BuiltIn:     role.BuiltIn,
+		Scopes:      role.ScopeNames(),
+	}
+}
+
+// ignoreMissing drops the not-found errors of an event about a user or role
+// this service does not know; retrying would not make it appear.
+func ignoreMissing(err error) error {
+	if errors.Is(err, domain.ErrUserNotFound) || errors.Is(err, domain.ErrRoleNotFound) {
+		return nil
+	}
+	return err
+}
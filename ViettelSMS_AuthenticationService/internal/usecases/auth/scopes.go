@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	"go.uber.org/zap"
+)
+
+const (
+	scopeUnknown    = "unknown"
+	scopeDuplicate  = "duplicate"
+	scopeDeprecated = "deprecated"
+)
+
+func (u *usecase) ListScopes(ctx context.Context) ([]*dto.ScopeResponse, error) {
+	scopes, err := u.repo.ListScopes(ctx)
+	if err != nil {
+		u.logger.Error("Failed to list scopes", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	result := make([]*dto.ScopeResponse, len(scopes))
+	for i, scope := range scopes {
+		result[i] = &dto.ScopeResponse{
+			Name:        scope.Name,
+			Description: scope.Description,
+			Service:     scope.Service,
+			Deprecated:  scope.Deprecated,
+		}
+	}
+	return result, nil
+}
+
+func (u *usecase) scopeCatalogue(ctx context.Context) (map[string]*entity.Scope, error) {
+	scopes, err := u.repo.ListScopes(ctx)
+	if err != nil {
+		u.logger.Error("Failed to list scopes", zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	catalogue := make(map[string]*entity.Scope, len(scopes))
+	for _, scope := range scopes {
+		catalogue[scope.Name] = scope
+	}
+	return catalogue, nil
+}
+
+// scopeRejection returns why scope cannot be granted, or "" if it can. A
+// wildcard is accepted when it covers at least one current catalogue scope.
+func (u *usecase) scopeRejection(catalogue map[string]*entity.Scope, scope string) string {
+	if !u.scopes.Valid(scope) {
+		return scopeUnknown
+	}
+
+	if entry, ok := catalogue[scope]; ok {
+		if entry.Deprecated {
+			return scopeDeprecated
+		}
+		return ""
+	}
+
+	if strings.Contains(scope, "*") {
+		for name, entry := range catalogue {
+			if !entry.Deprecated && u.scopes.Grants([]string{scope}, name) {
+				return ""
+			}
+		}
+	}
+	return scopeUnknown
+}
+
+// acceptGrants filters the scopes a user event grants, reporting each one
+// dropped as unknown, deprecated or duplicate.
+func (u *usecase) acceptGrants(ctx context.Context, username, event string, held, granted []string) ([]string, error) {
+	catalogue, err := u.scopeCatalogue(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted := slices.Clone(held)
+	for _, scope := range granted {
+		reason := u.scopeRejection(catalogue, scope)
+		if reason == "" && slices.Contains(accepted, scope) {
+			reason = scopeDuplicate
+		}
+		if reason != "" {
+			u.rejectScope(username, event, scope, reason)
+			continue
+		}
+		accepted = append(accepted, scope)
+	}
+	return accepted, nil
+}
+
+// rejectScope tells UserService that a scope it granted with event was not
+// stored.
+func (u *usecase) rejectScope(username, event, scope, reason string) {
+	u.logger.Warn("Scope grant rejected",
+		zap.String("user_name", username),
+		zap.String("event", event),
+		zap.String("scope", scope),
+		zap.String("reason", reason))
+	_ = u.publishEvent(mq.EventUserScopeRejected, username, map[string]interface{}{
+		"user_name": username,
+		"event":     event,
+		"scope":     scope,
+		"reason":    reason,
+	})
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
+	repo "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/repository"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/scope"
+	"go.uber.org/zap"
+)
+
+// scopeRepo keeps the stored scopes apart from the possibly stale ones a
+// read returns, as two event handlers racing on one user would see them.
+type scopeRepo struct {
+	repo.Repository
+	read    []string
+	stored  []string
+	catalog []*entity.Scope
+}
+
+func (r *scopeRepo) GetUserByUsername(_ context.Context, username string) (*entity.AuthUser, error) {
+	return &entity.AuthUser{ID: 1, Username: username, Scopes: slices.Clone(r.read)}, nil
+}
+
+func (r *scopeRepo) ListScopes(context.Context) ([]*entity.Scope, error) {
+	return r.catalog, nil
+}
+
+func (r *scopeRepo) AddUserScope(_ context.Context, _ uint, scope string) (bool, error) {
+	if slices.Contains(r.stored, scope) {
+		return false, nil
+	}
+	r.stored = append(r.stored, scope)
+	return true, nil
+}
+
+type recordingBroker struct{ messages []mq.Message }
+
+func (b *recordingBroker) Send(message mq.Message) error {
+	b.messages = append(b.messages, message)
+	return nil
+}
+
+// rejections returns the reason of every scope rejection sent.
+func (b *recordingBroker) rejections(t *testing.T) []string {
+	t.Helper()
+	var reasons []string
+	for _, message := range b.messages {
+		var event dto.UserEvent
+		if err := json.Unmarshal(message.Body, &event); err != nil {
+			t.Fatalf("unmarshal event: %v", err)
+		}
+		if event.Event == mq.EventUserScopeRejected {
+			reasons = append(reasons, event.Payload["reason"].(string))
+		}
+	}
+	return reasons
+}
+
+func TestAddAuthScope(t *testing.T) {
+	matcher, err := scope.NewMatcher(scope.Grammar{})
+	if err != nil {
+		t.Fatalf("NewMatcher: %v", err)
+	}
+	catalog := []*entity.Scope{
+		{Name: "server:read"},
+		{Name: "server:update"},
+		{Name: "user:read", Deprecated: true},
+	}
+
+	tests := []struct {
+		name       string
+		read       []string
+		stored     []string
+		scope      string
+		want       []string
+		rejections []string
+	}{
+		{"new scope", []string{"server:read"}, []string{"server:read"}, "server:update", []string{"server:read", "server:update"}, nil},
+		{"first scope", nil, nil, "server:read", []string{"server:read"}, nil},
+		{"already held", []string{"server:read"}, []string{"server:read"}, "server:read", []string{"server:read"}, []string{scopeDuplicate}},
+		{"granted concurrently", nil, []string{"server:update"}, "server:update", []string{"server:update"}, []string{scopeDuplicate}},
+		{"other grant kept", nil, []string{"server:read"}, "server:update", []string{"server:read", "server:update"}, nil},
+		{"unknown scope", nil, nil, "server:reboot", nil, []string{scopeUnknown}},
+		{"deprecated scope", nil, nil, "user:read", nil, []string{scopeDeprecated}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &scopeRepo{read: tt.read, stored: slices.Clone(tt.stored), catalog: catalog}
+			broker := &recordingBroker{}
+			u := &usecase{repo: r, broker: broker, scopes: matcher, logger: zap.NewNop()}
+
+			if err := u.AddAuthScope(context.Background(), map[string]interface{}{"user_name": "alice", "scope": tt.scope}); err != nil {
+				t.Fatalf("AddAuthScope: %v", err)
+			}
+			if !slices.Equal(r.stored, tt.want) {
+				t.Errorf("stored scopes = %q, want %q", r.stored, tt.want)
+			}
+			if got := broker.rejections(t); !slices.Equal(got, tt.rejections) {
+				t.Errorf("rejections = %q, want %q", got, tt.rejections)
+			}
+		})
+	}
+}
@@ -3,41 +3,85 @@ package auth
 import (
 	"context"
 	"errors"
-	"slices"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/golang-jwt/jwt"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/config"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/dto"
 	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/entity"
 	domain "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/errors"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/mq"
 	repo "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/repository"
 	srv "github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/service"
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/kafka/producer"
 	rdb "github.com/th1enq/ViettelSMS_AuthenticationService/internal/infrastucture/redis"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
 type usecase struct {
 	repo      repo.Repository
 	cache     rdb.CacheEngine
+	sessions  srv.SessionStore
 	password  srv.PasswordService
+	policy    srv.PasswordPolicy
+	breached  srv.BreachedPasswordChecker
+	captcha   srv.CaptchaVerifier
+	geoip     srv.GeoIPLocator
+	ipAccess  srv.IPAccessList
+	scopes    srv.ScopeMatcher
+	policies  srv.PolicyEngine
+	broker    producer.MessageBroker
+	mailer    srv.MailService
+	config    *config.Config
 	jwtSecret string
 	logger    *zap.Logger
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 func NewUseCase(
 	repo repo.Repository,
 	password srv.PasswordService,
+	policy srv.PasswordPolicy,
+	breached srv.BreachedPasswordChecker,
+	captcha srv.CaptchaVerifier,
+	geoip srv.GeoIPLocator,
+	ipAccess srv.IPAccessList,
+	scopes srv.ScopeMatcher,
+	policies srv.PolicyEngine,
 	cache rdb.CacheEngine,
-	jwtSecret string,
+	sessions srv.SessionStore,
+	broker producer.MessageBroker,
+	mailer srv.MailService,
+	config *config.Config,
 	logger *zap.Logger,
 ) UseCase {
 	return &usecase{
 		repo:      repo,
 		password:  password,
+		policy:    policy,
+		breached:  breached,
+		captcha:   captcha,
+		geoip:     geoip,
+		ipAccess:  ipAccess,
+		scopes:    scopes,
+		policies:  policies,
 		cache:     cache,
-		jwtSecret: jwtSecret,
+		sessions:  sessions,
+		broker:    broker,
+		mailer:    mailer,
+		config:    config,
+		jwtSecret: config.JWT.Secret,
 		logger:    logger,
 	}
 }
@@ -55,20 +99,31 @@ func (u *usecase) decodePayload(payload map[string]interface{}, result interface
 	}
 
 	if err := decoder.Decode(payload); err != nil {
-		u.logger.Error("Failed to decode payload", zap.Error(err), zap.Any("payload", payload))
+		u.logger.Error("Failed to decode payload", zap.Error(err), zap.Any("payload", redactPayload(payload)))
 		return err
 	}
 
 	return nil
 }
 
-func (u *usecase) Login(ctx context.Context, userName, password string) (*dto.LoginResponse, error) {
-	u.logger.Info("Login attempt", zap.String("username", userName))
+func (u *usecase) Login(ctx context.Context, req dto.LoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	userName, password := req.UserName, req.Password
+	u.logger.Info("Login attempt", zap.String("username", userName), zap.String("ip", client.IP))
+
+	if err := u.checkLoginChallenge(ctx, req, client); err != nil {
+		u.logger.Warn("Login challenge not satisfied", zap.String("username", userName), zap.String("ip", client.IP), zap.Error(err))
+		return nil, err
+	}
+
 	user, err := u.repo.GetUserByUsername(ctx, userName)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			u.logger.Warn("User not found", zap.String("username", userName))
-			return nil, domain.ErrUserNotFound
+			// Spend the same time as a real check so timing does not reveal
+			// which usernames exist.
+			u.verifyDummy(password)
+			u.recordLoginFailure(ctx, userName, client.IP)
+			return nil, domain.ErrInvalidCredentials
 		} else {
 			u.logger.Error("Failed to retrieve user", zap.String("username", userName), zap.Error(err))
 			return nil, domain.ErrInternalServer
@@ -80,28 +135,33 @@ func (u *usecase) Login(ctx context.Context, userName, password string) (*dto.Lo
 		u.logger.Error("Failed to verify password", zap.String("username", userName), zap.Error(err))
 		return nil, domain.ErrInternalServer
 	}
+
+	// A locked account answers like a wrong password, after the same hashing
+	// work, so a lockout does not reveal that the username exists. The owner
+	// hears about it through the account.locked event.
+	if isLocked(user) {
+		u.logger.Warn("Login attempt on locked account", zap.String("username", userName))
+		u.recordLoginFailure(ctx, userName, client.IP)
+		return nil, domain.ErrInvalidCredentials
+	}
 	if !ok {
 		u.logger.Warn("Invalid credentials", zap.String("username", userName))
+		u.recordLoginFailure(ctx, userName, client.IP)
+		u.registerFailedLogin(ctx, user)
 		return nil, domain.ErrInvalidCredentials
 	}
 
-	accessToken, err := u.generateAccessToken(user)
-	if err != nil {
-		u.logger.Error("Failed to generate access token", zap.String("username", userName), zap.Error(err))
-		return nil, domain.ErrInternalServer
-	}
+	u.clearLoginFailures(ctx, userName)
+	u.clearFailedLogins(ctx, user)
 
-	refreshToken, err := u.generateRefreshToken(user)
-	if err != nil {
-		u.logger.Error("Failed to generate refresh token", zap.String("username", userName), zap.Error(err))
-		return nil, domain.ErrInternalServer
+	if err := u.checkLoginIP(ctx, user, client); err != nil {
+		return nil, err
 	}
 
-	u.logger.Info("Login successful", zap.String("username", userName))
-	return &dto.LoginResponse{
-		AccessToken:  *accessToken,
-		RefreshToken: *refreshToken,
-	}, nil
+	u.rehashIfNeeded(ctx, user, password)
+	u.flagBreachedOnLogin(ctx, user, password)
+
+	return u.completeLogin(ctx, user, client)
 }
 
 func (u *usecase) RefreshToken(ctx context.Context, userID uint) (*string, error) {
@@ -111,7 +171,12 @@ func (u *usecase) RefreshToken(ctx context.Context, userID uint) (*string, error
 		return nil, domain.ErrInternalServer
 	}
 
-	accessToken, err := u.generateAccessToken(user)
+	if u.passwordChangeRequired(user) {
+		u.logger.Warn("Refresh refused until password is changed", zap.Uint("userID", userID))
+		return nil, domain.ErrPasswordChangeRequired
+	}
+
+	accessToken, err := u.generateAccessToken(ctx, user, "")
 	if err != nil {
 		u.logger.Error("Failed to generate access token", zap.Uint("userID", userID), zap.Error(err))
 		return nil, domain.ErrInternalServer
@@ -122,7 +187,7 @@ func (u *usecase) RefreshToken(ctx context.Context, userID uint) (*string, error
 }
 
 func (u *usecase) CreateAuthUser(ctx context.Context, payload map[string]interface{}) error {
-	u.logger.Info("Creating user", zap.Any("payload", payload))
+	u.logger.Info("Creating user", zap.Any("payload", redactPayload(payload)))
 
 	var req dto.UserCreate
 	if err := u.decodePayload(payload, &req); err != nil {
@@ -132,23 +197,38 @@ func (u *usecase) CreateAuthUser(ctx context.Context, payload map[string]interfa
 	u.logger.Info("Decoded user create request", zap.String("username", req.Username))
 
 	user := &entity.AuthUser{
-		Username: req.Username,
-		Password: req.Password,
-		Blocked:  req.Blocked,
-		Scopes:   req.Scopes,
+		Username:           req.Username,
+		Email:              req.Email,
+		Blocked:            req.Blocked,
+		PasswordChangedAt:  time.Now(),
+		MustChangePassword: req.MustChangePassword,
+	}
+
+	hashedPassword, err := u.ingestEventPassword(ctx, user, mq.EventUserCreated, req.Password)
+	if err != nil {
+		return ignorePolicyRejection(err)
+	}
+	user.Password = hashedPassword
+
+	user.Scopes, err = u.acceptGrants(ctx, req.Username, mq.EventUserCreated, nil, req.Scopes)
+	if err != nil {
+		return err
 	}
 
 	if err := u.repo.CreateUser(ctx, user); err != nil {
 		return err
 	}
 
+	u.recordPasswordHistory(ctx, user)
+	u.assignRoles(ctx, user, req.Roles)
+
 	u.logger.Info("User created successfully", zap.String("username", req.Username))
 
 	return nil
 }
 
 func (u *usecase) UpdateAuthUser(ctx context.Context, payload map[string]interface{}) error {
-	u.logger.Info("Updating user", zap.Any("payload", payload))
+	u.logger.Info("Updating user", zap.Any("payload", redactPayload(payload)))
 
 	var req dto.UserUpdate
 	if err := u.decodePayload(payload, &req); err != nil {
@@ -165,9 +245,12 @@ func (u *usecase) UpdateAuthUser(ctx context.Context, payload map[string]interfa
 		return err
 	}
 
-	user.Blocked = req.Blocked
+	columns := map[string]interface{}{"blocked": req.Blocked}
+	if req.Email != "" {
+		columns["email"] = req.Email
+	}
 
-	if err := u.repo.UpdateUser(ctx, user); err != nil {
+	if err := u.repo.UpdateUserColumns(ctx, user.ID, columns); err != nil {
 		return err
 	}
 
@@ -177,7 +260,7 @@ func (u *usecase) UpdateAuthUser(ctx context.Context, payload map[string]interfa
 }
 
 func (u *usecase) DeleteAuthUser(ctx context.Context, payload map[string]interface{}) error {
-	u.logger.Info("Deleting user", zap.Any("payload", payload))
+	u.logger.Info("Deleting user", zap.Any("payload", redactPayload(payload)))
 
 	var req dto.UserDelete
 	if err := u.decodePayload(payload, &req); err != nil {
@@ -194,6 +277,31 @@ func (u *usecase) DeleteAuthUser(ctx context.Context, payload map[string]interfa
 		return err
 	}
 
+	if err := u.repo.DeletePasswordHistory(ctx, user.ID); err != nil {
+		u.logger.Error("Failed to delete password history", zap.String("user_name", req.UserName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	if err := u.repo.DeleteUserRoles(ctx, user.ID); err != nil {
+		u.logger.Error("Failed to delete user roles", zap.String("user_name", req.UserName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	if err := u.repo.DeleteUserGroups(ctx, user.ID); err != nil {
+		u.logger.Error("Failed to delete user groups", zap.String("user_name", req.UserName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	if err := u.repo.DeleteKnownDevices(ctx, user.ID); err != nil {
+		u.logger.Error("Failed to delete known devices", zap.String("user_name", req.UserName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
+	if err := u.repo.DeleteSubjectPermissions(ctx, entity.PermissionSubjectUser, strconv.FormatUint(uint64(user.ID), 10)); err != nil {
+		u.logger.Error("Failed to delete resource permissions", zap.String("user_name", req.UserName), zap.Error(err))
+		return domain.ErrInternalServer
+	}
+
 	if err := u.repo.DeleteUser(ctx, user.ID); err != nil {
 		u.logger.Error("Failed to delete user", zap.String("user_name", req.UserName), zap.Error(err))
 		return domain.ErrInternalServer
@@ -205,7 +313,7 @@ func (u *usecase) DeleteAuthUser(ctx context.Context, payload map[string]interfa
 }
 
 func (u *usecase) UpdateAuthPassword(ctx context.Context, payload map[string]interface{}) error {
-	u.logger.Info("Updating user password", zap.Any("payload", payload))
+	u.logger.Info("Updating user password", zap.Any("payload", redactPayload(payload)))
 
 	var req dto.UserPasswordUpdate
 	if err := u.decodePayload(payload, &req); err != nil {
@@ -222,20 +330,33 @@ func (u *usecase) UpdateAuthPassword(ctx context.Context, payload map[string]int
 		return err
 	}
 
-	user.Password = req.Password
+	hashedPassword, err := u.ingestEventPassword(ctx, user, mq.EventUserUpdatedPassword, req.Password)
+	if err != nil {
+		return ignorePolicyRejection(err)
+	}
 
-	if err := u.repo.UpdateUser(ctx, user); err != nil {
+	user.Password = hashedPassword
+	user.PasswordChangedAt = time.Now()
+	user.MustChangePassword = req.MustChangePassword
+
+	if err := u.repo.UpdateUserColumns(ctx, user.ID, map[string]interface{}{
+		"password":             user.Password,
+		"password_changed_at":  user.PasswordChangedAt,
+		"must_change_password": user.MustChangePassword,
+	}); err != nil {
 		u.logger.Error("Failed to update user", zap.String("user_name", req.UserName), zap.Error(err))
 		return domain.ErrInternalServer
 	}
 
+	u.recordPasswordHistory(ctx, user)
+
 	u.logger.Info("User password updated successfully", zap.String("user_name", req.UserName))
 
 	return nil
 }
 
 func (u *usecase) AddAuthScope(ctx context.Context, payload map[string]interface{}) error {
-	u.logger.Info("Adding user scope", zap.Any("payload", payload))
+	u.logger.Info("Adding user scope", zap.Any("payload", redactPayload(payload)))
 
 	var req dto.UserScope
 	if err := u.decodePayload(payload, &req); err != nil {
@@ -252,11 +373,23 @@ func (u *usecase) AddAuthScope(ctx context.Context, payload map[string]interface
 		return err
 	}
 
-	user.Scopes = append(user.Scopes, req.Scope)
+	scopes, err := u.acceptGrants(ctx, req.UserName, mq.EventUserAddedScope, user.Scopes, []string{req.Scope})
+	if err != nil {
+		return err
+	}
+	if len(scopes) == len(user.Scopes) {
+		return nil
+	}
 
-	if err := u.repo.UpdateUser(ctx, user); err != nil {
+	added, err := u.repo.AddUserScope(ctx, user.ID, req.Scope)
+	if err != nil {
 		return err
 	}
+	if !added {
+		// Granted by a concurrent event since the user was read.
+		u.rejectScope(req.UserName, mq.EventUserAddedScope, req.Scope, scopeDuplicate)
+		return nil
+	}
 
 	u.logger.Info("User scope added successfully", zap.String("user_name", req.UserName), zap.String("scope", req.Scope))
 
@@ -264,7 +397,7 @@ func (u *usecase) AddAuthScope(ctx context.Context, payload map[string]interface
 }
 
 func (u *usecase) DeleteAuthScope(ctx context.Context, payload map[string]interface{}) error {
-	u.logger.Info("Deleting user scope", zap.Any("payload", payload))
+	u.logger.Info("Deleting user scope", zap.Any("payload", redactPayload(payload)))
 
 	var req dto.UserScope
 	if err := u.decodePayload(payload, &req); err != nil {
@@ -281,16 +414,7 @@ func (u *usecase) DeleteAuthScope(ctx context.Context, payload map[string]interf
 		return err
 	}
 
-	for i, scope := range user.Scopes {
-		if scope == req.Scope {
-			user.Scopes = slices.Delete(user.Scopes, i, i+1)
-			break
-		}
-	}
-
-	u.logger.Info("Current user scopes", zap.String("user_name", req.UserName), zap.Strings("scopes", user.Scopes))
-
-	if err := u.repo.UpdateUser(ctx, user); err != nil {
+	if err := u.repo.RemoveUserScope(ctx, user.ID, req.Scope); err != nil {
 		return err
 	}
 
@@ -299,17 +423,49 @@ func (u *usecase) DeleteAuthScope(ctx context.Context, payload map[string]interf
 	return nil
 }
 
-func (u *usecase) generateAccessToken(user *entity.AuthUser) (*string, error) {
-	scopes := make([]string, len(user.Scopes))
-	copy(scopes, user.Scopes)
+// issueTokens opens a session and mints the access and refresh token pair
+// handed out by every interactive grant (password login, device authorization).
+func (u *usecase) issueTokens(ctx context.Context, user *entity.AuthUser) (*dto.LoginResponse, error) {
+	sessionID, err := u.sessions.Create(ctx, user.ID, refreshTokenTTL)
+	if err != nil {
+		u.logger.Error("Failed to create session", zap.String("username", user.Username), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	accessToken, err := u.generateAccessToken(ctx, user, sessionID)
+	if err != nil {
+		u.logger.Error("Failed to generate access token", zap.String("username", user.Username), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	refreshToken, err := u.generateRefreshToken(user, sessionID)
+	if err != nil {
+		u.logger.Error("Failed to generate refresh token", zap.String("username", user.Username), zap.Error(err))
+		return nil, domain.ErrInternalServer
+	}
+
+	return &dto.LoginResponse{
+		AccessToken:  *accessToken,
+		RefreshToken: *refreshToken,
+	}, nil
+}
+
+func (u *usecase) generateAccessToken(ctx context.Context, user *entity.AuthUser, sessionID string) (*string, error) {
+	scopes, err := u.effectiveScopes(ctx, user)
+	if err != nil {
+		return nil, err
+	}
 
 	claims := jwt.MapClaims{
 		"sub":     user.ID,
 		"blocked": user.Blocked,
 		"scopes":  scopes,
-		"exp":     time.Now().Add(time.Minute * 15).Unix(),
+		"exp":     time.Now().Add(accessTokenTTL).Unix(),
 		"iat":     time.Now().Unix(),
 	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	signedToken, err := token.SignedString([]byte(u.jwtSecret))
@@ -319,10 +475,11 @@ func (u *usecase) generateAccessToken(user *entity.AuthUser) (*string, error) {
 	return &signedToken, nil
 }
 
-func (u *usecase) generateRefreshToken(user *entity.AuthUser) (*string, error) {
+func (u *usecase) generateRefreshToken(user *entity.AuthUser, sessionID string) (*string, error) {
 	claims := jwt.MapClaims{
 		"sub": user.ID,
-		"exp": time.Now().Add(time.Hour * 24 * 7).Unix(),
+		"sid": sessionID,
+		"exp": time.Now().Add(refreshTokenTTL).Unix(),
 		"iat": time.Now().Unix(),
 	}
 
@@ -334,6 +491,15 @@ func (u *usecase) generateRefreshToken(user *entity.AuthUser) (*string, error) {
 	return &signedToken, nil
 }
 
+// ignorePolicyRejection drops a password rejection that has already been
+// reported back to UserService; retrying the same event would fail again.
+func ignorePolicyRejection(err error) error {
+	if errors.Is(err, domain.ErrWeakPassword) {
+		return nil
+	}
+	return err
+}
+
 func (u *usecase) getUserByUserName(ctx context.Context, userName string) (*entity.AuthUser, error) {
 	user, err := u.repo.GetUserByUsername(ctx, userName)
 	if err != nil {
@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+)
+
+// CertThumbprint returns the base64url-encoded SHA-256 digest of the DER
+// certificate, as used by the RFC 8705 "x5t#S256" confirmation method.
+func CertThumbprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE machine_identities (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    subject VARCHAR(512) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL DEFAULT '',
+    blocked BOOLEAN NOT NULL DEFAULT FALSE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_machine_identities_fingerprint
+    ON machine_identities (fingerprint)
+    WHERE fingerprint <> '';
+
+CREATE INDEX idx_machine_identities_subject ON machine_identities (subject);
+
+-- +goose Down
+DROP TABLE machine_identities;
@@ -0,0 +1,8 @@
+-- +goose Up
+ALTER TABLE auth_users ADD COLUMN email VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX idx_auth_users_email ON auth_users (LOWER(email));
+
+-- +goose Down
+DROP INDEX idx_auth_users_email;
+ALTER TABLE auth_users DROP COLUMN email;
@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE password_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES auth_users (id) ON DELETE CASCADE,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_history_user_created ON password_history (user_id, created_at DESC);
+
+INSERT INTO password_history (user_id, password_hash)
+SELECT id, password FROM auth_users;
+
+-- +goose Down
+DROP TABLE password_history;
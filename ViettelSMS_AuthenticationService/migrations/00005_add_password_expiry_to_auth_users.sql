@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE auth_users
+    ADD COLUMN password_changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ADD COLUMN must_change_password BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE auth_users
+    DROP COLUMN password_changed_at,
+    DROP COLUMN must_change_password;
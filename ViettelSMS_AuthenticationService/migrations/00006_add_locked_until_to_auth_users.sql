@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE auth_users ADD COLUMN locked_until TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE auth_users DROP COLUMN locked_until;
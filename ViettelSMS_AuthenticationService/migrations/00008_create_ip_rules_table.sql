@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE ip_rules (
+    id BIGSERIAL PRIMARY KEY,
+    subject_type VARCHAR(16) NOT NULL CHECK (subject_type IN ('user', 'scope', 'machine')),
+    subject VARCHAR(255) NOT NULL,
+    action VARCHAR(8) NOT NULL CHECK (action IN ('allow', 'deny')),
+    cidr CIDR NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subject_type, subject, action, cidr)
+);
+
+-- +goose Down
+DROP TABLE ip_rules;
@@ -0,0 +1,76 @@
+-- +goose Up
+CREATE TABLE roles (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE role_scopes (
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    scope VARCHAR(255) NOT NULL,
+    PRIMARY KEY (role_id, scope)
+);
+
+CREATE TABLE user_roles (
+    user_id BIGINT NOT NULL REFERENCES auth_users (id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role_id ON user_roles (role_id);
+
+INSERT INTO roles (name, description, built_in) VALUES
+    ('viewer', 'Read-only access to users and servers', TRUE),
+    ('operator', 'Manage servers, read-only access to users', TRUE),
+    ('admin', 'Full access', TRUE);
+
+INSERT INTO role_scopes (role_id, scope)
+SELECT r.id, s.scope
+FROM roles r
+JOIN (VALUES
+    ('viewer', 'user:view'),
+    ('viewer', 'user:read'),
+    ('viewer', 'server:view'),
+    ('viewer', 'server:read'),
+
+    ('operator', 'user:view'),
+    ('operator', 'user:read'),
+    ('operator', 'server:view'),
+    ('operator', 'server:read'),
+    ('operator', 'server:update'),
+    ('operator', 'server:import'),
+    ('operator', 'server:export'),
+
+    ('admin', 'user:create'),
+    ('admin', 'user:read'),
+    ('admin', 'user:update'),
+    ('admin', 'user:delete'),
+    ('admin', 'user:view'),
+    ('admin', 'user:scope'),
+    ('admin', 'server:read'),
+    ('admin', 'server:update'),
+    ('admin', 'server:delete'),
+    ('admin', 'server:view'),
+    ('admin', 'server:import'),
+    ('admin', 'server:export')
+) AS s (role, scope) ON s.role = r.name;
+
+-- The seeded admin account gets its scopes from the admin role instead of
+-- the list written into 00001.
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id FROM auth_users u, roles r
+WHERE u.username = 'admin' AND r.name = 'admin';
+
+UPDATE auth_users SET scopes = '{}' WHERE username = 'admin';
+
+-- +goose Down
+UPDATE auth_users SET scopes = '{"user:create", "user:read", "user:update", "user:delete","user:view",
+     "user:scope", "server:read", "server:update", "server:delete", "server:view", "server:import", "server:export"}'
+WHERE username = 'admin';
+
+DROP TABLE user_roles;
+DROP TABLE role_scopes;
+DROP TABLE roles;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- With the scope grammar in config/scopes.yaml the built-in roles no longer
+-- need to list every scope: implied and wildcard scopes are expanded when
+-- tokens are minted.
+DELETE FROM role_scopes
+WHERE role_id IN (SELECT id FROM roles WHERE name IN ('viewer', 'operator', 'admin') AND built_in);
+
+INSERT INTO role_scopes (role_id, scope)
+SELECT r.id, s.scope
+FROM roles r
+JOIN (VALUES
+    ('viewer', '*:read'),
+
+    ('operator', 'user:read'),
+    ('operator', 'server:update'),
+    ('operator', 'server:import'),
+    ('operator', 'server:export'),
+
+    ('admin', '*:*')
+) AS s (role, scope) ON s.role = r.name
+WHERE r.built_in;
+
+-- +goose Down
+DELETE FROM role_scopes
+WHERE role_id IN (SELECT id FROM roles WHERE name IN ('viewer', 'operator', 'admin') AND built_in);
+
+INSERT INTO role_scopes (role_id, scope)
+SELECT r.id, s.scope
+FROM roles r
+JOIN (VALUES
+    ('viewer', 'user:view'),
+    ('viewer', 'user:read'),
+    ('viewer', 'server:view'),
+    ('viewer', 'server:read'),
+
+    ('operator', 'user:view'),
+    ('operator', 'user:read'),
+    ('operator', 'server:view'),
+    ('operator', 'server:read'),
+    ('operator', 'server:update'),
+    ('operator', 'server:import'),
+    ('operator', 'server:export'),
+
+    ('admin', 'user:create'),
+    ('admin', 'user:read'),
+    ('admin', 'user:update'),
+    ('admin', 'user:delete'),
+    ('admin', 'user:view'),
+    ('admin', 'user:scope'),
+    ('admin', 'server:read'),
+    ('admin', 'server:update'),
+    ('admin', 'server:delete'),
+    ('admin', 'server:view'),
+    ('admin', 'server:import'),
+    ('admin', 'server:export')
+) AS s (role, scope) ON s.role = r.name
+WHERE r.built_in;
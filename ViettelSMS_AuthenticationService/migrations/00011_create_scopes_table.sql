@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE scopes (
+    name VARCHAR(255) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    service VARCHAR(64) NOT NULL,
+    deprecated BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO scopes (name, description, service) VALUES
+    ('user:create', 'Create users', 'user'),
+    ('user:read', 'Read user details', 'user'),
+    ('user:update', 'Update, block and unlock users', 'user'),
+    ('user:delete', 'Delete users', 'user'),
+    ('user:view', 'List users', 'user'),
+    ('user:scope', 'Grant scopes and roles to users', 'user'),
+    ('server:read', 'Read server details and status', 'server'),
+    ('server:update', 'Update servers', 'server'),
+    ('server:delete', 'Delete servers', 'server'),
+    ('server:view', 'List servers', 'server'),
+    ('server:import', 'Import servers from files', 'server'),
+    ('server:export', 'Export servers to files', 'server'),
+    ('auth:authorize', 'Ask the authorization endpoint for policy decisions', 'auth');
+
+-- Grants used to be appended blindly.
+UPDATE auth_users
+SET scopes = ARRAY(SELECT DISTINCT s FROM unnest(scopes) AS s ORDER BY s)
+WHERE cardinality(scopes) > 0;
+
+-- +goose Down
+DROP TABLE scopes;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE resource_permissions (
+    id BIGSERIAL PRIMARY KEY,
+    subject_type VARCHAR(16) NOT NULL CHECK (subject_type IN ('user', 'role', 'machine')),
+    subject VARCHAR(255) NOT NULL,
+    resource_type VARCHAR(64) NOT NULL,
+    resource VARCHAR(255) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (subject_type, subject, resource_type, resource, action)
+);
+
+CREATE INDEX idx_resource_permissions_resource ON resource_permissions (resource_type, resource);
+
+-- +goose Down
+DROP TABLE resource_permissions;
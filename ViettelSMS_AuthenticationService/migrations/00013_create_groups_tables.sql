@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE groups (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE group_scopes (
+    group_id BIGINT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    scope VARCHAR(255) NOT NULL,
+    PRIMARY KEY (group_id, scope)
+);
+
+CREATE TABLE group_roles (
+    group_id BIGINT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, role_id)
+);
+
+-- A group nested in a parent inherits the parent's scopes and roles. The
+-- service refuses edges that would close a cycle.
+CREATE TABLE group_parents (
+    group_id BIGINT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    parent_id BIGINT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, parent_id),
+    CHECK (group_id <> parent_id)
+);
+
+CREATE TABLE group_members (
+    group_id BIGINT NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES auth_users (id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX idx_group_members_user_id ON group_members (user_id);
+
+-- +goose Down
+DROP TABLE group_members;
+DROP TABLE group_parents;
+DROP TABLE group_roles;
+DROP TABLE group_scopes;
+DROP TABLE groups;
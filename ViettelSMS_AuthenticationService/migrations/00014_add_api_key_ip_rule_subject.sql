@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE ip_rules DROP CONSTRAINT ip_rules_subject_type_check;
+ALTER TABLE ip_rules ADD CONSTRAINT ip_rules_subject_type_check
+    CHECK (subject_type IN ('user', 'scope', 'machine', 'api_key'));
+
+-- +goose Down
+DELETE FROM ip_rules WHERE subject_type = 'api_key';
+ALTER TABLE ip_rules DROP CONSTRAINT ip_rules_subject_type_check;
+ALTER TABLE ip_rules ADD CONSTRAINT ip_rules_subject_type_check
+    CHECK (subject_type IN ('user', 'scope', 'machine'));
@@ -0,0 +1,233 @@
+// Package authclient verifies access tokens issued by the authentication
+// service, for the other ViettelSMS services. It applies the checks of the
+// service's own JWT middleware (signature, blocked and restricted tokens,
+// session revocation and certificate binding) and exposes the result as a
+// typed Principal through gin and net/http middlewares and gRPC
+// interceptors.
+//
+// The package lives in the service's own module rather than one of its own,
+// so the claims and error bodies it mirrors are versioned together with the
+// service that produces them. Importing it only builds its own dependencies
+// (jwt, go-redis, gin and grpc), not the service's.
+//
+// The auth service signs every token with HS256 and its JWT_SECRET. It
+// publishes no key set, so SharedSecret is the only way to verify its tokens
+// today; JWKSURL and NewJWKS are for a future asymmetric signer and reject
+// every token the service currently issues.
+package authclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultJWKSRefresh           = 10 * time.Minute
+	defaultIntrospectionCacheTTL = 30 * time.Second
+)
+
+type Config struct {
+	// SharedSecret verifies HMAC tokens; it is the auth service's JWT_SECRET.
+	SharedSecret []byte
+	// JWKSURL verifies RSA and ECDSA tokens against a published key set,
+	// refetched every JWKSRefresh (default 10m).
+	//
+	// NOT USABLE YET: the auth service signs only HS256 tokens and serves no
+	// key set. A verifier configured with JWKSURL alone rejects all of its
+	// tokens; set SharedSecret.
+	JWKSURL     string
+	JWKSRefresh time.Duration
+
+	// Redis, when set, is checked directly for revoked sessions.
+	Redis redis.UniversalClient
+	// IntrospectionURL is the auth service's /auth/introspect, asked when
+	// Redis is not configured or unreachable, with a token from
+	// IntrospectionToken. Answers are cached for IntrospectionCacheTTL
+	// (default 30s).
+	IntrospectionURL      string
+	IntrospectionToken    TokenSource
+	IntrospectionCacheTTL time.Duration
+
+	// HTTPClient is used for the key set and introspection.
+	HTTPClient *http.Client
+	// Leeway tolerates clock skew on exp, nbf and iat.
+	Leeway time.Duration
+
+	// Keys and Revocation replace the sources built from the fields above.
+	Keys       []KeySource
+	Revocation RevocationChecker
+}
+
+// Verifier turns a bearer token into a Principal. Errors wrap the Err
+// values of this package.
+type Verifier interface {
+	Verify(ctx context.Context, token string) (*Principal, error)
+}
+
+// claims mirrors the access token claims minted by the auth service.
+type claims struct {
+	Sub        uint          `json:"sub"`
+	Sid        string        `json:"sid,omitempty"`
+	Scopes     []string      `json:"scopes"`
+	Blocked    bool          `json:"blocked"`
+	Machine    string        `json:"machine,omitempty"`
+	Restricted bool          `json:"restricted,omitempty"`
+	Cnf        *confirmation `json:"cnf,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type confirmation struct {
+	X5tS256 string `json:"x5t#S256"`
+}
+
+type verifier struct {
+	keys       map[string]KeySource
+	methods    []string
+	revocation RevocationChecker
+	leeway     time.Duration
+}
+
+func NewVerifier(cfg Config) (Verifier, error) {
+	sources := cfg.Keys
+	if len(sources) == 0 {
+		if len(cfg.SharedSecret) > 0 {
+			sources = append(sources, NewSharedKey(cfg.SharedSecret))
+		}
+		if cfg.JWKSURL != "" {
+			refresh := cfg.JWKSRefresh
+			if refresh <= 0 {
+				refresh = defaultJWKSRefresh
+			}
+			sources = append(sources, NewJWKS(cfg.JWKSURL, cfg.HTTPClient, refresh))
+		}
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("authclient: a shared secret, a JWKS URL or a key source is required")
+	}
+
+	v := &verifier{keys: map[string]KeySource{}, leeway: cfg.Leeway}
+	for _, source := range sources {
+		for _, method := range source.Methods() {
+			if _, ok := v.keys[method]; !ok {
+				v.keys[method] = source
+				v.methods = append(v.methods, method)
+			}
+		}
+	}
+
+	v.revocation = cfg.Revocation
+	if v.revocation == nil {
+		var checkers []RevocationChecker
+		if cfg.Redis != nil {
+			checkers = append(checkers, NewRedisRevocation(cfg.Redis))
+		}
+		if cfg.IntrospectionURL != "" {
+			if cfg.IntrospectionToken == nil {
+				return nil, fmt.Errorf("authclient: introspection requires a token source")
+			}
+			ttl := cfg.IntrospectionCacheTTL
+			if ttl <= 0 {
+				ttl = defaultIntrospectionCacheTTL
+			}
+			checkers = append(checkers, NewIntrospection(cfg.IntrospectionURL, cfg.HTTPClient, cfg.IntrospectionToken, ttl))
+		}
+		if len(checkers) == 0 {
+			// Without a revocation check a logged out token would keep
+			// working until it expires.
+			return nil, fmt.Errorf("authclient: Redis or an introspection URL is required to check revocation")
+		}
+		v.revocation = NewFallbackRevocation(checkers...)
+	}
+
+	return v, nil
+}
+
+func (v *verifier) Verify(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrMissingToken
+	}
+
+	var c claims
+	parsed, err := jwt.ParseWithClaims(token, &c, func(t *jwt.Token) (interface{}, error) {
+		source, ok := v.keys[t.Method.Alg()]
+		if !ok {
+			return nil, fmt.Errorf("unexpected token signing method: %v", t.Header["alg"])
+		}
+		return source.Key(ctx, t)
+	}, jwt.WithValidMethods(v.methods), jwt.WithLeeway(v.leeway))
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+	if !parsed.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	if c.Blocked {
+		return nil, ErrUserBlocked
+	}
+	// Restricted tokens only open the auth service's change-password
+	// endpoint.
+	if c.Restricted {
+		return nil, ErrPasswordChangeRequired
+	}
+
+	p := &Principal{
+		UserID:    c.Sub,
+		Machine:   c.Machine,
+		SessionID: c.Sid,
+		Scopes:    c.Scopes,
+	}
+	if c.IssuedAt != nil {
+		p.IssuedAt = c.IssuedAt.Time
+	}
+	if c.ExpiresAt != nil {
+		p.ExpiresAt = c.ExpiresAt.Time
+	}
+	if c.Cnf != nil {
+		p.CertThumbprint = c.Cnf.X5tS256
+	}
+
+	if p.SessionID != "" {
+		active, err := v.revocation.Active(ctx, token, p)
+		if err != nil {
+			if errors.Is(err, ErrUnavailable) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		if !active {
+			return nil, ErrTokenRevoked
+		}
+	}
+
+	return p, nil
+}
+
+// checkBinding enforces RFC 8705: a certificate-bound token is only
+// accepted from the client certificate it was issued to.
+func checkBinding(p *Principal, peerCertificates []*x509.Certificate) error {
+	if p.CertThumbprint == "" {
+		return nil
+	}
+	if len(peerCertificates) == 0 {
+		return fmt.Errorf("%w: no client certificate presented", ErrCertificateMismatch)
+	}
+
+	sum := sha256.Sum256(peerCertificates[0].Raw)
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != p.CertThumbprint {
+		return ErrCertificateMismatch
+	}
+	return nil
+}
@@ -0,0 +1,441 @@
+package authclient
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+)
+
+var testSecret = []byte("test-secret")
+
+func testClaims() claims {
+	now := time.Now()
+	return claims{
+		Sub:    7,
+		Scopes: []string{"server:read"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key interface{}, c claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return token
+}
+
+func newTestRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client, mr
+}
+
+func TestNewVerifierRequirements(t *testing.T) {
+	client, _ := newTestRedis(t)
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no key", Config{Redis: client}},
+		{"no revocation", Config{SharedSecret: testSecret}},
+		{"introspection without token", Config{SharedSecret: testSecret, IntrospectionURL: "http://auth/introspect"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewVerifier(tt.cfg); err == nil {
+				t.Error("NewVerifier accepted an incomplete config")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	client, mr := newTestRedis(t)
+	if err := mr.Set(SessionKeyPrefix+"live", "1"); err != nil {
+		t.Fatalf("set session: %v", err)
+	}
+	v, err := NewVerifier(Config{SharedSecret: testSecret, Redis: client, Leeway: time.Minute})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	with := func(change func(*claims)) claims {
+		c := testClaims()
+		change(&c)
+		return c
+	}
+	none, err := jwt.NewWithClaims(jwt.SigningMethodNone, testClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("sign none token: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", signToken(t, jwt.SigningMethodHS256, testSecret, testClaims()), nil},
+		{"HS512", signToken(t, jwt.SigningMethodHS512, testSecret, testClaims()), nil},
+		{"live session", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(c *claims) { c.Sid = "live" })), nil},
+		{"expired within leeway", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(c *claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-30 * time.Second))
+		})), nil},
+
+		{"missing", "", ErrMissingToken},
+		{"malformed", "not.a.token", ErrInvalidToken},
+		{"wrong secret", signToken(t, jwt.SigningMethodHS256, []byte("other"), testClaims()), ErrInvalidToken},
+		{"alg none", none, ErrInvalidToken},
+		{"alg not configured", signToken(t, jwt.SigningMethodRS256, rsaKey, testClaims()), ErrInvalidToken},
+		{"expired", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(c *claims) {
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+		})), ErrInvalidToken},
+		{"blocked", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(c *claims) { c.Blocked = true })), ErrUserBlocked},
+		{"restricted", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(c *claims) { c.Restricted = true })), ErrPasswordChangeRequired},
+		{"revoked session", signToken(t, jwt.SigningMethodHS256, testSecret, with(func(c *claims) { c.Sid = "gone" })), ErrTokenRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if p.UserID != 7 || !p.HasScope("server:read") || p.ExpiresAt.IsZero() {
+				t.Errorf("Verify = %+v, want user 7 with server:read", p)
+			}
+		})
+	}
+}
+
+func TestVerifyPrincipal(t *testing.T) {
+	client, mr := newTestRedis(t)
+	if err := mr.Set(SessionKeyPrefix+"s1", "1"); err != nil {
+		t.Fatalf("set session: %v", err)
+	}
+	v, err := NewVerifier(Config{SharedSecret: testSecret, Redis: client})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	c := testClaims()
+	c.Sid = "s1"
+	c.Machine = "scanner"
+	c.Cnf = &confirmation{X5tS256: "thumb"}
+	p, err := v.Verify(context.Background(), signToken(t, jwt.SigningMethodHS256, testSecret, c))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if p.SessionID != "s1" || !p.IsMachine() || p.Machine != "scanner" || p.CertThumbprint != "thumb" ||
+		!p.IssuedAt.Equal(c.IssuedAt.Time) || !p.ExpiresAt.Equal(c.ExpiresAt.Time) {
+		t.Errorf("Verify = %+v, want every claim carried over", p)
+	}
+}
+
+func TestVerifyRevocationUnavailable(t *testing.T) {
+	client, mr := newTestRedis(t)
+	v, err := NewVerifier(Config{SharedSecret: testSecret, Redis: client})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	mr.Close()
+
+	c := testClaims()
+	if _, err := v.Verify(context.Background(), signToken(t, jwt.SigningMethodHS256, testSecret, c)); err != nil {
+		t.Errorf("Verify without a session ID = %v, want no revocation check", err)
+	}
+	c.Sid = "s1"
+	if _, err := v.Verify(context.Background(), signToken(t, jwt.SigningMethodHS256, testSecret, c)); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Verify error = %v, want %v", err, ErrUnavailable)
+	}
+}
+
+// A JWKS verifier must not accept HMAC tokens, or the public key would work
+// as an HMAC secret.
+func TestVerifyJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+		}}})
+	}))
+	defer server.Close()
+
+	client, _ := newTestRedis(t)
+	v, err := NewVerifier(Config{JWKSURL: server.URL, Redis: client})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+
+	rs256 := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims())
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(rsaKey)
+		if err != nil {
+			t.Fatalf("sign token: %v", err)
+		}
+		return signed
+	}
+
+	if _, err := v.Verify(context.Background(), rs256("k1")); err != nil {
+		t.Fatalf("Verify(RS256): %v", err)
+	}
+	if _, err := v.Verify(context.Background(), rs256("k1")); err != nil || fetches != 1 {
+		t.Fatalf("Verify(RS256) again = %v after %d fetches, want the cached key set", err, fetches)
+	}
+	if _, err := v.Verify(context.Background(), rs256("unknown")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify(unknown kid) error = %v, want %v", err, ErrInvalidToken)
+	}
+	if _, err := v.Verify(context.Background(), signToken(t, jwt.SigningMethodHS256, testSecret, testClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify(HS256) error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+type stubRevocation struct {
+	active bool
+	err    error
+	calls  int
+}
+
+func (s *stubRevocation) Active(context.Context, string, *Principal) (bool, error) {
+	s.calls++
+	return s.active, s.err
+}
+
+func TestFallbackRevocation(t *testing.T) {
+	down := errors.New("down")
+
+	tests := []struct {
+		name       string
+		checkers   []*stubRevocation
+		active     bool
+		wantErr    error
+		wantCalled []int
+	}{
+		{"first answers", []*stubRevocation{{active: true}, {active: false}}, true, nil, []int{1, 0}},
+		{"first says revoked", []*stubRevocation{{active: false}, {active: true}}, false, nil, []int{1, 0}},
+		{"falls back", []*stubRevocation{{err: down}, {active: true}}, true, nil, []int{1, 1}},
+		{"all down", []*stubRevocation{{err: down}, {err: down}}, false, ErrUnavailable, []int{1, 1}},
+		{"none", nil, false, ErrUnavailable, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checkers []RevocationChecker
+			for _, checker := range tt.checkers {
+				checkers = append(checkers, checker)
+			}
+
+			active, err := NewFallbackRevocation(checkers...).Active(context.Background(), "token", &Principal{SessionID: "s1"})
+			if !errors.Is(err, tt.wantErr) || active != tt.active {
+				t.Fatalf("Active = %v, %v, want %v, %v", active, err, tt.active, tt.wantErr)
+			}
+			if tt.wantErr != nil && len(tt.checkers) > 0 && !errors.Is(err, down) {
+				t.Errorf("Active error = %v, want the checker errors joined", err)
+			}
+			for i, checker := range tt.checkers {
+				if checker.calls != tt.wantCalled[i] {
+					t.Errorf("checker %d called %d times, want %d", i, checker.calls, tt.wantCalled[i])
+				}
+			}
+		})
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	calls := 0
+	active := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer service-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("token") != "user-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]bool{"active": active})
+	}))
+	defer server.Close()
+
+	serviceToken := func(context.Context) (string, error) { return "service-token", nil }
+	checker := NewIntrospection(server.URL, nil, serviceToken, time.Minute).(*introspection)
+	p := &Principal{SessionID: "s1", ExpiresAt: time.Now().Add(time.Hour)}
+	ctx := context.Background()
+
+	if got, err := checker.Active(ctx, "user-token", p); err != nil || !got {
+		t.Fatalf("Active = %v, %v, want active", got, err)
+	}
+
+	// Cached until the TTL expires, even once revoked.
+	active = false
+	if got, err := checker.Active(ctx, "user-token", p); err != nil || !got || calls != 1 {
+		t.Fatalf("Active = %v, %v after %d calls, want the cached answer", got, err, calls)
+	}
+
+	for key, result := range checker.cache {
+		result.expires = time.Now().Add(-time.Second)
+		checker.cache[key] = result
+	}
+	if got, err := checker.Active(ctx, "user-token", p); err != nil || got || calls != 2 {
+		t.Fatalf("Active = %v, %v after %d calls, want a fresh revoked answer", got, err, calls)
+	}
+}
+
+func TestIntrospectionCacheBoundedByExpiry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]bool{"active": true})
+	}))
+	defer server.Close()
+
+	checker := NewIntrospection(server.URL, nil, func(context.Context) (string, error) { return "t", nil }, time.Hour).(*introspection)
+	expires := time.Now().Add(time.Minute)
+	if _, err := checker.Active(context.Background(), "user-token", &Principal{ExpiresAt: expires}); err != nil {
+		t.Fatalf("Active: %v", err)
+	}
+	for _, result := range checker.cache {
+		if result.expires.After(expires) {
+			t.Errorf("cached until %s, after the token expires at %s", result.expires, expires)
+		}
+	}
+}
+
+func TestIntrospectionErrors(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	garbled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("{"))
+	}))
+	defer garbled.Close()
+
+	token := func(context.Context) (string, error) { return "t", nil }
+	noToken := func(context.Context) (string, error) { return "", errors.New("no token") }
+
+	tests := []struct {
+		name    string
+		checker RevocationChecker
+	}{
+		{"server error", NewIntrospection(failing.URL, nil, token, time.Minute)},
+		{"malformed answer", NewIntrospection(garbled.URL, nil, token, time.Minute)},
+		{"no service token", NewIntrospection(garbled.URL, nil, noToken, time.Minute)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.checker.Active(context.Background(), "user-token", &Principal{}); err == nil {
+				t.Error("Active succeeded")
+			}
+		})
+	}
+}
+
+func TestCheckBinding(t *testing.T) {
+	cert := &x509.Certificate{Raw: []byte("client certificate")}
+	other := &x509.Certificate{Raw: []byte("another certificate")}
+	sum := sha256.Sum256(cert.Raw)
+	thumbprint := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	tests := []struct {
+		name       string
+		thumbprint string
+		certs      []*x509.Certificate
+		wantErr    error
+	}{
+		{"unbound without certificate", "", nil, nil},
+		{"unbound with certificate", "", []*x509.Certificate{other}, nil},
+		{"bound and matching", thumbprint, []*x509.Certificate{cert}, nil},
+		{"bound without certificate", thumbprint, nil, ErrCertificateMismatch},
+		{"bound to another certificate", thumbprint, []*x509.Certificate{other}, ErrCertificateMismatch},
+		{"only the leaf counts", thumbprint, []*x509.Certificate{other, cert}, ErrCertificateMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBinding(&Principal{CertThumbprint: tt.thumbprint}, tt.certs)
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("checkBinding error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMiddlewareStatus(t *testing.T) {
+	client, _ := newTestRedis(t)
+	v, err := NewVerifier(Config{SharedSecret: testSecret, Redis: client})
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	handler := Middleware(v)(RequireScopes("server:update")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	blocked := testClaims()
+	blocked.Blocked = true
+	updater := testClaims()
+	updater.Scopes = []string{"server:*"}
+
+	tests := []struct {
+		name   string
+		header string
+		want   int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"not bearer", "Basic abc", http.StatusUnauthorized},
+		{"blocked", "Bearer " + signToken(t, jwt.SigningMethodHS256, testSecret, blocked), http.StatusForbidden},
+		{"missing scope", "Bearer " + signToken(t, jwt.SigningMethodHS256, testSecret, testClaims()), http.StatusForbidden},
+		{"allowed", "Bearer " + signToken(t, jwt.SigningMethodHS256, testSecret, updater), http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/servers", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
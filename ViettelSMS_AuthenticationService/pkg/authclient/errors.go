@@ -0,0 +1,33 @@
+package authclient
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	ErrMissingToken           = errors.New("missing or malformed token")
+	ErrInvalidToken           = errors.New("invalid token")
+	ErrTokenRevoked           = errors.New("session revoked")
+	ErrUserBlocked            = errors.New("user is blocked")
+	ErrPasswordChangeRequired = errors.New("password change required")
+	ErrCertificateMismatch    = errors.New("client certificate does not match token binding")
+	ErrInsufficientScope      = errors.New("insufficient scope")
+	// ErrUnavailable means revocation could not be checked, neither in Redis
+	// nor by introspection. The request should be retried, not refused.
+	ErrUnavailable = errors.New("token revocation check unavailable")
+)
+
+// httpStatus maps a verification error to the status the auth service
+// itself would answer with.
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrUserBlocked),
+		errors.Is(err, ErrPasswordChangeRequired),
+		errors.Is(err, ErrInsufficientScope):
+		return http.StatusForbidden
+	case errors.Is(err, ErrUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusUnauthorized
+}
@@ -0,0 +1,57 @@
+package authclient
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GinMiddleware verifies the bearer token of every request and stores the
+// Principal for FromGin and, in the request context, FromContext.
+func GinMiddleware(v Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, err := v.Verify(c.Request.Context(), bearerToken(c.GetHeader("Authorization")))
+		if err == nil {
+			err = checkBinding(p, peerCertificates(c.Request))
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(errorResponse(err, nil))
+			return
+		}
+
+		c.Set(ginPrincipalKey, p)
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), p))
+		c.Next()
+	}
+}
+
+// GinRequireScopes must follow GinMiddleware. It lets a request through
+// only when the token grants every scope.
+func GinRequireScopes(scopes ...string) gin.HandlerFunc {
+	return requireGin(strings.Join(scopes, " && "), func(p *Principal) bool {
+		return p.HasAllScopes(scopes...)
+	})
+}
+
+// GinRequireAnyScope must follow GinMiddleware. It lets a request through
+// when the token grants at least one of the scopes.
+func GinRequireAnyScope(scopes ...string) gin.HandlerFunc {
+	return requireGin(strings.Join(scopes, " || "), func(p *Principal) bool {
+		return p.HasAnyScope(scopes...)
+	})
+}
+
+func requireGin(required string, granted func(*Principal) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := FromGin(c)
+		if !ok {
+			c.AbortWithStatusJSON(errorResponse(ErrMissingToken, nil))
+			return
+		}
+		if !granted(p) {
+			c.AbortWithStatusJSON(errorResponse(ErrInsufficientScope, scopeDenial(required)))
+			return
+		}
+		c.Next()
+	}
+}
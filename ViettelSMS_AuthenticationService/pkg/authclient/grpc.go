@@ -0,0 +1,106 @@
+package authclient
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCOptions configures the interceptors. Methods are full method names,
+// as in "/server.v1.ServerService/GetServer".
+type GRPCOptions struct {
+	// PublicMethods are served without a token, e.g. health checks.
+	PublicMethods []string
+	// MethodScopes lists the scopes a method requires, all of them. Other
+	// methods only require a valid token.
+	MethodScopes map[string][]string
+}
+
+// UnaryServerInterceptor verifies the bearer token in the "authorization"
+// metadata and stores the Principal in the handler's context.
+func UnaryServerInterceptor(v Verifier, opts GRPCOptions) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authorizeGRPC(ctx, v, opts, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming methods.
+func StreamServerInterceptor(v Verifier, opts GRPCOptions) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorizeGRPC(ss.Context(), v, opts, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+func authorizeGRPC(ctx context.Context, v Verifier, opts GRPCOptions, method string) (context.Context, error) {
+	if slices.Contains(opts.PublicMethods, method) {
+		return ctx, nil
+	}
+
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			token = bearerToken(values[0])
+		}
+	}
+
+	p, err := v.Verify(ctx, token)
+	if err == nil {
+		err = checkBinding(p, grpcPeerCertificates(ctx))
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	if scopes := opts.MethodScopes[method]; !p.HasAllScopes(scopes...) {
+		return nil, status.Errorf(codes.PermissionDenied, "%v: requires %v", ErrInsufficientScope, scopes)
+	}
+	return NewContext(ctx, p), nil
+}
+
+func grpcPeerCertificates(ctx context.Context) []*x509.Certificate {
+	pr, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := pr.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	return tlsInfo.State.PeerCertificates
+}
+
+func grpcError(err error) error {
+	code := codes.Unauthenticated
+	switch httpStatus(err) {
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,115 @@
+package authclient
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/th1enq/ViettelSMS_AuthenticationService/internal/domain/response"
+)
+
+// Middleware verifies the bearer token of every request and stores the
+// Principal in the request context, for FromContext. Failures are answered
+// in the auth service's APIResponse format.
+func Middleware(v Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, err := v.Verify(r.Context(), bearerToken(r.Header.Get("Authorization")))
+			if err == nil {
+				err = checkBinding(p, peerCertificates(r))
+			}
+			if err != nil {
+				writeError(w, err, nil)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), p)))
+		})
+	}
+}
+
+// RequireScopes must follow Middleware. It lets a request through only
+// when the token grants every scope.
+func RequireScopes(scopes ...string) func(http.Handler) http.Handler {
+	return requireHTTP(strings.Join(scopes, " && "), func(p *Principal) bool {
+		return p.HasAllScopes(scopes...)
+	})
+}
+
+// RequireAnyScope must follow Middleware. It lets a request through when
+// the token grants at least one of the scopes.
+func RequireAnyScope(scopes ...string) func(http.Handler) http.Handler {
+	return requireHTTP(strings.Join(scopes, " || "), func(p *Principal) bool {
+		return p.HasAnyScope(scopes...)
+	})
+}
+
+func requireHTTP(required string, granted func(*Principal) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := FromContext(r.Context())
+			if !ok {
+				writeError(w, ErrMissingToken, nil)
+				return
+			}
+			if !granted(p) {
+				writeError(w, ErrInsufficientScope, scopeDenial(required))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func bearerToken(header string) string {
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimPrefix(header, "Bearer ")
+}
+
+func peerCertificates(r *http.Request) []*x509.Certificate {
+	if r.TLS == nil {
+		return nil
+	}
+	return r.TLS.PeerCertificates
+}
+
+func scopeDenial(required string) map[string]string {
+	return map[string]string{"required": required}
+}
+
+// errorResponse builds the body the auth service's presenter would send for
+// err.
+func errorResponse(err error, details interface{}) (int, *response.APIResponse) {
+	status := httpStatus(err)
+
+	code, message := response.CodeUnauthorized, "Invalid token"
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		message = "Missing or malformed token"
+	case errors.Is(err, ErrTokenRevoked):
+		message = "Session revoked"
+	case errors.Is(err, ErrUserBlocked):
+		code, message = response.CodeForbidden, "User is blocked"
+	case errors.Is(err, ErrPasswordChangeRequired):
+		code, message = response.CodeForbidden, "Password change required"
+	case errors.Is(err, ErrInsufficientScope):
+		code, message = response.CodeForbidden, "Insufficient scope"
+	case errors.Is(err, ErrUnavailable):
+		code, message = response.CodeInternalServerError, "Authentication unavailable"
+	}
+
+	if details == nil {
+		details = err.Error()
+	}
+	return status, response.NewErrorResponse(code, message, details)
+}
+
+func writeError(w http.ResponseWriter, err error, details interface{}) {
+	status, body := errorResponse(err, details)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
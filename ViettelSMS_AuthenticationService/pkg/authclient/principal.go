@@ -0,0 +1,90 @@
+package authclient
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type principalKey struct{}
+
+// ginPrincipalKey is where the gin middleware stores the principal, next to
+// the request context.
+const ginPrincipalKey = "authclient.principal"
+
+// Principal is the verified holder of an access token: a user or, when
+// Machine is set, a machine identity.
+type Principal struct {
+	UserID    uint
+	Machine   string
+	SessionID string
+	Scopes    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// CertThumbprint is the RFC 8705 "x5t#S256" binding, if the token is
+	// bound to a client certificate.
+	CertThumbprint string
+}
+
+func (p *Principal) IsMachine() bool {
+	return p.Machine != ""
+}
+
+// HasScope reports whether the token grants scope. Tokens carry expanded
+// scopes, but one minted before the scope grammar changed may still hold a
+// wildcard such as "server:*", which is honoured here.
+func (p *Principal) HasScope(scope string) bool {
+	resource, action, _ := strings.Cut(scope, ":")
+	return slices.ContainsFunc(p.Scopes, func(granted string) bool {
+		grantedResource, grantedAction, _ := strings.Cut(granted, ":")
+		return (grantedResource == "*" || grantedResource == resource) &&
+			(grantedAction == "*" || grantedAction == action)
+	})
+}
+
+func (p *Principal) HasAllScopes(scopes ...string) bool {
+	for _, scope := range scopes {
+		if !p.HasScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Principal) HasAnyScope(scopes ...string) bool {
+	return slices.ContainsFunc(scopes, p.HasScope)
+}
+
+// NewContext returns a copy of ctx carrying p.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal stored by one of the middlewares or
+// interceptors.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// FromGin returns the principal stored by the gin middleware.
+func FromGin(c *gin.Context) (*Principal, bool) {
+	if value, ok := c.Get(ginPrincipalKey); ok {
+		p, ok := value.(*Principal)
+		return p, ok && p != nil
+	}
+	return FromContext(c.Request.Context())
+}
+
+// MustFromGin is FromGin for handlers behind the gin middleware, where a
+// missing principal is a wiring bug.
+func MustFromGin(c *gin.Context) *Principal {
+	p, ok := FromGin(c)
+	if !ok {
+		panic("authclient: no principal in gin context; is the middleware installed?")
+	}
+	return p
+}
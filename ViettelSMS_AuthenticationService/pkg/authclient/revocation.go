@@ -0,0 +1,178 @@
+package authclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SessionKeyPrefix is where the auth service keeps live sessions in Redis.
+// Revoking a session deletes its key.
+const SessionKeyPrefix = "auth:session:"
+
+// RevocationChecker tells whether the session behind a token is still live.
+// It is only consulted for tokens carrying a session ID.
+type RevocationChecker interface {
+	Active(ctx context.Context, token string, p *Principal) (bool, error)
+}
+
+type redisRevocation struct {
+	client redis.UniversalClient
+}
+
+// NewRedisRevocation checks sessions directly in the auth service's Redis.
+func NewRedisRevocation(client redis.UniversalClient) RevocationChecker {
+	return &redisRevocation{client: client}
+}
+
+func (r *redisRevocation) Active(ctx context.Context, _ string, p *Principal) (bool, error) {
+	n, err := r.client.Exists(ctx, SessionKeyPrefix+p.SessionID).Result()
+	if err != nil {
+		return false, fmt.Errorf("check session: %w", err)
+	}
+	return n > 0, nil
+}
+
+// TokenSource returns the bearer token a service presents to the
+// introspection endpoint, which requires the auth:authorize scope.
+type TokenSource func(ctx context.Context) (string, error)
+
+type introspection struct {
+	url      string
+	client   *http.Client
+	token    TokenSource
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]introspectionResult
+}
+
+type introspectionResult struct {
+	active  bool
+	expires time.Time
+}
+
+// NewIntrospection asks the auth service's POST /auth/introspect. Answers
+// are kept for cacheTTL, so a revocation takes that long to be noticed.
+func NewIntrospection(url string, client *http.Client, token TokenSource, cacheTTL time.Duration) RevocationChecker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &introspection{
+		url:      url,
+		client:   client,
+		token:    token,
+		cacheTTL: cacheTTL,
+		cache:    map[string]introspectionResult{},
+	}
+}
+
+func (i *introspection) Active(ctx context.Context, token string, p *Principal) (bool, error) {
+	sum := sha256.Sum256([]byte(token))
+	key := hex.EncodeToString(sum[:])
+	if active, ok := i.cached(key); ok {
+		return active, nil
+	}
+
+	active, err := i.introspect(ctx, token)
+	if err != nil {
+		return false, err
+	}
+
+	if i.cacheTTL > 0 {
+		expires := time.Now().Add(i.cacheTTL)
+		if !p.ExpiresAt.IsZero() && p.ExpiresAt.Before(expires) {
+			expires = p.ExpiresAt
+		}
+		i.store(key, introspectionResult{active: active, expires: expires})
+	}
+	return active, nil
+}
+
+func (i *introspection) introspect(ctx context.Context, token string) (bool, error) {
+	bearer, err := i.token(ctx)
+	if err != nil {
+		return false, fmt.Errorf("get introspection token: %w", err)
+	}
+
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+bearer)
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("introspect token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("introspect token: unexpected status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Active bool `json:"active"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("decode introspection response: %w", err)
+	}
+	return result.Active, nil
+}
+
+func (i *introspection) cached(key string) (bool, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	result, ok := i.cache[key]
+	if !ok || time.Now().After(result.expires) {
+		return false, false
+	}
+	return result.active, true
+}
+
+func (i *introspection) store(key string, result introspectionResult) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := time.Now()
+	for k, r := range i.cache {
+		if now.After(r.expires) {
+			delete(i.cache, k)
+		}
+	}
+	i.cache[key] = result
+}
+
+type fallbackRevocation struct {
+	checkers []RevocationChecker
+}
+
+// NewFallbackRevocation tries each checker in turn until one answers,
+// typically Redis first and introspection when Redis is unreachable.
+func NewFallbackRevocation(checkers ...RevocationChecker) RevocationChecker {
+	return &fallbackRevocation{checkers: checkers}
+}
+
+func (f *fallbackRevocation) Active(ctx context.Context, token string, p *Principal) (bool, error) {
+	var errs []error
+	for _, checker := range f.checkers {
+		active, err := checker.Active(ctx, token, p)
+		if err == nil {
+			return active, nil
+		}
+		errs = append(errs, err)
+	}
+	return false, fmt.Errorf("%w: %w", ErrUnavailable, errors.Join(errs...))
+}
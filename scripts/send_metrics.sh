@@ -23,14 +23,33 @@ if [ -z "$SERVER_ID" ] || [ -z "$INTERVAL_TIME" ]; then
     exit 1
 fi
 
+# Lấy access token gắn với chứng chỉ client (mTLS) nếu đã cấu hình AGENT_CERT/AGENT_KEY
+fetch_token() {
+    if [ -z "$AGENT_CERT" ] || [ -z "$AGENT_KEY" ] || [ -z "$AUTH_URL" ]; then
+        return 0
+    fi
+
+    ACCESS_TOKEN=$(curl -s -X POST "$AUTH_URL/auth/machine/token" \
+        --cert "$AGENT_CERT" \
+        --key "$AGENT_KEY" \
+        ${AGENT_CA:+--cacert "$AGENT_CA"} | jq -r '.data.access_token // empty')
+
+    if [ -z "$ACCESS_TOKEN" ]; then
+        echo "Không lấy được access token từ $AUTH_URL"
+    fi
+}
+
 # Hàm gửi metrics
 send_metrics() {
     local timestamp=$(date -u +"%Y-%m-%dT%H:%M:%S.000Z")
     
     echo "Gửi metrics"
 
+    fetch_token
+
     RESPONSE=$(curl -s -X POST http://$HOST_IP:80/healthcheck/monitoring \
         -H "Content-Type: application/json" \
+        ${ACCESS_TOKEN:+-H "Authorization: Bearer $ACCESS_TOKEN"} \
         -d "{
             \"server_id\": \"$SERVER_ID\",
             \"interval_time\": $INTERVAL_TIME,